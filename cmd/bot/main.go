@@ -1,28 +1,62 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
 	"github.com/PoluyanbIch/GoTgBot/internal/service"
 	"github.com/PoluyanbIch/GoTgBot/internal/telegram"
 )
 
 func main() {
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if token == "" {
-		log.Fatal("TELEGRAM_BOT_TOKEN environment variable is required")
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("loading config failed", "err", err)
+		os.Exit(1)
 	}
 
+	// Уровень хранится в LevelVar, чтобы /reloadconfig мог поменять его на лету
+	level := new(slog.LevelVar)
+	level.Set(cfg.Level())
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
 	// Автоматически выбирает Gist или Memory
-	leaderboardService := service.NewLeaderboardService()
+	leaderboardService, err := service.NewLeaderboardService(service.LeaderboardOptions{
+		FailFast:     cfg.LeaderboardFailFast,
+		GistCacheTTL: time.Duration(cfg.GistCacheTTL) * time.Second,
+		GistRetry: service.RetryPolicy{
+			Attempts:  cfg.GistRetryAttempts,
+			BaseDelay: time.Duration(cfg.GistRetryDelayMs) * time.Millisecond,
+		},
+		MinPercent: cfg.MinLeaderboardPercent,
+		Logger:     logger,
+	})
+	if err != nil {
+		logger.Error("creating leaderboard failed", "err", err)
+		os.Exit(1)
+	}
 
 	// Создаем бота
-	bot, err := telegram.NewBot(token, leaderboardService, "questions.txt")
+	bot, err := telegram.NewBot(cfg, leaderboardService, logger)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("creating bot failed", "err", err)
+		os.Exit(1)
 	}
+	bot.SetLogLevel(level)
+
+	// Останавливаем бота по SIGINT/SIGTERM, чтобы он не пытался переподключиться
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		logger.Info("shutting down")
+		bot.Stop()
+	}()
 
-	log.Println("🤖 Bot is starting...")
+	logger.Info("bot is starting")
 	bot.Start()
 }
@@ -2,4 +2,7 @@ module github.com/PoluyanbIch/GoTgBot
 
 go 1.25.2
 
-require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1 // indirect
+require (
+	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/mattn/go-sqlite3 v1.14.6
+)
@@ -0,0 +1,580 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Режимы обработки отредактированных команд
+const (
+	EditedCommandsReply  = "reply"  // ответить, что редактирование не поддерживается
+	EditedCommandsHandle = "handle" // выполнить команду как новую
+)
+
+// Порядок категорий в /categories
+const (
+	CategoriesSortCount = "count" // сначала категории с большим числом вопросов
+	CategoriesSortName  = "name"  // по алфавиту
+)
+
+// Оценка вопросов с несколькими правильными ответами
+const (
+	ScoringExact   = "exact"   // очки только за точный набор правильных вариантов
+	ScoringPartial = "partial" // очки за каждый правильный вариант минус неправильные
+)
+
+// Порядок вопросов в категории
+const (
+	OrderShuffled = "shuffled" // вопросы перемешиваются
+	OrderFile     = "file"     // вопросы идут в порядке файла
+)
+
+// Config содержит настройки бота. Значения читаются из переменных окружения,
+// а затем могут быть переопределены JSON-файлом из CONFIG_FILE
+type Config struct {
+	Token         string `json:"token"`
+	QuestionsFile string `json:"questions_file"`
+
+	// Debug - подробный лог запросов к Telegram API
+	Debug bool `json:"debug"`
+
+	// LogLevel - уровень логов: debug, info, warn или error. Пустой - debug при Debug, иначе info
+	LogLevel string `json:"log_level"`
+
+	// AdminIDs - Telegram ID пользователей с доступом к админ-командам
+	AdminIDs []int64 `json:"admin_ids"`
+
+	// PercentPrecision - количество знаков после запятой при выводе процентов
+	PercentPrecision int `json:"percent_precision"`
+
+	// MaxMessageLength - максимальная длина одного сообщения, более длинные разбиваются на части
+	MaxMessageLength int `json:"max_message_length"`
+
+	// HideCorrectAnswer - не показывать правильный ответ сразу после ошибки
+	HideCorrectAnswer bool `json:"hide_correct_answer"`
+
+	// PassPercentage - проходной процент для вердикта "Сдано/Не сдано", 0 - без вердикта
+	PassPercentage int `json:"pass_percentage"`
+
+	// RandomQuizMin и RandomQuizMax - диапазон длины викторины со случайным количеством вопросов
+	RandomQuizMin int `json:"random_quiz_min"`
+	RandomQuizMax int `json:"random_quiz_max"`
+
+	// QuizInDM - викторина, начатая в группе, продолжается в личных сообщениях
+	QuizInDM bool `json:"quiz_in_dm"`
+
+	// EditedCommands - что делать с отредактированной командой: EditedCommandsReply или EditedCommandsHandle
+	EditedCommands string `json:"edited_commands"`
+
+	// ExpectedOptionCount - ожидаемое количество вариантов ответа у вопросов, 0 - определить автоматически
+	ExpectedOptionCount int `json:"expected_option_count"`
+
+	// BonusQuestion - задавать бонусный вопрос в конце викторины
+	BonusQuestion bool `json:"bonus_question"`
+
+	// ManualContinue - следующий вопрос показывается по кнопке "Далее", а не автоматически
+	ManualContinue bool `json:"manual_continue"`
+
+	// QuizPollMode - вопросы с одним правильным ответом отправляются нативными опросами-викторинами Telegram
+	QuizPollMode bool `json:"quiz_poll_mode"`
+
+	// ShuffleQuestions - перемешивать вопросы; если выключено, вопросы идут в порядке файла
+	ShuffleQuestions bool `json:"shuffle_questions"`
+
+	// ShuffleOptions - перемешивать варианты ответа внутри каждого вопроса, чтобы нельзя было запомнить позиции
+	ShuffleOptions bool `json:"shuffle_options"`
+
+	// CelebrateTop - праздничное сообщение со случайным поздравлением за рекорд в топ-3
+	CelebrateTop bool `json:"celebrate_top"`
+
+	// MinAttempts - минимальное число пройденных викторин для попадания в топ, 0 - без ограничения
+	MinAttempts int `json:"min_attempts"`
+
+	// ChallengeTTLMinutes - сколько минут действует ссылка "Вызвать друга"
+	ChallengeTTLMinutes int `json:"challenge_ttl_minutes"`
+
+	// QuestionsPerPage - количество вопросов на странице /listq
+	QuestionsPerPage int `json:"questions_per_page"`
+
+	// CompositeTimePenalty - сколько процентов снимается за каждую секунду среднего времени ответа
+	// в рейтинге "Скорость и точность"
+	CompositeTimePenalty float64 `json:"composite_time_penalty"`
+
+	// CommandAliases - дополнительные псевдонимы команд: псевдоним -> команда (без "/")
+	CommandAliases map[string]string `json:"command_aliases"`
+
+	// MaxSessions - максимальное число одновременных викторин, 0 - без ограничения
+	MaxSessions int `json:"max_sessions"`
+
+	// MinLeaderboardPercent - минимальный процент правильных ответов, с которым результат попадает
+	// в лидерборд. 0 - сохраняются все результаты
+	MinLeaderboardPercent int `json:"min_leaderboard_percent"`
+
+	// LeaderboardFailFast - не запускать бота, если Gist лидерборда недоступен, вместо перехода на память
+	LeaderboardFailFast bool `json:"leaderboard_fail_fast"`
+
+	// AnswerRevealDelayMs - пауза в миллисекундах перед показом результата ответа, клавиатура
+	// на это время убирается, чтобы быстрое повторное нажатие не попало в следующий вопрос. 0 - без паузы
+	AnswerRevealDelayMs int `json:"answer_reveal_delay_ms"`
+
+	// AnswerDelayMs - пауза в миллисекундах между результатом ответа и следующим вопросом. 0 - без паузы
+	AnswerDelayMs int `json:"answer_delay_ms"`
+
+	// QuestionTimeLimit - время на ответ в секундах для вопросов без своего ограничения (time:N), 0 - без ограничения
+	QuestionTimeLimit int `json:"question_time_limit"`
+
+	// GistCacheTTL - сколько секунд используется прочитанный из Gist лидерборд, 0 - без кэша
+	GistCacheTTL int `json:"gist_cache_ttl"`
+
+	// GistRetryAttempts - сколько раз пробовать запрос к Gist при ошибках сети и ответах 5xx
+	GistRetryAttempts int `json:"gist_retry_attempts"`
+
+	// GistRetryDelayMs - пауза перед первым повтором в миллисекундах, дальше она удваивается
+	GistRetryDelayMs int `json:"gist_retry_delay_ms"`
+
+	// QuizQuestionCount - количество вопросов в обычной викторине, 0 - все доступные
+	QuizQuestionCount int `json:"quiz_question_count"`
+
+	// QuizSkips - сколько вопросов можно пропустить за викторину кнопкой "Пропустить", 0 - без пропусков
+	QuizSkips int `json:"quiz_skips"`
+
+	// CategoriesSort - порядок категорий в /categories: CategoriesSortCount или CategoriesSortName
+	CategoriesSort string `json:"categories_sort"`
+
+	// ScoringMode - оценка вопросов с несколькими правильными ответами: ScoringExact или ScoringPartial
+	ScoringMode string `json:"scoring_mode"`
+
+	// CategoryOrder - порядок вопросов в отдельных категориях (OrderShuffled или OrderFile),
+	// переопределяет ShuffleQuestions для викторин по этим категориям
+	CategoryOrder map[string]string `json:"category_order"`
+
+	// PersistSessions - сохранять незавершенные викторины в хранилище лидерборда и продолжать
+	// их после перезапуска. Каждый вопрос - запись в хранилище, поэтому по умолчанию выключено
+	PersistSessions bool `json:"persist_sessions"`
+
+	// StatsFlushInterval - раз в сколько секунд статистика ответов, накопленная в памяти, записывается
+	// в хранилище (и при остановке бота). 0 - запись на каждый ответ
+	StatsFlushInterval int `json:"stats_flush_interval"`
+
+	// StreakGraceDays - сколько пропущенных дней за неделю не обрывают серию дней с викториной
+	StreakGraceDays int `json:"streak_grace_days"`
+}
+
+// Load читает конфигурацию из переменных окружения и необязательного файла CONFIG_FILE
+func Load() (*Config, error) {
+	cfg, err := loadEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadEnv читает настройки из переменных окружения
+func loadEnv() (*Config, error) {
+	cfg := &Config{
+		Token:          os.Getenv("TELEGRAM_BOT_TOKEN"),
+		QuestionsFile:  getEnv("QUESTIONS_FILE", "questions.txt"),
+		EditedCommands: getEnv("EDITED_COMMANDS", EditedCommandsReply),
+		LogLevel:       os.Getenv("LOG_LEVEL"),
+		CategoriesSort: getEnv("CATEGORIES_SORT", CategoriesSortCount),
+		ScoringMode:    getEnv("SCORING_MODE", ScoringExact),
+	}
+
+	var err error
+	if cfg.Debug, err = getEnvBool("BOT_DEBUG", true); err != nil {
+		return nil, err
+	}
+	if cfg.AdminIDs, err = getEnvInt64List("BOT_ADMINS"); err != nil {
+		return nil, err
+	}
+	if cfg.PercentPrecision, err = getEnvInt("PERCENT_PRECISION", 0); err != nil {
+		return nil, err
+	}
+	if cfg.MaxMessageLength, err = getEnvInt("MAX_MESSAGE_LENGTH", 4096); err != nil {
+		return nil, err
+	}
+	if cfg.HideCorrectAnswer, err = getEnvBool("HIDE_CORRECT_ANSWER", false); err != nil {
+		return nil, err
+	}
+	if cfg.PassPercentage, err = getEnvInt("PASS_PERCENTAGE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.RandomQuizMin, err = getEnvInt("RANDOM_QUIZ_MIN", 5); err != nil {
+		return nil, err
+	}
+	if cfg.RandomQuizMax, err = getEnvInt("RANDOM_QUIZ_MAX", 15); err != nil {
+		return nil, err
+	}
+	if cfg.QuizInDM, err = getEnvBool("QUIZ_IN_DM", false); err != nil {
+		return nil, err
+	}
+	if cfg.ExpectedOptionCount, err = getEnvInt("EXPECTED_OPTION_COUNT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.BonusQuestion, err = getEnvBool("BONUS_QUESTION", false); err != nil {
+		return nil, err
+	}
+	if cfg.ManualContinue, err = getEnvBool("MANUAL_CONTINUE", false); err != nil {
+		return nil, err
+	}
+	if cfg.QuizPollMode, err = getEnvBool("QUIZ_POLL_MODE", false); err != nil {
+		return nil, err
+	}
+	if cfg.ShuffleQuestions, err = getEnvBool("SHUFFLE_QUESTIONS", true); err != nil {
+		return nil, err
+	}
+	if cfg.PersistSessions, err = getEnvBool("PERSIST_SESSIONS", false); err != nil {
+		return nil, err
+	}
+	if cfg.StatsFlushInterval, err = getEnvInt("STATS_FLUSH_INTERVAL", 30); err != nil {
+		return nil, err
+	}
+	if cfg.StreakGraceDays, err = getEnvInt("STREAK_GRACE_DAYS", 1); err != nil {
+		return nil, err
+	}
+	if cfg.ShuffleOptions, err = getEnvBool("SHUFFLE_OPTIONS", false); err != nil {
+		return nil, err
+	}
+	if cfg.CelebrateTop, err = getEnvBool("CELEBRATE_TOP", false); err != nil {
+		return nil, err
+	}
+	if cfg.MinAttempts, err = getEnvInt("MIN_ATTEMPTS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ChallengeTTLMinutes, err = getEnvInt("CHALLENGE_TTL_MINUTES", 24*60); err != nil {
+		return nil, err
+	}
+	if cfg.QuestionsPerPage, err = getEnvInt("QUESTIONS_PER_PAGE", 20); err != nil {
+		return nil, err
+	}
+	if cfg.CompositeTimePenalty, err = getEnvFloat("COMPOSITE_TIME_PENALTY", 1); err != nil {
+		return nil, err
+	}
+	if cfg.CommandAliases, err = getEnvStringMap("COMMAND_ALIASES"); err != nil {
+		return nil, err
+	}
+	if cfg.CategoryOrder, err = getEnvStringMap("CATEGORY_ORDER"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxSessions, err = getEnvInt("MAX_SESSIONS", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.MinLeaderboardPercent, err = getEnvInt("MIN_LEADERBOARD_PERCENT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.LeaderboardFailFast, err = getEnvBool("LEADERBOARD_FAIL_FAST", false); err != nil {
+		return nil, err
+	}
+	if cfg.AnswerRevealDelayMs, err = getEnvInt("ANSWER_REVEAL_DELAY_MS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.AnswerDelayMs, err = getEnvInt("QUIZ_ANSWER_DELAY_MS", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.QuestionTimeLimit, err = getEnvInt("QUESTION_TIME_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.GistCacheTTL, err = getEnvInt("GIST_CACHE_TTL", 30); err != nil {
+		return nil, err
+	}
+	if cfg.GistRetryAttempts, err = getEnvInt("GIST_RETRY_ATTEMPTS", 3); err != nil {
+		return nil, err
+	}
+	if cfg.GistRetryDelayMs, err = getEnvInt("GIST_RETRY_DELAY_MS", 500); err != nil {
+		return nil, err
+	}
+	if cfg.QuizQuestionCount, err = getEnvInt("QUIZ_QUESTION_COUNT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.QuizSkips, err = getEnvInt("QUIZ_SKIPS", 3); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile переопределяет настройки значениями из JSON-файла
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// validate проверяет, что значения настроек допустимы
+func (c *Config) validate() error {
+	if c.Token == "" {
+		return fmt.Errorf("TELEGRAM_BOT_TOKEN environment variable is required")
+	}
+	if c.PercentPrecision < 0 || c.PercentPrecision > 2 {
+		return fmt.Errorf("percent precision must be between 0 and 2, got %d", c.PercentPrecision)
+	}
+	if c.MaxMessageLength <= 0 || c.MaxMessageLength > 4096 {
+		return fmt.Errorf("max message length must be between 1 and 4096, got %d", c.MaxMessageLength)
+	}
+	if c.PassPercentage < 0 || c.PassPercentage > 100 {
+		return fmt.Errorf("pass percentage must be between 0 and 100, got %d", c.PassPercentage)
+	}
+	if c.RandomQuizMin < 1 || c.RandomQuizMax < c.RandomQuizMin {
+		return fmt.Errorf("invalid random quiz range %d-%d", c.RandomQuizMin, c.RandomQuizMax)
+	}
+	if c.MinAttempts < 0 {
+		return fmt.Errorf("min attempts must not be negative, got %d", c.MinAttempts)
+	}
+	if c.ChallengeTTLMinutes <= 0 {
+		return fmt.Errorf("challenge TTL must be positive, got %d minutes", c.ChallengeTTLMinutes)
+	}
+	if c.QuestionsPerPage <= 0 {
+		return fmt.Errorf("questions per page must be positive, got %d", c.QuestionsPerPage)
+	}
+	if c.CompositeTimePenalty < 0 {
+		return fmt.Errorf("composite time penalty must not be negative, got %g", c.CompositeTimePenalty)
+	}
+	if c.MaxSessions < 0 {
+		return fmt.Errorf("max sessions must not be negative, got %d", c.MaxSessions)
+	}
+	if c.AnswerRevealDelayMs < 0 {
+		return fmt.Errorf("answer reveal delay must not be negative, got %d", c.AnswerRevealDelayMs)
+	}
+	if c.AnswerDelayMs < 0 {
+		return fmt.Errorf("answer delay must not be negative, got %d", c.AnswerDelayMs)
+	}
+	if c.QuestionTimeLimit < 0 {
+		return fmt.Errorf("question time limit must not be negative, got %d", c.QuestionTimeLimit)
+	}
+	if c.MinLeaderboardPercent < 0 || c.MinLeaderboardPercent > 100 {
+		return fmt.Errorf("min leaderboard percent must be between 0 and 100, got %d", c.MinLeaderboardPercent)
+	}
+	if c.StreakGraceDays < 0 || c.StreakGraceDays >= 7 {
+		return fmt.Errorf("streak grace days must be between 0 and 6, got %d", c.StreakGraceDays)
+	}
+	if c.StatsFlushInterval < 0 {
+		return fmt.Errorf("stats flush interval must not be negative, got %d", c.StatsFlushInterval)
+	}
+	if c.GistCacheTTL < 0 {
+		return fmt.Errorf("gist cache TTL must not be negative, got %d", c.GistCacheTTL)
+	}
+	if c.GistRetryAttempts <= 0 {
+		return fmt.Errorf("gist retry attempts must be positive, got %d", c.GistRetryAttempts)
+	}
+	if c.GistRetryDelayMs < 0 {
+		return fmt.Errorf("gist retry delay must not be negative, got %d", c.GistRetryDelayMs)
+	}
+	if c.QuizSkips < 0 {
+		return fmt.Errorf("quiz skips must not be negative, got %d", c.QuizSkips)
+	}
+	if c.QuizQuestionCount < 0 {
+		return fmt.Errorf("quiz question count must not be negative, got %d", c.QuizQuestionCount)
+	}
+	if c.ExpectedOptionCount < 0 {
+		return fmt.Errorf("expected option count must not be negative, got %d", c.ExpectedOptionCount)
+	}
+	if c.LogLevel != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+			return fmt.Errorf("invalid log level %q", c.LogLevel)
+		}
+	}
+	if c.CategoriesSort != CategoriesSortCount && c.CategoriesSort != CategoriesSortName {
+		return fmt.Errorf("categories sort must be %q or %q, got %q",
+			CategoriesSortCount, CategoriesSortName, c.CategoriesSort)
+	}
+	if c.EditedCommands != EditedCommandsReply && c.EditedCommands != EditedCommandsHandle {
+		return fmt.Errorf("edited commands mode must be %q or %q, got %q",
+			EditedCommandsReply, EditedCommandsHandle, c.EditedCommands)
+	}
+	if c.ScoringMode != ScoringExact && c.ScoringMode != ScoringPartial {
+		return fmt.Errorf("scoring mode must be %q or %q, got %q", ScoringExact, ScoringPartial, c.ScoringMode)
+	}
+	for category, order := range c.CategoryOrder {
+		if order != OrderShuffled && order != OrderFile {
+			return fmt.Errorf("order of category %q must be %q or %q, got %q", category, OrderShuffled, OrderFile, order)
+		}
+	}
+	return nil
+}
+
+// ShuffleCategory сообщает, перемешивать ли вопросы викторины по категории category
+func (c *Config) ShuffleCategory(category string) bool {
+	switch c.CategoryOrder[category] {
+	case OrderShuffled:
+		return true
+	case OrderFile:
+		return false
+	default:
+		return c.ShuffleQuestions
+	}
+}
+
+// RestartRequired возвращает названия настроек, которые отличаются от other,
+// но не могут быть применены без перезапуска бота
+func (c *Config) RestartRequired(other *Config) []string {
+	var fields []string
+	if c.Token != other.Token {
+		fields = append(fields, "token")
+	}
+	if c.QuestionsFile != other.QuestionsFile {
+		fields = append(fields, "questions_file")
+	}
+	if c.MinLeaderboardPercent != other.MinLeaderboardPercent {
+		fields = append(fields, "min_leaderboard_percent")
+	}
+	if c.LeaderboardFailFast != other.LeaderboardFailFast {
+		fields = append(fields, "leaderboard_fail_fast")
+	}
+	if c.GistCacheTTL != other.GistCacheTTL {
+		fields = append(fields, "gist_cache_ttl")
+	}
+	if c.GistRetryAttempts != other.GistRetryAttempts {
+		fields = append(fields, "gist_retry_attempts")
+	}
+	if c.GistRetryDelayMs != other.GistRetryDelayMs {
+		fields = append(fields, "gist_retry_delay_ms")
+	}
+	if c.StatsFlushInterval != other.StatsFlushInterval {
+		fields = append(fields, "stats_flush_interval")
+	}
+	return fields
+}
+
+// KeepRestartFields переносит из old настройки, которые применяются только при запуске,
+// чтобы перезагруженная конфигурация соответствовала тому, как бот на самом деле работает
+func (c *Config) KeepRestartFields(old *Config) {
+	c.Token = old.Token
+	c.QuestionsFile = old.QuestionsFile
+	c.MinLeaderboardPercent = old.MinLeaderboardPercent
+	c.LeaderboardFailFast = old.LeaderboardFailFast
+	c.GistCacheTTL = old.GistCacheTTL
+	c.GistRetryAttempts = old.GistRetryAttempts
+	c.GistRetryDelayMs = old.GistRetryDelayMs
+	c.StatsFlushInterval = old.StatsFlushInterval
+}
+
+// Level возвращает уровень логов: LogLevel, а если он не задан - debug при Debug, иначе info
+func (c *Config) Level() slog.Level {
+	var level slog.Level
+	if c.LogLevel != "" && level.UnmarshalText([]byte(c.LogLevel)) == nil {
+		return level
+	}
+	if c.Debug {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// getEnv возвращает значение переменной окружения или значение по умолчанию
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvInt читает целое число из переменной окружения
+func getEnvInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return n, nil
+}
+
+// getEnvFloat читает дробное число из переменной окружения
+func getEnvFloat(key string, def float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return f, nil
+}
+
+// getEnvBool читает логическое значение из переменной окружения
+func getEnvBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return b, nil
+}
+
+// getEnvInt64List читает список чисел через запятую из переменной окружения
+func getEnvInt64List(key string) ([]int64, error) {
+	var values []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// getEnvStringMap читает пары вида "ключ=значение" через запятую из переменной окружения
+func getEnvStringMap(key string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid %s: expected key=value, got %q", key, part)
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+// IsAdmin проверяет, есть ли пользователь в списке администраторов
+func (c *Config) IsAdmin(userID int64) bool {
+	for _, id := range c.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+func TestRestartRequired(t *testing.T) {
+	base := Config{
+		Token:                 "token",
+		QuestionsFile:         "questions.txt",
+		MinLeaderboardPercent: 10,
+		GistCacheTTL:          30,
+		GistRetryAttempts:     3,
+		GistRetryDelayMs:      500,
+	}
+
+	tests := []struct {
+		field  string
+		change func(c *Config)
+	}{
+		{"token", func(c *Config) { c.Token = "other" }},
+		{"questions_file", func(c *Config) { c.QuestionsFile = "other.txt" }},
+		{"min_leaderboard_percent", func(c *Config) { c.MinLeaderboardPercent = 50 }},
+		{"leaderboard_fail_fast", func(c *Config) { c.LeaderboardFailFast = true }},
+		{"gist_cache_ttl", func(c *Config) { c.GistCacheTTL = 0 }},
+		{"gist_retry_attempts", func(c *Config) { c.GistRetryAttempts = 5 }},
+		{"gist_retry_delay_ms", func(c *Config) { c.GistRetryDelayMs = 100 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			changed := base
+			tt.change(&changed)
+
+			if got := base.RestartRequired(&changed); !slices.Equal(got, []string{tt.field}) {
+				t.Errorf("RestartRequired = %v, want [%s]", got, tt.field)
+			}
+
+			// После KeepRestartFields перезапуск больше не нужен
+			changed.KeepRestartFields(&base)
+			if got := base.RestartRequired(&changed); len(got) != 0 {
+				t.Errorf("after KeepRestartFields RestartRequired = %v, want none", got)
+			}
+		})
+	}
+
+	// Настройки, которые применяются на лету, перезапуска не требуют
+	live := base
+	live.Debug = true
+	live.LogLevel = "warn"
+	live.AnswerDelayMs = 0
+	live.QuizQuestionCount = 5
+	if got := base.RestartRequired(&live); len(got) != 0 {
+		t.Errorf("RestartRequired for live settings = %v, want none", got)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		logLevel string
+		debug    bool
+		want     slog.Level
+	}{
+		{"", false, slog.LevelInfo},
+		{"", true, slog.LevelDebug},
+		{"warn", true, slog.LevelWarn},
+		{"ERROR", false, slog.LevelError},
+	}
+	for _, tt := range tests {
+		cfg := Config{LogLevel: tt.logLevel, Debug: tt.debug}
+		if got := cfg.Level(); got != tt.want {
+			t.Errorf("Level(%q, debug=%t) = %v, want %v", tt.logLevel, tt.debug, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidLogLevel(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "token")
+	t.Setenv("LOG_LEVEL", "verbose")
+
+	if _, err := Load(); err == nil {
+		t.Error("Load accepted an invalid log level")
+	}
+}
+
+func TestLoadScoringMode(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "token")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ScoringMode != ScoringExact {
+		t.Errorf("default scoring mode = %q, want %q", cfg.ScoringMode, ScoringExact)
+	}
+
+	t.Setenv("SCORING_MODE", "generous")
+	if _, err := Load(); err == nil {
+		t.Error("Load accepted an unknown scoring mode")
+	}
+}
+
+func TestShuffleCategory(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "token")
+	t.Setenv("SHUFFLE_QUESTIONS", "false")
+	t.Setenv("CATEGORY_ORDER", "История=shuffled, Наука=file")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for category, want := range map[string]bool{"История": true, "Наука": false, "Кино": false} {
+		if got := cfg.ShuffleCategory(category); got != want {
+			t.Errorf("ShuffleCategory(%q) = %t, want %t", category, got, want)
+		}
+	}
+
+	t.Setenv("CATEGORY_ORDER", "История=random")
+	if _, err := Load(); err == nil {
+		t.Error("Load accepted an unknown category order")
+	}
+}
+
+func TestLoadAnswerDelay(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "token")
+	t.Setenv("QUIZ_ANSWER_DELAY_MS", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AnswerDelayMs != 1000 {
+		t.Errorf("default answer delay = %d, want 1000", cfg.AnswerDelayMs)
+	}
+
+	for value, want := range map[string]int{"250": 250, "0": 0} {
+		t.Setenv("QUIZ_ANSWER_DELAY_MS", value)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("QUIZ_ANSWER_DELAY_MS=%s: %v", value, err)
+		}
+		if cfg.AnswerDelayMs != want {
+			t.Errorf("QUIZ_ANSWER_DELAY_MS=%s: delay = %d, want %d", value, cfg.AnswerDelayMs, want)
+		}
+	}
+
+	for _, value := range []string{"-1", "секунда"} {
+		t.Setenv("QUIZ_ANSWER_DELAY_MS", value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Load accepted QUIZ_ANSWER_DELAY_MS=%s", value)
+		}
+	}
+}
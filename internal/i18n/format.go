@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// numberFormat - правила записи чисел и дат языка
+type numberFormat struct {
+	decimal  string             // десятичный разделитель
+	dateTime string             // формат даты и времени для time.Format
+	ordinal  func(n int) string // место в рейтинге: "2" в "на 2 месте", "2nd" в английском
+}
+
+var formats = map[string]numberFormat{
+	"ru": {decimal: ",", dateTime: "02.01.2006 15:04", ordinal: strconv.Itoa},
+	"en": {decimal: ".", dateTime: "Jan 2, 2006 15:04", ordinal: englishOrdinal},
+}
+
+// englishOrdinal возвращает порядковое числительное: 1st, 2nd, 3rd, 4th, 11th, 21st
+func englishOrdinal(n int) string {
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// format возвращает правила языка lang, для неизвестных языков - DefaultLanguage
+func (l *Localizer) format(lang string) numberFormat {
+	if f, exists := formats[l.Language(lang)]; exists {
+		return f
+	}
+	return formats[DefaultLanguage]
+}
+
+// Number заменяет десятичную точку в числе, записанном через strconv, разделителем языка lang
+func (l *Localizer) Number(lang, number string) string {
+	return strings.Replace(number, ".", l.format(lang).decimal, 1)
+}
+
+// DateTime записывает дату и время в местном часовом поясе в формате языка lang
+func (l *Localizer) DateTime(lang string, t time.Time) string {
+	return t.Local().Format(l.format(lang).dateTime)
+}
+
+// Ordinal записывает место n в рейтинге по правилам языка lang
+func (l *Localizer) Ordinal(lang string, n int) string {
+	return l.format(lang).ordinal(n)
+}
@@ -0,0 +1,59 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrdinal(t *testing.T) {
+	l := NewLocalizer()
+	tests := []struct {
+		lang string
+		n    int
+		want string
+	}{
+		{"en", 1, "1st"},
+		{"en", 2, "2nd"},
+		{"en", 3, "3rd"},
+		{"en", 4, "4th"},
+		{"en", 11, "11th"},
+		{"en", 12, "12th"},
+		{"en", 13, "13th"},
+		{"en", 21, "21st"},
+		{"en", 102, "102nd"},
+		{"en", 111, "111th"},
+		{"ru", 2, "2"},
+		{"", 3, "3"},
+		{"de", 3, "3"},
+	}
+	for _, tt := range tests {
+		if got := l.Ordinal(tt.lang, tt.n); got != tt.want {
+			t.Errorf("Ordinal(%q, %d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	l := NewLocalizer()
+	if got := l.Number("ru", "83.33"); got != "83,33" {
+		t.Errorf("ru = %q, want 83,33", got)
+	}
+	if got := l.Number("en-US", "83.33"); got != "83.33" {
+		t.Errorf("en = %q, want 83.33", got)
+	}
+	if got := l.Number("ru", "83"); got != "83" {
+		t.Errorf("integer = %q, want 83", got)
+	}
+}
+
+func TestDateTime(t *testing.T) {
+	l := NewLocalizer()
+	date := time.Date(2024, time.March, 5, 14, 7, 0, 0, time.Local)
+
+	if got := l.DateTime("ru", date); got != "05.03.2024 14:07" {
+		t.Errorf("ru = %q", got)
+	}
+	if got := l.DateTime("en", date); got != "Mar 5, 2024 14:07" {
+		t.Errorf("en = %q", got)
+	}
+}
@@ -0,0 +1,629 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key - ключ сообщения бота
+type Key string
+
+// Ключи сообщений главного меню
+const (
+	MenuTitle        Key = "menu_title"
+	MenuNoQuestions  Key = "menu_no_questions"
+	ButtonQuiz       Key = "button_quiz"
+	ButtonRandom     Key = "button_random"
+	ButtonPractice   Key = "button_practice"
+	ButtonBoard      Key = "button_leaderboard"
+	ButtonActive     Key = "button_active"
+	ButtonComposite  Key = "button_composite"
+	ButtonMyStats    Key = "button_my_stats"
+	ButtonInfo       Key = "button_info"
+	ButtonBackToMenu Key = "button_back_to_menu"
+)
+
+// Ключи сообщений викторины
+const (
+	QuestionHeader       Key = "question_header"
+	BonusQuestionHeader  Key = "bonus_question_header"
+	PracticeHeader       Key = "practice_header"
+	ButtonConfirm        Key = "button_confirm"
+	ButtonStopPractice   Key = "button_stop_practice"
+	ButtonExitQuiz       Key = "button_exit_quiz"
+	ButtonSkip           Key = "button_skip"
+	ButtonFiftyFifty     Key = "button_fifty_fifty"
+	ButtonResetOrder     Key = "button_reset_order"
+	OrderHint            Key = "order_hint"
+	ButtonNext           Key = "button_next"
+	AnswerCorrect        Key = "answer_correct"
+	AnswerCorrectBonus   Key = "answer_correct_bonus"
+	AnswerPoints         Key = "answer_points"
+	AnswerWrong          Key = "answer_wrong"
+	CorrectAnswer        Key = "correct_answer"
+	TimeUp               Key = "time_up"
+	QuestionSkipped      Key = "question_skipped"
+	Streak               Key = "streak"
+	StreakBonus          Key = "streak_bonus"
+	QuizPaused           Key = "quiz_paused"
+	QuizExited           Key = "quiz_exited"
+	QuizFinished         Key = "quiz_finished"
+	QuizBonusPoints      Key = "quiz_bonus_points"
+	QuizBestStreak       Key = "quiz_best_streak"
+	QuizSaveFailed       Key = "quiz_save_failed"
+	QuizNotRanked        Key = "quiz_not_ranked"
+	ButtonRestart        Key = "button_restart"
+	ButtonReview         Key = "button_review"
+	ButtonSameQuestions  Key = "button_same_questions"
+	ButtonChallenge      Key = "button_challenge"
+	ButtonStartQuiz      Key = "button_start_quiz"
+	ButtonMainMenu       Key = "button_main_menu"
+	NoQuestions          Key = "no_questions"
+	SessionLimitReached  Key = "session_limit_reached"
+	PracticeFinished     Key = "practice_finished"
+	ButtonMorePractice   Key = "button_more_practice"
+	LeaderboardEmpty     Key = "leaderboard_empty"
+	LeaderboardTop       Key = "leaderboard_top"
+	LeaderboardTopWeek   Key = "leaderboard_top_week"
+	LeaderboardTopMonth  Key = "leaderboard_top_month"
+	LeaderboardPlayers   Key = "leaderboard_players"
+	LeaderboardYou       Key = "leaderboard_you"
+	LeaderboardAllTime   Key = "leaderboard_all_time"
+	LeaderboardWeek      Key = "leaderboard_week"
+	LeaderboardMonth     Key = "leaderboard_month"
+	LeaderboardHidden    Key = "leaderboard_hidden"
+	LeaderboardUnavail   Key = "leaderboard_unavailable"
+	ActiveLeaderboard    Key = "active_leaderboard"
+	ActiveLeaderboardNil Key = "active_leaderboard_empty"
+	QuizzesPlayed        Key = "quizzes_played"
+	CompositeEmpty       Key = "composite_empty"
+	CompositeTitle       Key = "composite_title"
+	CompositeDetails     Key = "composite_details"
+	RankMissing          Key = "rank_missing"
+	RankPosition         Key = "rank_position"
+)
+
+// Ключи сообщений категорий
+const (
+	CategoriesTitle Key = "categories_title"
+	CategoriesItem  Key = "categories_item"
+	CategoriesOther Key = "categories_other"
+	CategoriesNone  Key = "categories_none"
+
+	PracticeChooseCategory Key = "practice_choose_category"
+	PracticeAllCategories  Key = "practice_all_categories"
+
+	DailyQuizIntro Key = "daily_quiz_intro"
+)
+
+// Ключи общих сообщений: команды, запуск викторины, итоги
+const (
+	UnknownCommand      Key = "unknown_command"
+	CommandSuggestion   Key = "command_suggestion"
+	EditedCommand       Key = "edited_command"
+	NoActiveQuiz        Key = "no_active_quiz"
+	NoPausedQuiz        Key = "no_paused_quiz"
+	NoPreviousQuestions Key = "no_previous_questions"
+	NoTaggedQuestions   Key = "no_tagged_questions"
+	DMContinue          Key = "dm_continue"
+	DMInstructions      Key = "dm_instructions"
+	ButtonOpenBot       Key = "button_open_bot"
+	DMSent              Key = "dm_sent"
+	NewRecord           Key = "new_record"
+	Celebration1        Key = "celebration_1"
+	Celebration2        Key = "celebration_2"
+	Celebration3        Key = "celebration_3"
+	Celebration4        Key = "celebration_4"
+	QuizPassed          Key = "quiz_passed"
+	QuizFailed          Key = "quiz_failed"
+	InfoText            Key = "info_text"
+	ButtonRepository    Key = "button_repository"
+	ButtonAuthor        Key = "button_author"
+	ButtonWrite         Key = "button_write"
+	ButtonBack          Key = "button_back"
+)
+
+// Ключи категорий, разбора ответов, ошибок и вызовов
+const (
+	ChooseCategory     Key = "choose_category"
+	AllCategories      Key = "all_categories"
+	CategoryNotFound   Key = "category_not_found"
+	NoMistakes         Key = "no_mistakes"
+	MistakesHeader     Key = "mistakes_header"
+	MistakesLine       Key = "mistakes_line"
+	MistakesFooter     Key = "mistakes_footer"
+	ReviewUnavailable  Key = "review_unavailable"
+	ReviewCorrect      Key = "review_correct"
+	ReviewWrong        Key = "review_wrong"
+	ReviewAnswer       Key = "review_answer"
+	ChallengeShareText Key = "challenge_share_text"
+	ChallengeNotFound  Key = "challenge_not_found"
+	ChallengeOwn       Key = "challenge_own"
+	ChallengeIntro     Key = "challenge_intro"
+	ChallengeExpired   Key = "challenge_expired"
+	ChallengeSummary   Key = "challenge_summary"
+	ChallengeLostTo    Key = "challenge_lost_to"
+	ChallengeWon       Key = "challenge_won"
+	ChallengeBeat      Key = "challenge_beat"
+	ChallengeLost      Key = "challenge_lost"
+	ChallengeDraw      Key = "challenge_draw"
+)
+
+// Ключи статистики, поиска и видимости лидерборда
+const (
+	VisibilityGroupsOnly Key = "visibility_groups_only"
+	VisibilityAdminsOnly Key = "visibility_admins_only"
+	LeaderboardHiddenSet Key = "leaderboard_hidden_set"
+	LeaderboardShown     Key = "leaderboard_shown"
+	NoStats              Key = "no_stats"
+	StatsText            Key = "stats_text"
+	StatsBonus           Key = "stats_bonus"
+	FindUsage            Key = "find_usage"
+	PlayerNotFound       Key = "player_not_found"
+	FindResults          Key = "find_results"
+)
+
+// Ключи команд администратора
+const (
+	AdminOnly               Key = "admin_only"
+	ShowQuestionUsage       Key = "showq_usage"
+	ShowQuestionHeader      Key = "showq_header"
+	ShowQuestionOrder       Key = "showq_order"
+	ShowQuestionCorrect     Key = "showq_correct"
+	ShowQuestionCategory    Key = "showq_category"
+	ShowQuestionTags        Key = "showq_tags"
+	ShowQuestionDifficulty  Key = "showq_difficulty"
+	ShowQuestionExplanation Key = "showq_explanation"
+	ShowQuestionRetired     Key = "showq_retired"
+	QuestionNotFound        Key = "question_not_found"
+	NoAnswerTimes           Key = "no_answer_times"
+	AnswerTimesHeader       Key = "answer_times_header"
+	AnswerTimesLine         Key = "answer_times_line"
+	ConfigReloadFailed      Key = "config_reload_failed"
+	ConfigReloaded          Key = "config_reloaded"
+	ConfigRestartRequired   Key = "config_restart_required"
+	ConfigBackendNote       Key = "config_backend_note"
+	QuestionsReloadFailed   Key = "questions_reload_failed"
+	QuestionsReloaded       Key = "questions_reloaded"
+	StatusText              Key = "status_text"
+	OptionsConsistent       Key = "options_consistent"
+	OptionsMismatch         Key = "options_mismatch"
+	PreviewUsage            Key = "preview_usage"
+	PreviewHeader           Key = "preview_header"
+	PreviewShortage         Key = "preview_shortage"
+	ListQuestionsUsage      Key = "listq_usage"
+	ListQuestionsHeader     Key = "listq_header"
+)
+
+// Ключи вопросов с несколькими правильными ответами
+const (
+	MultiHint          Key = "multi_hint"
+	ButtonSubmitChoice Key = "button_submit_choice"
+	AnswerPartial      Key = "answer_partial"
+)
+
+// Ключи /showq для вопросов с несколькими ответами
+const (
+	ShowQuestionCorrectSet Key = "showq_correct_set"
+)
+
+// Ключи настроек и уведомлений об обгоне
+const (
+	SettingsTitle       Key = "settings_title"
+	SettingsPrivateOnly Key = "settings_private_only"
+	ButtonSettings      Key = "button_settings"
+	ButtonOvertakeOn    Key = "button_overtake_on"
+	ButtonOvertakeOff   Key = "button_overtake_off"
+	OvertakeAlert       Key = "overtake_alert"
+)
+
+// Ключи восстановления викторин после перезапуска
+const (
+	SessionRecovered Key = "session_recovered"
+)
+
+// Серия дней с викториной в /stats
+const (
+	StatsDailyStreak Key = "stats_daily_streak"
+)
+
+// DefaultLanguage - язык, на который переводятся неизвестные языки и недостающие ключи
+const DefaultLanguage = "ru"
+
+var ru = map[Key]string{
+	MenuTitle:        "📋 *Главное меню*",
+	MenuNoQuestions:  "⚠️ Вопросы временно недоступны",
+	ButtonQuiz:       "🐖Харам тест🐖",
+	ButtonRandom:     "🎲 Случайная длина",
+	ButtonPractice:   "📚 Тренировка",
+	ButtonBoard:      "🏆 Лидерборд",
+	ButtonActive:     "🏃 Самые активные",
+	ButtonComposite:  "⚡ Скорость и точность",
+	ButtonMyStats:    "📊 Моя статистика",
+	ButtonInfo:       "ℹ️Обо мнеℹ️",
+	ButtonBackToMenu: "🔙 В меню",
+
+	QuestionHeader:      "❓ *Вопрос %d/%d*\n\n%s",
+	BonusQuestionHeader: "⭐ *Бонусный вопрос*\n\n%s\n\n_Ошибка не снизит результат_",
+	PracticeHeader:      "📚 *Тренировка* · вопрос %d\n\n%s",
+	ButtonConfirm:       "✔️ Подтвердить",
+	ButtonStopPractice:  "⏹ Стоп",
+	ButtonExitQuiz:      "🚪Выйти из викторины🚪",
+	ButtonSkip:          "⏭ Пропустить (%d)",
+	ButtonFiftyFifty:    "🎲 50/50",
+	ButtonResetOrder:    "↩️ Сбросить порядок",
+	OrderHint:           "\n\n🔢 Нажимайте варианты по порядку",
+	ButtonNext:          "➡ Далее",
+	AnswerCorrect:       "✅ *Правильно!* 🎉",
+	AnswerCorrectBonus:  "⭐ *Правильно!* +1 бонусное очко 🎉",
+	AnswerPoints:        " +%d очка",
+	AnswerWrong:         "❌ *Неправильно!*",
+	CorrectAnswer:       "\nПравильный ответ: %s",
+	TimeUp:              "⏰ *Время вышло!*",
+	QuestionSkipped:     "⏭ Вопрос пропущен, осталось пропусков: %d",
+	Streak:              "\n🔥 Серия: %d",
+	StreakBonus:         " (+%d бонусное очко)",
+	QuizPaused:          "⏸ Викторина на паузе. Чтобы продолжить, отправьте /resume",
+	QuizExited:          "🚪 Викторина прервана.\nВаш результат не сохранен.",
+	QuizFinished:        "🏁 *Викторина завершена!*\n\n📊 Результат: %d/%d\n📈 Процент правильных: %s%%\n\n",
+	QuizBonusPoints:     "⭐ Бонусные очки: %d\n\n",
+	QuizBestStreak:      "🔥 Лучшая серия: %d\n\n",
+	QuizSaveFailed:      "⚠️ Не удалось сохранить результат: лидерборд временно недоступен\n\n",
+	QuizNotRanked:       "📉 В лидерборд попадают результаты от %d%%\n\n",
+	ButtonRestart:       "🎯 Начать заново",
+	ButtonReview:        "🔍 Разбор ответов",
+	ButtonSameQuestions: "🔁 Те же вопросы",
+	ButtonChallenge:     "⚔️ Вызвать друга",
+	ButtonStartQuiz:     "🎯 Начать викторину",
+	ButtonMainMenu:      "📋 Главное меню",
+	NoQuestions:         "⚠️ Вопросы временно недоступны, попробуйте позже",
+	SessionLimitReached: "⏳ Сейчас слишком много активных викторин, попробуйте немного позже",
+	PracticeFinished: "📚 *Тренировка завершена*\n\n📊 Правильных ответов: %d/%d\n📈 Точность: %s%%\n\n" +
+		"Результат тренировки не попадает в лидерборд.",
+	ButtonMorePractice: "📚 Еще тренировка",
+
+	LeaderboardEmpty:     "🏆 Лидерборд\n\nПока нет результатов. Будьте первым! 🎯",
+	LeaderboardTop:       "Топ 10 игроков",
+	LeaderboardTopWeek:   "Топ 10 игроков за неделю",
+	LeaderboardTopMonth:  "Топ 10 игроков за месяц",
+	LeaderboardPlayers:   "Игроки %d-%d",
+	LeaderboardYou:       "📍 Ваше место: %s из %d",
+	LeaderboardAllTime:   "За все время",
+	LeaderboardWeek:      "За неделю",
+	LeaderboardMonth:     "За месяц",
+	LeaderboardHidden:    "🏆 Лидерборд отключен в этом чате",
+	LeaderboardUnavail:   "⚠️ Лидерборд временно недоступен, попробуйте позже",
+	ActiveLeaderboard:    "Самые активные игроки",
+	ActiveLeaderboardNil: "🏃 Самые активные\n\nПока никто не прошел викторину. Будьте первым! 🎯",
+	QuizzesPlayed:        "🎯 Викторин пройдено: %d",
+	CompositeEmpty:       "⚡ Скорость и точность\n\nПока нет результатов с известным временем. Будьте первым! 🎯",
+	CompositeTitle:       "⚡ <b>Скорость и точность</b>\n<i>Очки = процент - %g × среднее время на вопрос (с)</i>\n\n",
+	CompositeDetails:     "⏱ %d с · ⚡ %.1f очков",
+	RankMissing:          "🏆 Вас пока нет в рейтинге - сыграйте, чтобы попасть в рейтинг! 🎯",
+	RankPosition:         "🏆 Вы на %s месте из %d",
+
+	CategoriesTitle: "📂 Категории и число вопросов:\n",
+	CategoriesItem:  "\n• %s: %d",
+	CategoriesOther: "\n• Без категории: %d",
+	CategoriesNone:  "📂 Категорий пока нет: все вопросы (%d) без категории. Начните викторину через /quiz",
+
+	PracticeChooseCategory: "📚 Выберите категорию для тренировки",
+	PracticeAllCategories:  "🎯 Все вопросы",
+
+	DailyQuizIntro:          "📅 Викторина дня: сегодня у всех одинаковые вопросы в одинаковом порядке",
+	UnknownCommand:          "Неизвестная команда",
+	CommandSuggestion:       ". Возможно, вы имели в виду /%s?",
+	EditedCommand:           "✏️ Редактирование команд не поддерживается, отправьте команду заново",
+	NoActiveQuiz:            "Нет активной викторины",
+	NoPausedQuiz:            "Нет викторины на паузе",
+	NoPreviousQuestions:     "Предыдущий набор вопросов не найден, начните новую викторину",
+	NoTaggedQuestions:       "🏷 Нет вопросов с тегом «%s»",
+	DMContinue:              "🎯 Продолжаем викторину здесь, чтобы не мешать группе",
+	DMInstructions:          "%s, викторина проходит в личных сообщениях.\nОткройте бота по кнопке ниже и нажмите «Запустить» - викторина начнется автоматически.",
+	ButtonOpenBot:           "💬 Открыть бота",
+	DMSent:                  "📩 %s, викторина отправлена вам в личные сообщения",
+	NewRecord:               "🎉 *Новый рекорд!* Вы на %s месте в лидерборде!\n\n",
+	Celebration1:            "🎊🥳 Невероятно! Вы среди лучших!",
+	Celebration2:            "🔥🏆 Вот это результат! Так держать!",
+	Celebration3:            "🌟✨ Легенда лидерборда!",
+	Celebration4:            "🚀🎉 Вы ворвались в тройку лидеров!",
+	QuizPassed:              "✅ *Сдано!* (проходной балл: %d%%)\n\n",
+	QuizFailed:              "❌ *Не сдано* (проходной балл: %d%%)\n\n",
+	InfoText:                "Мой исходный код:\nhttps://github.com/PoluyanbIch/GoTgBot\nМожно поставить звездочку⭐ на него и подписаться:\nhttps://github.com/PoluyanbIch\nотзывы, предложения, предпочтения -> https://t.me/PoluyanbIch",
+	ButtonRepository:        "📂 GitHub репозиторий",
+	ButtonAuthor:            "👤 Автор",
+	ButtonWrite:             "💬 Написать",
+	ButtonBack:              "🔙 Назад",
+	ChooseCategory:          "📂 Выберите категорию вопросов",
+	AllCategories:           "🎯 Все вопросы",
+	CategoryNotFound:        "Категория не найдена, выберите ее заново",
+	NoMistakes:              "📝 Ошибок пока нет - или вы еще не проходили викторину 🎯",
+	MistakesHeader:          "📝 Вопросы, в которых вы ошибались чаще всего:\n\n",
+	MistakesLine:            "%d. %s - ошибок: %d\n",
+	MistakesFooter:          "\nПовторите их в тренировке: /practice",
+	ReviewUnavailable:       "⌛ Разбор ответов больше недоступен",
+	ReviewCorrect:           "✅ Верно",
+	ReviewWrong:             "❌ Неверно",
+	ReviewAnswer:            "📝 Разбор: вопрос %d/%d\n\n%s\n\nВаш ответ: %s\nПравильный ответ: %s\n\n%s",
+	ChallengeShareText:      "⚔️ Сможешь ответить лучше меня?",
+	ChallengeNotFound:       "⌛ Вызов не найден или уже истек",
+	ChallengeOwn:            "⚔️ Это ваш собственный вызов - отправьте ссылку другу",
+	ChallengeIntro:          "⚔️ %s бросает вам вызов: %d/%d. Ответьте на те же вопросы!",
+	ChallengeExpired:        "⌛ Вызов истек, пока вы отвечали - результат не сравнивается",
+	ChallengeSummary:        "⚔️ Итог вызова\n\n%s: %d/%d\n%s: %d/%d\n\n",
+	ChallengeLostTo:         "😔 %s победил(а) в вашем вызове",
+	ChallengeWon:            "🏆 Вы победили!",
+	ChallengeBeat:           "🏆 Вы победили %s!",
+	ChallengeLost:           "😔 Вызов не принят - попробуйте еще раз",
+	ChallengeDraw:           "🤝 Ничья",
+	VisibilityGroupsOnly:    "Видимость лидерборда настраивается только в группах",
+	VisibilityAdminsOnly:    "⛔ Настройка доступна только администраторам чата",
+	LeaderboardHiddenSet:    "🙈 Лидерборд скрыт в этом чате",
+	LeaderboardShown:        "🏆 Лидерборд снова доступен в этом чате",
+	NoStats:                 "📊 У вас пока нет результатов - пройдите викторину! 🎯",
+	StatsText:               "📊 <b>Моя статистика</b>\n\n🏆 Место: %d из %d\n📈 Лучший результат: %s%% (%d/%d), %s\n🎯 Викторин пройдено: %d\n📅 Последняя игра: %s",
+	StatsBonus:              "\n⭐ Бонусных очков: %d",
+	FindUsage:               "🔍 Укажите имя игрока: /find <username>",
+	PlayerNotFound:          "🔍 Игрок «%s» не найден в лидерборде",
+	FindResults:             "🔍 <b>Результаты поиска «%s»</b>\n\n",
+	AdminOnly:               "⛔ Команда доступна только администраторам",
+	ShowQuestionUsage:       "Использование: /showq <id>",
+	ShowQuestionHeader:      "🔎 Вопрос #%d\n\nТекст: %q\n\nВарианты:\n",
+	ShowQuestionOrder:       "\nПравильный порядок: %s",
+	ShowQuestionCorrect:     "\nПравильный индекс: %d",
+	ShowQuestionCategory:    "\nКатегория: %s",
+	ShowQuestionTags:        "\nТеги: %s",
+	ShowQuestionDifficulty:  "\nСложность: %d",
+	ShowQuestionExplanation: "\nПояснение: %q",
+	ShowQuestionRetired:     "\n🗄 Вопрос устарел и не используется в викторинах",
+	QuestionNotFound:        "Вопрос с ID %d не найден",
+	NoAnswerTimes:           "⏱ Статистики времени ответов пока нет",
+	AnswerTimesHeader:       "⏱ Среднее время ответа (от самых долгих):\n\n",
+	AnswerTimesLine:         "#%d %s - %.1f с (ответов: %d)\n",
+	ConfigReloadFailed:      "❌ Ошибка загрузки конфигурации: %v",
+	ConfigReloaded:          "✅ Конфигурация перезагружена\n\nТочность процентов: %d\nМакс. длина сообщения: %d\nСкрывать правильный ответ: %t\nПроходной процент: %d\nСлучайная длина: %d-%d\nDebug: %t\nУровень логов: %s",
+	ConfigRestartRequired:   "\n\n⚠️ Требуют перезапуска: %s",
+	ConfigBackendNote:       "\nБэкенд лидерборда выбирается только при запуске.",
+	QuestionsReloadFailed:   "❌ Ошибка загрузки вопросов: %v\nОставлен прежний набор вопросов",
+	QuestionsReloaded:       "✅ Загружено вопросов: %d",
+	StatusText:              "🩺 Статус бота\n\n⏱ Аптайм: %s\n🎯 Активных викторин: %s\n❓ Вопросов загружено: %d (устаревших: %d)\n💾 Хранилище лидерборда: %s",
+	OptionsConsistent:       "✅ У всех вопросов %d варианта(ов) ответа",
+	OptionsMismatch:         "⚠️ Ожидается %d варианта(ов) ответа, не совпадают:\n\n",
+	PreviewUsage:            "Использование: /preview [количество вопросов]",
+	PreviewHeader:           "👀 Пример викторины (%d вопросов)\n",
+	PreviewShortage:         "Запрошено %d, доступно только %d\n",
+	ListQuestionsUsage:      "Использование: /listq [страница]",
+	ListQuestionsHeader:     "📋 Вопросы: страница %d/%d (всего %d)\n\n",
+	MultiHint:               "\n\n☑️ Отметьте все правильные варианты и нажмите «Готово»",
+	ButtonSubmitChoice:      "✅ Готово",
+	AnswerPartial:           "🟡 Частично верно: +%d",
+	ShowQuestionCorrectSet:  "\nПравильные варианты: %s",
+	SettingsTitle:           "⚙️ Настройки",
+	SettingsPrivateOnly:     "⚙️ Настройки доступны в личных сообщениях с ботом",
+	ButtonSettings:          "⚙️ Настройки",
+	ButtonOvertakeOn:        "🔔 Уведомления об обгоне: вкл",
+	ButtonOvertakeOff:       "🔕 Уведомления об обгоне: выкл",
+	OvertakeAlert:           "🏃 %s обогнал(а) вас в лидерборде! Верните свое место: /quiz\n\nОтключить такие уведомления: /settings",
+	SessionRecovered:        "🔄 Бот перезапустился, продолжаем с вопроса %d\n\n",
+	StatsDailyStreak:        "\n📆 Дней подряд: %d (можно пропустить на этой неделе: %d)",
+}
+
+var en = map[Key]string{
+	MenuTitle:        "📋 *Main menu*",
+	MenuNoQuestions:  "⚠️ Questions are temporarily unavailable",
+	ButtonQuiz:       "🐖Haram test🐖",
+	ButtonRandom:     "🎲 Random length",
+	ButtonPractice:   "📚 Practice",
+	ButtonBoard:      "🏆 Leaderboard",
+	ButtonActive:     "🏃 Most active",
+	ButtonComposite:  "⚡ Speed and accuracy",
+	ButtonMyStats:    "📊 My stats",
+	ButtonInfo:       "ℹ️About meℹ️",
+	ButtonBackToMenu: "🔙 To menu",
+
+	QuestionHeader:      "❓ *Question %d/%d*\n\n%s",
+	BonusQuestionHeader: "⭐ *Bonus question*\n\n%s\n\n_A wrong answer won't lower your score_",
+	PracticeHeader:      "📚 *Practice* · question %d\n\n%s",
+	ButtonConfirm:       "✔️ Confirm",
+	ButtonStopPractice:  "⏹ Stop",
+	ButtonExitQuiz:      "🚪Leave the quiz🚪",
+	ButtonSkip:          "⏭ Skip (%d)",
+	ButtonFiftyFifty:    "🎲 50/50",
+	ButtonResetOrder:    "↩️ Reset the order",
+	OrderHint:           "\n\n🔢 Tap the options in order",
+	ButtonNext:          "➡ Next",
+	AnswerCorrect:       "✅ *Correct!* 🎉",
+	AnswerCorrectBonus:  "⭐ *Correct!* +1 bonus point 🎉",
+	AnswerPoints:        " +%d points",
+	AnswerWrong:         "❌ *Wrong!*",
+	CorrectAnswer:       "\nCorrect answer: %s",
+	TimeUp:              "⏰ *Time is up!*",
+	QuestionSkipped:     "⏭ Question skipped, skips left: %d",
+	Streak:              "\n🔥 Streak: %d",
+	StreakBonus:         " (+%d bonus point)",
+	QuizPaused:          "⏸ The quiz is paused. Send /resume to continue",
+	QuizExited:          "🚪 Quiz aborted.\nYour result was not saved.",
+	QuizFinished:        "🏁 *Quiz finished!*\n\n📊 Result: %d/%d\n📈 Correct answers: %s%%\n\n",
+	QuizBonusPoints:     "⭐ Bonus points: %d\n\n",
+	QuizBestStreak:      "🔥 Best streak: %d\n\n",
+	QuizSaveFailed:      "⚠️ Could not save your result: the leaderboard is temporarily unavailable\n\n",
+	QuizNotRanked:       "📉 Only results of %d%% or more make the leaderboard\n\n",
+	ButtonRestart:       "🎯 Play again",
+	ButtonReview:        "🔍 Review answers",
+	ButtonSameQuestions: "🔁 Same questions",
+	ButtonChallenge:     "⚔️ Challenge a friend",
+	ButtonStartQuiz:     "🎯 Start the quiz",
+	ButtonMainMenu:      "📋 Main menu",
+	NoQuestions:         "⚠️ Questions are temporarily unavailable, please try later",
+	SessionLimitReached: "⏳ Too many quizzes are running right now, please try again a bit later",
+	PracticeFinished: "📚 *Practice finished*\n\n📊 Correct answers: %d/%d\n📈 Accuracy: %s%%\n\n" +
+		"Practice results don't go to the leaderboard.",
+	ButtonMorePractice: "📚 Practice again",
+
+	LeaderboardEmpty:     "🏆 Leaderboard\n\nNo results yet. Be the first! 🎯",
+	LeaderboardTop:       "Top 10 players",
+	LeaderboardTopWeek:   "Top 10 players this week",
+	LeaderboardTopMonth:  "Top 10 players this month",
+	LeaderboardPlayers:   "Players %d-%d",
+	LeaderboardYou:       "📍 Your place: %s of %d",
+	LeaderboardAllTime:   "All time",
+	LeaderboardWeek:      "Week",
+	LeaderboardMonth:     "Month",
+	LeaderboardHidden:    "🏆 The leaderboard is disabled in this chat",
+	LeaderboardUnavail:   "⚠️ The leaderboard is temporarily unavailable, please try later",
+	ActiveLeaderboard:    "Most active players",
+	ActiveLeaderboardNil: "🏃 Most active\n\nNobody has finished a quiz yet. Be the first! 🎯",
+	QuizzesPlayed:        "🎯 Quizzes played: %d",
+	CompositeEmpty:       "⚡ Speed and accuracy\n\nNo results with a known time yet. Be the first! 🎯",
+	CompositeTitle:       "⚡ <b>Speed and accuracy</b>\n<i>Points = percent - %g × average seconds per question</i>\n\n",
+	CompositeDetails:     "⏱ %d s · ⚡ %.1f points",
+	RankMissing:          "🏆 You are not ranked yet - play a quiz to get on the board! 🎯",
+	RankPosition:         "🏆 You are %s of %d",
+
+	CategoriesTitle: "📂 Categories and question counts:\n",
+	CategoriesItem:  "\n• %s: %d",
+	CategoriesOther: "\n• No category: %d",
+	CategoriesNone:  "📂 There are no categories yet: all %d questions are uncategorized. Start a quiz with /quiz",
+
+	PracticeChooseCategory: "📚 Choose a category to practice",
+	PracticeAllCategories:  "🎯 All questions",
+
+	DailyQuizIntro:          "📅 Quiz of the day: everyone gets the same questions in the same order today",
+	UnknownCommand:          "Unknown command",
+	CommandSuggestion:       ". Did you mean /%s?",
+	EditedCommand:           "✏️ Editing commands is not supported, please send the command again",
+	NoActiveQuiz:            "There is no active quiz",
+	NoPausedQuiz:            "There is no paused quiz",
+	NoPreviousQuestions:     "The previous set of questions was not found, please start a new quiz",
+	NoTaggedQuestions:       "🏷 There are no questions tagged “%s”",
+	DMContinue:              "🎯 Let's continue the quiz here so we don't disturb the group",
+	DMInstructions:          "%s, the quiz runs in private messages.\nOpen the bot with the button below and press “Start” - the quiz will begin automatically.",
+	ButtonOpenBot:           "💬 Open the bot",
+	DMSent:                  "📩 %s, the quiz has been sent to your private messages",
+	NewRecord:               "🎉 *New record!* You are %s on the leaderboard!\n\n",
+	Celebration1:            "🎊🥳 Incredible! You are among the best!",
+	Celebration2:            "🔥🏆 What a result! Keep it up!",
+	Celebration3:            "🌟✨ A leaderboard legend!",
+	Celebration4:            "🚀🎉 You broke into the top three!",
+	QuizPassed:              "✅ *Passed!* (pass mark: %d%%)\n\n",
+	QuizFailed:              "❌ *Not passed* (pass mark: %d%%)\n\n",
+	InfoText:                "My source code:\nhttps://github.com/PoluyanbIch/GoTgBot\nYou can star it⭐ and follow:\nhttps://github.com/PoluyanbIch\nfeedback, ideas, wishes -> https://t.me/PoluyanbIch",
+	ButtonRepository:        "📂 GitHub repository",
+	ButtonAuthor:            "👤 Author",
+	ButtonWrite:             "💬 Message me",
+	ButtonBack:              "🔙 Back",
+	ChooseCategory:          "📂 Choose a question category",
+	AllCategories:           "🎯 All questions",
+	CategoryNotFound:        "Category not found, please choose again",
+	NoMistakes:              "📝 No mistakes yet - or you haven't taken a quiz 🎯",
+	MistakesHeader:          "📝 Questions you got wrong most often:\n\n",
+	MistakesLine:            "%d. %s - mistakes: %d\n",
+	MistakesFooter:          "\nGo over them in practice: /practice",
+	ReviewUnavailable:       "⌛ The answer review is no longer available",
+	ReviewCorrect:           "✅ Correct",
+	ReviewWrong:             "❌ Wrong",
+	ReviewAnswer:            "📝 Review: question %d/%d\n\n%s\n\nYour answer: %s\nCorrect answer: %s\n\n%s",
+	ChallengeShareText:      "⚔️ Can you beat my score?",
+	ChallengeNotFound:       "⌛ The challenge was not found or has expired",
+	ChallengeOwn:            "⚔️ This is your own challenge - send the link to a friend",
+	ChallengeIntro:          "⚔️ %s challenges you: %d/%d. Answer the same questions!",
+	ChallengeExpired:        "⌛ The challenge expired while you were answering - the result is not compared",
+	ChallengeSummary:        "⚔️ Challenge result\n\n%s: %d/%d\n%s: %d/%d\n\n",
+	ChallengeLostTo:         "😔 %s won your challenge",
+	ChallengeWon:            "🏆 You won!",
+	ChallengeBeat:           "🏆 You beat %s!",
+	ChallengeLost:           "😔 Challenge failed - try again",
+	ChallengeDraw:           "🤝 It's a draw",
+	VisibilityGroupsOnly:    "Leaderboard visibility can only be set in groups",
+	VisibilityAdminsOnly:    "⛔ Only chat administrators can change this setting",
+	LeaderboardHiddenSet:    "🙈 The leaderboard is hidden in this chat",
+	LeaderboardShown:        "🏆 The leaderboard is available in this chat again",
+	NoStats:                 "📊 You have no results yet - take a quiz! 🎯",
+	StatsText:               "📊 <b>My stats</b>\n\n🏆 Place: %d of %d\n📈 Best result: %s%% (%d/%d), %s\n🎯 Quizzes taken: %d\n📅 Last played: %s",
+	StatsBonus:              "\n⭐ Bonus points: %d",
+	FindUsage:               "🔍 Specify a player name: /find <username>",
+	PlayerNotFound:          "🔍 Player “%s” is not on the leaderboard",
+	FindResults:             "🔍 <b>Search results for “%s”</b>\n\n",
+	AdminOnly:               "⛔ This command is for administrators only",
+	ShowQuestionUsage:       "Usage: /showq <id>",
+	ShowQuestionHeader:      "🔎 Question #%d\n\nText: %q\n\nOptions:\n",
+	ShowQuestionOrder:       "\nCorrect order: %s",
+	ShowQuestionCorrect:     "\nCorrect index: %d",
+	ShowQuestionCategory:    "\nCategory: %s",
+	ShowQuestionTags:        "\nTags: %s",
+	ShowQuestionDifficulty:  "\nDifficulty: %d",
+	ShowQuestionExplanation: "\nExplanation: %q",
+	ShowQuestionRetired:     "\n🗄 The question is retired and not used in quizzes",
+	QuestionNotFound:        "Question with ID %d not found",
+	NoAnswerTimes:           "⏱ No answer time statistics yet",
+	AnswerTimesHeader:       "⏱ Average answer time (slowest first):\n\n",
+	AnswerTimesLine:         "#%d %s - %.1f s (answers: %d)\n",
+	ConfigReloadFailed:      "❌ Failed to load the configuration: %v",
+	ConfigReloaded:          "✅ Configuration reloaded\n\nPercent precision: %d\nMax message length: %d\nHide correct answer: %t\nPass percentage: %d\nRandom length: %d-%d\nDebug: %t\nLog level: %s",
+	ConfigRestartRequired:   "\n\n⚠️ Restart required: %s",
+	ConfigBackendNote:       "\nThe leaderboard backend is only chosen at startup.",
+	QuestionsReloadFailed:   "❌ Failed to load questions: %v\nThe previous set of questions is kept",
+	QuestionsReloaded:       "✅ Questions loaded: %d",
+	StatusText:              "🩺 Bot status\n\n⏱ Uptime: %s\n🎯 Active quizzes: %s\n❓ Questions loaded: %d (retired: %d)\n💾 Leaderboard storage: %s",
+	OptionsConsistent:       "✅ All questions have %d answer options",
+	OptionsMismatch:         "⚠️ Expected %d answer options, mismatched:\n\n",
+	PreviewUsage:            "Usage: /preview [number of questions]",
+	PreviewHeader:           "👀 Sample quiz (%d questions)\n",
+	PreviewShortage:         "Requested %d, only %d available\n",
+	ListQuestionsUsage:      "Usage: /listq [page]",
+	ListQuestionsHeader:     "📋 Questions: page %d/%d (%d total)\n\n",
+	MultiHint:               "\n\n☑️ Check every correct option and press “Done”",
+	ButtonSubmitChoice:      "✅ Done",
+	AnswerPartial:           "🟡 Partially correct: +%d",
+	ShowQuestionCorrectSet:  "\nCorrect options: %s",
+	SettingsTitle:           "⚙️ Settings",
+	SettingsPrivateOnly:     "⚙️ Settings are available in private messages with the bot",
+	ButtonSettings:          "⚙️ Settings",
+	ButtonOvertakeOn:        "🔔 Overtake alerts: on",
+	ButtonOvertakeOff:       "🔕 Overtake alerts: off",
+	OvertakeAlert:           "🏃 %s has overtaken you on the leaderboard! Win your place back: /quiz\n\nTurn these alerts off: /settings",
+	SessionRecovered:        "🔄 The bot was restarted, continuing from question %d\n\n",
+	StatsDailyStreak:        "\n📆 Days in a row: %d (days you can skip this week: %d)",
+}
+
+// Localizer переводит сообщения бота на язык пользователя
+type Localizer struct {
+	tables map[string]map[Key]string
+}
+
+// NewLocalizer создает переводчик с русскими и английскими сообщениями
+func NewLocalizer() *Localizer {
+	return &Localizer{
+		tables: map[string]map[Key]string{
+			"ru": ru,
+			"en": en,
+		},
+	}
+}
+
+// Language приводит language_code из Telegram ("en-US", "RU") к языку таблицы сообщений.
+// Для неизвестных и пустых кодов возвращает DefaultLanguage
+func (l *Localizer) Language(code string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(code)), "-")
+	if _, exists := l.tables[lang]; exists {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// T возвращает сообщение key на языке lang, подставляя args через fmt.Sprintf.
+// Если перевода нет, используется русский текст, а если нет и его - сам ключ
+func (l *Localizer) T(lang string, key Key, args ...any) string {
+	text, exists := l.tables[l.Language(lang)][key]
+	if !exists {
+		text, exists = l.tables[DefaultLanguage][key]
+	}
+	if !exists {
+		text = string(key)
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
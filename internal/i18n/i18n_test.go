@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	l := NewLocalizer()
+	tests := []struct {
+		lang string
+		key  Key
+		args []any
+		want string
+	}{
+		{"ru", NoActiveQuiz, nil, "Нет активной викторины"},
+		{"en", NoActiveQuiz, nil, "There is no active quiz"},
+		{"ru", QuestionsReloaded, []any{12}, "✅ Загружено вопросов: 12"},
+		{"en", QuestionsReloaded, []any{12}, "✅ Questions loaded: 12"},
+		{"en-US", ReviewCorrect, nil, "✅ Correct"},
+		{"RU", ReviewCorrect, nil, "✅ Верно"},
+	}
+	for _, tt := range tests {
+		if got := l.T(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("T(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateFallback(t *testing.T) {
+	l := NewLocalizer()
+
+	for _, lang := range []string{"de", "", "zz-ZZ"} {
+		if got, want := l.T(lang, ChallengeDraw), ru[ChallengeDraw]; got != want {
+			t.Errorf("T(%q) = %q, want russian %q", lang, got, want)
+		}
+		if got := l.Language(lang); got != DefaultLanguage {
+			t.Errorf("Language(%q) = %q, want %q", lang, got, DefaultLanguage)
+		}
+	}
+
+	if got := l.T("en", Key("missing_key")); got != "missing_key" {
+		t.Errorf("missing key = %q, want the key itself", got)
+	}
+}
+
+// formatVerb находит директивы fmt в тексте сообщения
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestTablesParity проверяет, что у каждого ключа есть оба перевода с одинаковыми директивами fmt:
+// иначе английский текст молча заменится русским или аргументы подставятся не туда
+func TestTablesParity(t *testing.T) {
+	for key, ruText := range ru {
+		enText, exists := en[key]
+		if !exists {
+			t.Errorf("key %q has no english translation", key)
+			continue
+		}
+		ruVerbs := formatVerb.FindAllString(ruText, -1)
+		enVerbs := formatVerb.FindAllString(enText, -1)
+		if !slices.Equal(ruVerbs, enVerbs) {
+			t.Errorf("key %q: ru verbs %v, en verbs %v", key, ruVerbs, enVerbs)
+		}
+	}
+	for key := range en {
+		if _, exists := ru[key]; !exists {
+			t.Errorf("key %q has no russian translation", key)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Пространства имен статистики времени ответов в Store, ключ - ID вопроса
+const (
+	AnswerStatsNamespace   = "answer_stats"
+	PracticeStatsNamespace = "practice_stats" // тренировки считаются отдельно от настоящих попыток
+)
+
+// QuestionTimeStats - накопленная статистика времени ответа на один вопрос
+type QuestionTimeStats struct {
+	QuestionID int           `json:"question_id"`
+	Question   string        `json:"question"`
+	Answers    int           `json:"answers"`
+	TotalTime  time.Duration `json:"total_time"`
+}
+
+// Average возвращает среднее время ответа на вопрос
+func (s QuestionTimeStats) Average() time.Duration {
+	if s.Answers == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Answers)
+}
+
+// add прибавляет к статистике приращение delta
+func (s QuestionTimeStats) add(delta QuestionTimeStats) QuestionTimeStats {
+	s.QuestionID = delta.QuestionID
+	s.Question = delta.Question
+	s.Answers += delta.Answers
+	s.TotalTime += delta.TotalTime
+	return s
+}
+
+// AnswerStats собирает статистику ответов по вопросам в пространстве имен Store
+type AnswerStats struct {
+	mu        sync.Mutex // Record читает и перезаписывает статистику вопроса, это должно быть атомарно
+	store     Store
+	namespace string
+	logger    *slog.Logger
+
+	batched bool
+	pending map[int]QuestionTimeStats // приращения с последнего Flush, накапливаются только при batched
+}
+
+// NewAnswerStats хранит статистику ответов в памяти
+func NewAnswerStats() *AnswerStats {
+	return NewStoreAnswerStats(NewMemoryStore(), AnswerStatsNamespace, nil)
+}
+
+// NewStoreAnswerStats хранит статистику ответов в пространстве имен namespace хранилища store.
+// logger == nil - slog.Default()
+func NewStoreAnswerStats(store Store, namespace string, logger *slog.Logger) *AnswerStats {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &AnswerStats{store: store, namespace: namespace, logger: logger}
+}
+
+// EnableBatching включает накопление статистики в памяти: Record больше не пишет в хранилище
+// на каждый ответ, накопленное записывается одной операцией в Flush
+func (s *AnswerStats) EnableBatching() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batched = true
+	if s.pending == nil {
+		s.pending = make(map[int]QuestionTimeStats)
+	}
+}
+
+// Record учитывает время, за которое пользователь ответил на вопрос
+func (s *AnswerStats) Record(question QuizQuestion, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delta := QuestionTimeStats{QuestionID: question.ID, Question: question.Question, Answers: 1, TotalTime: elapsed}
+	if s.batched {
+		s.pending[question.ID] = s.pending[question.ID].add(delta)
+		return
+	}
+
+	key := strconv.Itoa(question.ID)
+	stats := QuestionTimeStats{QuestionID: question.ID}
+	value, err := s.store.Get(s.namespace, key)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(value, &stats); err != nil {
+			s.logger.Error("loading answer stats failed", "question_id", question.ID, "err", err)
+			return
+		}
+	case !errors.Is(err, ErrNotFound):
+		s.logger.Error("loading answer stats failed", "question_id", question.ID, "err", err)
+		return
+	}
+
+	stats = stats.add(delta)
+
+	data, err := json.Marshal(stats)
+	if err == nil {
+		err = s.store.Set(s.namespace, key, data)
+	}
+	if err != nil {
+		s.logger.Error("saving answer stats failed", "question_id", question.ID, "err", err)
+	}
+}
+
+// Flush добавляет накопленные приращения к статистике в хранилище одной записью.
+// При ошибке приращения остаются в памяти и будут записаны следующим Flush
+func (s *AnswerStats) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	persisted, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string][]byte, len(s.pending))
+	for id, delta := range s.pending {
+		data, err := json.Marshal(persisted[id].add(delta))
+		if err != nil {
+			return err
+		}
+		values[strconv.Itoa(id)] = data
+	}
+	if err := setMany(s.store, s.namespace, values); err != nil {
+		return err
+	}
+
+	clear(s.pending)
+	return nil
+}
+
+// load читает статистику всех вопросов из хранилища
+func (s *AnswerStats) load() (map[int]QuestionTimeStats, error) {
+	values, err := s.store.List(s.namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]QuestionTimeStats, len(values))
+	for key, value := range values {
+		var stats QuestionTimeStats
+		if err := json.Unmarshal(value, &stats); err != nil {
+			s.logger.Error("invalid answer stats", "key", key, "err", err)
+			continue
+		}
+		result[stats.QuestionID] = stats
+	}
+	return result, nil
+}
+
+// AverageTimes возвращает статистику по всем вопросам, от самых долгих к самым быстрым.
+// Еще не записанные приращения учитываются вместе с сохраненными
+func (s *AnswerStats) AverageTimes() []QuestionTimeStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	persisted, err := s.load()
+	if err != nil {
+		s.logger.Error("loading answer stats failed", "err", err)
+		return nil
+	}
+	for id, delta := range s.pending {
+		persisted[id] = persisted[id].add(delta)
+	}
+
+	result := make([]QuestionTimeStats, 0, len(persisted))
+	for _, stats := range persisted {
+		result = append(result, stats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Average() == result[j].Average() {
+			return result[i].QuestionID < result[j].QuestionID
+		}
+		return result[i].Average() > result[j].Average()
+	})
+
+	return result
+}
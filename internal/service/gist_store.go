@@ -0,0 +1,236 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GistStore использует GitHub Gist для хранения: пространство имен - файл <namespace>.json.
+// Прочитанные документы кэшируются на cacheTTL, чтобы просмотр лидерборда не упирался в лимиты GitHub API
+type GistStore struct {
+	documentStore
+	gistID      string
+	githubToken string
+	httpClient  *http.Client
+
+	cacheTTL time.Duration
+	cacheMu  sync.Mutex
+	cache    map[string]cachedDocument
+
+	retry RetryPolicy
+}
+
+// RetryPolicy - повторы запросов к GitHub API при ошибках сети и ответах 5xx
+type RetryPolicy struct {
+	Attempts  int           // сколько всего попыток сделать, меньше 1 - одна попытка
+	BaseDelay time.Duration // пауза перед первым повтором, перед каждым следующим удваивается
+}
+
+// DefaultGistRetry - повторы запросов к Gist по умолчанию
+var DefaultGistRetry = RetryPolicy{Attempts: 3, BaseDelay: 500 * time.Millisecond}
+
+// cachedDocument - документ пространства имен и время его загрузки из Gist
+type cachedDocument struct {
+	doc       map[string]json.RawMessage
+	lastFetch time.Time
+}
+
+// gistRequestTimeout - таймаут запросов к GitHub API по умолчанию
+const gistRequestTimeout = 10 * time.Second
+
+// NewGistStore создает хранилище в Gist. cacheTTL <= 0 отключает кэш
+func NewGistStore(gistID, githubToken string, cacheTTL time.Duration) *GistStore {
+	return NewGistStoreWithClient(gistID, githubToken, cacheTTL, &http.Client{Timeout: gistRequestTimeout})
+}
+
+// NewGistStoreWithClient создает хранилище в Gist, которое ходит в GitHub API через client:
+// так можно задать свои таймауты или подменить транспорт в тестах
+func NewGistStoreWithClient(gistID, githubToken string, cacheTTL time.Duration, client *http.Client) *GistStore {
+	gs := &GistStore{
+		gistID:      gistID,
+		githubToken: githubToken,
+		httpClient:  client,
+		cacheTTL:    cacheTTL,
+		cache:       make(map[string]cachedDocument),
+		retry:       DefaultGistRetry,
+	}
+	gs.load = gs.loadCached
+	gs.save = gs.saveCached
+	return gs
+}
+
+// SetRetryPolicy задает повторы запросов к GitHub API
+func (gs *GistStore) SetRetryPolicy(retry RetryPolicy) {
+	gs.retry = retry
+}
+
+// doWithRetry выполняет запрос, созданный newRequest, повторяя его с экспоненциальной паузой
+// при ошибках сети и ответах 5xx. Запрос создается заново на каждую попытку, чтобы заново читалось тело
+func (gs *GistStore) doWithRetry(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	attempts := gs.retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := gs.retry.BaseDelay
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			gs.log().Warn("gist request failed, retrying",
+				"attempt", attempt-1, "attempts", attempts, "delay", delay, "err", lastErr)
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := gs.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("gist request failed after %d attempts: %w", attempts, lastErr)
+}
+
+// loadCached возвращает копию документа из кэша, если он не старше cacheTTL, иначе загружает его из Gist
+func (gs *GistStore) loadCached(namespace string) (map[string]json.RawMessage, error) {
+	gs.cacheMu.Lock()
+	cached, exists := gs.cache[namespace]
+	gs.cacheMu.Unlock()
+
+	if exists && time.Since(cached.lastFetch) < gs.cacheTTL {
+		return copyDocument(cached.doc), nil
+	}
+
+	doc, err := gs.loadFromGist(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	gs.cacheMu.Lock()
+	gs.cache[namespace] = cachedDocument{doc: copyDocument(doc), lastFetch: time.Now()}
+	gs.cacheMu.Unlock()
+
+	return doc, nil
+}
+
+// saveCached сохраняет документ в Gist. После успешной записи кэш содержит сохраненный документ,
+// после ошибки - сбрасывается, чтобы следующее чтение пошло в Gist
+func (gs *GistStore) saveCached(namespace string, doc map[string]json.RawMessage) error {
+	err := gs.saveToGist(namespace, doc)
+
+	gs.cacheMu.Lock()
+	defer gs.cacheMu.Unlock()
+
+	if err != nil {
+		delete(gs.cache, namespace)
+		return err
+	}
+	gs.cache[namespace] = cachedDocument{doc: copyDocument(doc), lastFetch: time.Now()}
+	return nil
+}
+
+// copyDocument копирует документ, чтобы изменения вызывающего кода не попадали в кэш
+func copyDocument(doc map[string]json.RawMessage) map[string]json.RawMessage {
+	copied := make(map[string]json.RawMessage, len(doc))
+	for key, value := range doc {
+		copied[key] = value
+	}
+	return copied
+}
+
+func (gs *GistStore) loadFromGist(namespace string) (map[string]json.RawMessage, error) {
+	url := fmt.Sprintf("https://api.github.com/gists/%s", gs.gistID)
+
+	resp, err := gs.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if gs.githubToken != "" {
+			req.Header.Set("Authorization", "token "+gs.githubToken)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var gist struct {
+		Files map[string]struct {
+			Content string `json:"content"`
+		} `json:"files"`
+	}
+
+	if err := json.Unmarshal(body, &gist); err != nil {
+		return nil, err
+	}
+
+	return decodeDocument(namespace, []byte(gist.Files[namespace+".json"].Content))
+}
+
+func (gs *GistStore) saveToGist(namespace string, doc map[string]json.RawMessage) error {
+	content, err := encodeDocument(namespace, doc)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"files": map[string]interface{}{
+			namespace + ".json": map[string]interface{}{
+				"content": string(content),
+			},
+		},
+	}
+
+	jsonPayload, _ := json.Marshal(payload)
+
+	url := fmt.Sprintf("https://api.github.com/gists/%s", gs.gistID)
+	resp, err := gs.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("PATCH", url, strings.NewReader(string(jsonPayload)))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", "token "+gs.githubToken)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
@@ -2,11 +2,14 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,141 +21,463 @@ type LeaderboardEntry struct {
 	FirstName  string `json:"first_name"`
 	Score      int    `json:"score"`
 	Total      int    `json:"total"`
-	Percentage int    `json:"percentage"`
+	Percentage int    `json:"percentage"` // округленный вниз процент, для отображения используйте FormatPercentage
 	Date       string `json:"date"`
+	Attempts   int    `json:"attempts"`              // количество завершенных викторин
+	Bonus      int    `json:"bonus"`                 // бонусные очки за все викторины, не влияют на место
+	Duration   int    `json:"duration"`              // время прохождения лучшей викторины в секундах, 0 - неизвестно
+	LastPlayed string `json:"last_played,omitempty"` // дата последней викторины, в отличие от Date - не только лучшей
+
+	// CreatedAt - время лучшего результата, Date выводится из него для отображения.
+	// У записей, сохраненных до появления поля, восстанавливается из Date
+	CreatedAt time.Time `json:"created_at,omitzero"`
 }
 
-type Leaderboard struct {
-	Entries []LeaderboardEntry `json:"entries"`
-	mu      sync.RWMutex
+// entryDateLayout - формат Date и LastPlayed
+const entryDateLayout = "02.01.2006 15:04"
+
+// UnmarshalJSON читает и новый формат записи с created_at, и старый, где есть только Date
+func (e *LeaderboardEntry) UnmarshalJSON(data []byte) error {
+	type plain LeaderboardEntry
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+
+	switch {
+	case e.CreatedAt.IsZero() && e.Date != "":
+		// Старые записи хранили время в локальном часовом поясе сервера
+		if createdAt, err := time.ParseInLocation(entryDateLayout, e.Date, time.Local); err == nil {
+			e.CreatedAt = createdAt
+		}
+	case e.Date == "" && !e.CreatedAt.IsZero():
+		e.Date = e.CreatedAt.Local().Format(entryDateLayout)
+	}
+	return nil
+}
+
+// Period - период, за который строится лидерборд
+type Period int
+
+const (
+	PeriodAll   Period = iota // за все время
+	PeriodWeek                // за последние 7 дней
+	PeriodMonth               // за последний месяц
+)
+
+// Since возвращает начало периода относительно now, для PeriodAll - нулевое время
+func (p Period) Since(now time.Time) time.Time {
+	switch p {
+	case PeriodWeek:
+		return now.AddDate(0, 0, -7)
+	case PeriodMonth:
+		return now.AddDate(0, -1, 0)
+	}
+	return time.Time{}
 }
 
+// UserStats - личная статистика игрока
+type UserStats struct {
+	Position int              // место в лидерборде
+	Players  int              // всего игроков в лидерборде
+	Best     LeaderboardEntry // лучший результат, число викторин и дата последней игры
+}
+
+// FormatPercentage форматирует долю score/total в процентах с заданным числом знаков после запятой.
+// При precision == 0 процент округляется вниз, как и раньше
+func FormatPercentage(score, total, precision int) string {
+	if total <= 0 {
+		return "0"
+	}
+	if precision <= 0 {
+		return strconv.Itoa(score * 100 / total)
+	}
+	return strconv.FormatFloat(float64(score)*100/float64(total), 'f', precision, 64)
+}
+
+// LeaderboardPage - страница лидерборда вместе с местом игрока, который ее смотрит
+type LeaderboardPage struct {
+	Entries  []LeaderboardEntry // записи начиная с места offset+1
+	Players  int                // сколько игроков прошли фильтр по числу попыток
+	Position int                // место игрока среди них, -1 - игрока нет
+}
+
+// RankedEntry - запись лидерборда вместе с её местом
+type RankedEntry struct {
+	Position int
+	Entry    LeaderboardEntry
+}
+
+// LeaderboardService - хранилище результатов. Ошибки хранилища возвращаются вызывающему коду,
+// чтобы пустой лидерборд можно было отличить от недоступного
 type LeaderboardService interface {
-	AddEntry(userID int64, username, firstName string, score, total int) bool
-	GetTop(limit int) []LeaderboardEntry
-	GetUserPosition(userID int64) (int, *LeaderboardEntry)
+	// AddEntry сохраняет результат и сообщает, стал ли он новым лучшим результатом игрока
+	AddEntry(userID int64, username, firstName string, score, total, bonus int, duration time.Duration) (bool, error)
+	GetTop(limit int) ([]LeaderboardEntry, error)
+	GetTopFiltered(limit, minAttempts int) ([]LeaderboardEntry, error)
+	GetTopByAttempts(limit, minAttempts int) ([]LeaderboardEntry, error)
+	GetTopComposite(limit int, timePenalty float64, minAttempts int) ([]LeaderboardEntry, error)
+	GetTopByPeriod(limit int, period Period, minAttempts int) ([]LeaderboardEntry, error)
+	GetUserPosition(userID int64) (int, *LeaderboardEntry, error)
+	// GetUserRank возвращает место игрока по тем же правилам, что и GetTopWithPosition
+	GetUserRank(userID int64, minAttempts int) (position, players int, err error)
+	// GetTopWithPosition возвращает страницу топа и место игрока за одну загрузку и одну сортировку
+	GetTopWithPosition(offset, limit, minAttempts int, userID int64) (LeaderboardPage, error)
+	GetUserStats(userID int64) (UserStats, bool, error)
+	Count() (int, error)
+	FindByUsername(query string) ([]RankedEntry, error)
+	Backend() string
+	// Store - хранилище лидерборда, в нем же бот держит настройки чатов и статистику
+	Store() Store
 }
 
-// GistLeaderboardService использует GitHub Gist для хранения
-type GistLeaderboardService struct {
-	gistID      string
-	githubToken string
-	filename    string
+// compareResults сравнивает результаты по точной доле правильных ответов (score/total),
+// а при равенстве - по количеству очков. Возвращает 1, если a лучше b, -1 если хуже, 0 если равны
+func compareResults(a, b LeaderboardEntry) int {
+	left, right := a.Score*b.Total, b.Score*a.Total
+	switch {
+	case left > right:
+		return 1
+	case left < right:
+		return -1
+	case a.Score > b.Score:
+		return 1
+	case a.Score < b.Score:
+		return -1
+	}
+	return 0
 }
 
-func NewLeaderboardService() LeaderboardService {
-	gistID := os.Getenv("GITHUB_GIST_ID")
-	githubToken := os.Getenv("GITHUB_TOKEN")
+// sortEntries возвращает отсортированную копию записей (по проценту и количеству очков)
+func sortEntries(entries []LeaderboardEntry) []LeaderboardEntry {
+	sorted := make([]LeaderboardEntry, len(entries))
+	copy(sorted, entries)
 
-	if gistID != "" && githubToken != "" {
-		return NewGistLeaderboardService(gistID, githubToken)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareResults(sorted[i], sorted[j]) > 0
+	})
+
+	return sorted
+}
+
+// sortByAttempts возвращает записи с ненулевым числом попыток, отсортированные по убыванию попыток
+func sortByAttempts(entries []LeaderboardEntry) []LeaderboardEntry {
+	sorted := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Attempts > 0 {
+			sorted = append(sorted, entry)
+		}
 	}
 
-	// Fallback - in-memory (данные теряются при рестарте)
-	return NewMemoryLeaderboardService()
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Attempts == sorted[j].Attempts {
+			return compareResults(sorted[i], sorted[j]) > 0
+		}
+		return sorted[i].Attempts > sorted[j].Attempts
+	})
+
+	return sorted
 }
 
-func NewGistLeaderboardService(gistID, githubToken string) *GistLeaderboardService {
-	return &GistLeaderboardService{
-		gistID:      gistID,
-		githubToken: githubToken,
-		filename:    "leaderboard.json",
+// CompositeScore - комбинированный рейтинг точности и скорости:
+//
+//	процент правильных ответов - timePenalty * (среднее время на вопрос в секундах)
+//
+// Например, при timePenalty = 1 результат 90% со средним временем 5 с на вопрос
+// дает 85 очков и обходит 88% при 8 с на вопрос (80 очков)
+func CompositeScore(entry LeaderboardEntry, timePenalty float64) float64 {
+	if entry.Total <= 0 {
+		return 0
 	}
+	accuracy := float64(entry.Score) * 100 / float64(entry.Total)
+	secondsPerQuestion := float64(entry.Duration) / float64(entry.Total)
+	return accuracy - timePenalty*secondsPerQuestion
 }
 
-func (gs *GistLeaderboardService) loadFromGist() (*Leaderboard, error) {
-	url := fmt.Sprintf("https://api.github.com/gists/%s", gs.gistID)
+// sortByComposite возвращает записи с известным временем прохождения,
+// отсортированные по убыванию CompositeScore
+func sortByComposite(entries []LeaderboardEntry, timePenalty float64) []LeaderboardEntry {
+	sorted := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Duration > 0 {
+			sorted = append(sorted, entry)
+		}
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return CompositeScore(sorted[i], timePenalty) > CompositeScore(sorted[j], timePenalty)
+	})
+
+	return sorted
+}
+
+// filterMinAttempts оставляет только записи игроков, прошедших не меньше minAttempts викторин
+func filterMinAttempts(entries []LeaderboardEntry, minAttempts int) []LeaderboardEntry {
+	if minAttempts <= 0 {
+		return entries
 	}
 
-	if gs.githubToken != "" {
-		req.Header.Set("Authorization", "token "+gs.githubToken)
+	filtered := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		if passesMinAttempts(entry, minAttempts) {
+			filtered = append(filtered, entry)
+		}
 	}
+	return filtered
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+// filterPeriod оставляет записи, лучший результат которых показан не раньше начала периода.
+// Записи без времени (и без CreatedAt, и без разбираемой Date) попадают только в лидерборд за все время
+func filterPeriod(entries []LeaderboardEntry, period Period, now time.Time) []LeaderboardEntry {
+	since := period.Since(now)
+	if since.IsZero() {
+		return entries
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	filtered := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.CreatedAt.IsZero() && !entry.CreatedAt.Before(since) {
+			filtered = append(filtered, entry)
+		}
 	}
+	return filtered
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// limitEntries обрезает отсортированный список до limit записей
+func limitEntries(sorted []LeaderboardEntry, limit int) []LeaderboardEntry {
+	if limit > len(sorted) {
+		limit = len(sorted)
 	}
+	return sorted[:limit]
+}
 
-	var gist struct {
-		Files map[string]struct {
-			Content string `json:"content"`
-		} `json:"files"`
+// findPosition возвращает место игрока userID в отсортированном списке и его запись (-1 и nil, если игрока нет)
+func findPosition(sorted []LeaderboardEntry, userID int64) (int, *LeaderboardEntry) {
+	for i := range sorted {
+		if sorted[i].UserID == userID {
+			entry := sorted[i]
+			return i + 1, &entry
+		}
 	}
+	return -1, nil
+}
 
-	if err := json.Unmarshal(body, &gist); err != nil {
-		return nil, err
+// rangeEntries возвращает limit записей отсортированного списка, начиная с offset
+func rangeEntries(sorted []LeaderboardEntry, offset, limit int) []LeaderboardEntry {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(sorted) {
+		offset = len(sorted)
+	}
+	return limitEntries(sorted[offset:], limit)
+}
+
+// passesMinAttempts проверяет, прошел ли игрок не меньше minAttempts викторин
+func passesMinAttempts(entry LeaderboardEntry, minAttempts int) bool {
+	return minAttempts <= 0 || entry.Attempts >= minAttempts
+}
+
+// searchQuery убирает из запроса поиска пробелы по краям и @ перед username
+func searchQuery(query string) string {
+	return strings.TrimPrefix(strings.TrimSpace(query), "@")
+}
+
+// findByUsername ищет записи по username или имени без учета регистра
+func findByUsername(entries []LeaderboardEntry, query string) []RankedEntry {
+	query = searchQuery(query)
+	if query == "" {
+		return nil
 	}
 
-	leaderboard := &Leaderboard{}
-	file, exists := gist.Files[gs.filename]
-	if exists && file.Content != "" {
-		if err := json.Unmarshal([]byte(file.Content), &leaderboard.Entries); err != nil {
-			return nil, err
+	var found []RankedEntry
+	for i, entry := range sortEntries(entries) {
+		if strings.EqualFold(entry.Username, query) || strings.EqualFold(entry.FirstName, query) {
+			found = append(found, RankedEntry{Position: i + 1, Entry: entry})
 		}
 	}
+	return found
+}
 
-	return leaderboard, nil
+// leaderboardNamespace - пространство имен записей лидерборда в Store, ключ - ID пользователя
+const leaderboardNamespace = "leaderboard"
+
+// leaderboardIndex - хранилище, которое само ранжирует и ищет записи лидерборда запросами (SQLite).
+// Места считаются в том же порядке, что у sortEntries. StoreLeaderboardService пользуется им
+// вместо загрузки и сортировки всех записей
+type leaderboardIndex interface {
+	topEntries(offset, limit, minAttempts int) ([]LeaderboardEntry, error)
+	countEntries(minAttempts int) (int, error)
+	entryRank(userID int64, minAttempts int) (entry *LeaderboardEntry, above int, err error)
+	findEntries(query string) ([]RankedEntry, error)
+	// updateEntry атомарно читает запись игрока (nil - записи нет) и сохраняет то, что вернет update.
+	// update == nil - ничего не сохранять
+	updateEntry(userID int64, update func(entry *LeaderboardEntry) *LeaderboardEntry) error
 }
 
-func (gs *GistLeaderboardService) saveToGist(leaderboard *Leaderboard) error {
-	content, err := json.MarshalIndent(leaderboard.Entries, "", "  ")
-	if err != nil {
-		return err
+// StoreLeaderboardService хранит лидерборд в Store
+type StoreLeaderboardService struct {
+	store      Store
+	backend    string
+	minPercent int        // минимальный процент правильных ответов для попадания в лидерборд
+	mu         sync.Mutex // updateEntry читает и перезаписывает запись, это должно быть атомарно
+}
+
+// LeaderboardOptions - настройки хранилища лидерборда
+type LeaderboardOptions struct {
+	// FailFast - вернуть ошибку, если Gist недоступен при запуске, вместо перехода на память
+	FailFast bool
+
+	// GistCacheTTL - сколько используется прочитанный из Gist лидерборд, 0 - без кэша
+	GistCacheTTL time.Duration
+
+	// GistRetry - повторы запросов к Gist, нулевое значение - DefaultGistRetry
+	GistRetry RetryPolicy
+
+	// GistClient - HTTP-клиент запросов к GitHub API, nil - клиент с таймаутом по умолчанию
+	GistClient *http.Client
+
+	// Logger - логгер лидерборда и его хранилища, nil - slog.Default()
+	Logger *slog.Logger
+
+	// MinPercent - минимальный процент правильных ответов, с которым результат попадает в лидерборд, 0 - все результаты
+	MinPercent int
+}
+
+// NewLeaderboardService выбирает Gist, если заданы GITHUB_GIST_ID и GITHUB_TOKEN,
+// затем базу SQLite из LEADERBOARD_SQLITE_PATH, локальный файл из LEADERBOARD_FILE, иначе память.
+// Доступность Gist проверяется пробным чтением: при ошибке и FailFast возвращается ошибка,
+// иначе бот продолжает работу со следующим вариантом хранилища
+func NewLeaderboardService(opts LeaderboardOptions) (LeaderboardService, error) {
+	gistID := os.Getenv("GITHUB_GIST_ID")
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	sqlitePath := os.Getenv("LEADERBOARD_SQLITE_PATH")
+	file := os.Getenv("LEADERBOARD_FILE")
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
 
-	payload := map[string]interface{}{
-		"files": map[string]interface{}{
-			gs.filename: map[string]interface{}{
-				"content": string(content),
-			},
-		},
+	if gistID != "" && githubToken != "" {
+		client := opts.GistClient
+		if client == nil {
+			client = &http.Client{Timeout: gistRequestTimeout}
+		}
+		store := NewGistStoreWithClient(gistID, githubToken, opts.GistCacheTTL, client)
+		store.SetLogger(logger)
+		if opts.GistRetry != (RetryPolicy{}) {
+			store.SetRetryPolicy(opts.GistRetry)
+		}
+		gs := NewStoreLeaderboardService(store, "gist")
+		_, err := gs.entries()
+		if err == nil {
+			gs.SetMinPercent(opts.MinPercent)
+			return gs, nil
+		}
+		if opts.FailFast {
+			return nil, fmt.Errorf("leaderboard gist is unavailable: %w", err)
+		}
+		logger.Warn("leaderboard gist is unavailable, falling back to local storage", "err", err)
 	}
 
-	jsonPayload, _ := json.Marshal(payload)
+	var ls *StoreLeaderboardService
+	if sqlitePath != "" {
+		// База задана явно, поэтому ошибка ее открытия - ошибка настройки, а не повод молча перейти на память
+		sqlite, err := NewSQLiteLeaderboardService(sqlitePath)
+		if err != nil {
+			return nil, fmt.Errorf("opening leaderboard database: %w", err)
+		}
+		ls = sqlite
+	} else if file != "" {
+		ls = NewFileLeaderboardService(file)
+		ls.store.(*FileStore).SetLogger(logger)
+	} else {
+		// Fallback - in-memory (данные теряются при рестарте)
+		ls = NewMemoryLeaderboardService()
+	}
+	ls.SetMinPercent(opts.MinPercent)
+	return ls, nil
+}
+
+// NewStoreLeaderboardService создает лидерборд поверх store, backend - название хранилища для /status
+func NewStoreLeaderboardService(store Store, backend string) *StoreLeaderboardService {
+	return &StoreLeaderboardService{
+		store:   store,
+		backend: backend,
+	}
+}
+
+// SetMinPercent задает минимальный процент правильных ответов: результаты ниже него
+// не создают запись и не обновляют лучший результат, но засчитываются как попытка
+func (ls *StoreLeaderboardService) SetMinPercent(percent int) {
+	ls.minPercent = percent
+}
+
+// NewGistLeaderboardService хранит лидерборд в файле leaderboard.json в GitHub Gist
+func NewGistLeaderboardService(gistID, githubToken string, cacheTTL time.Duration) *StoreLeaderboardService {
+	return NewStoreLeaderboardService(NewGistStore(gistID, githubToken, cacheTTL), "gist")
+}
+
+// NewGistLeaderboardServiceWithClient - как NewGistLeaderboardService, но запросы к GitHub идут через client
+func NewGistLeaderboardServiceWithClient(gistID, githubToken string, cacheTTL time.Duration, client *http.Client) *StoreLeaderboardService {
+	return NewStoreLeaderboardService(NewGistStoreWithClient(gistID, githubToken, cacheTTL, client), "gist")
+}
+
+// NewFileLeaderboardService хранит лидерборд в JSON-файле path, чтобы он переживал рестарт без Gist
+func NewFileLeaderboardService(path string) *StoreLeaderboardService {
+	store := NewFileStore(filepath.Dir(path))
+	store.files[leaderboardNamespace] = path
+	return NewStoreLeaderboardService(store, "file")
+}
 
-	url := fmt.Sprintf("https://api.github.com/gists/%s", gs.gistID)
-	req, err := http.NewRequest("PATCH", url, strings.NewReader(string(jsonPayload)))
+// NewSQLiteLeaderboardService хранит лидерборд в таблице leaderboard базы SQLite path,
+// настройки чатов и статистика попадают в ту же базу
+func NewSQLiteLeaderboardService(path string) (*StoreLeaderboardService, error) {
+	store, err := NewSQLiteStore(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return NewStoreLeaderboardService(store, "sqlite"), nil
+}
 
-	req.Header.Set("Authorization", "token "+gs.githubToken)
-	req.Header.Set("Content-Type", "application/json")
+// NewMemoryLeaderboardService - fallback вариант, данные теряются при рестарте
+func NewMemoryLeaderboardService() *StoreLeaderboardService {
+	return NewStoreLeaderboardService(NewMemoryStore(), "memory")
+}
 
-	resp, err := http.DefaultClient.Do(req)
+// entries загружает все записи лидерборда. Store не хранит порядок, поэтому записи
+// упорядочиваются по ID пользователя - так сортировка по результату остается стабильной
+func (ls *StoreLeaderboardService) entries() ([]LeaderboardEntry, error) {
+	values, err := ls.store.List(leaderboardNamespace)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	entries := make([]LeaderboardEntry, 0, len(values))
+	for key, value := range values {
+		var entry LeaderboardEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return nil, fmt.Errorf("invalid leaderboard entry %s: %w", key, err)
+		}
+		entries = append(entries, entry)
 	}
 
-	return nil
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].UserID < entries[j].UserID
+	})
+	return entries, nil
 }
 
-func (gs *GistLeaderboardService) AddEntry(userID int64, username, firstName string, score, total int) bool {
-	leaderboard, err := gs.loadFromGist()
-	if err != nil {
-		fmt.Printf("Error loading from gist: %v\n", err)
-		return false
+func (ls *StoreLeaderboardService) AddEntry(userID int64, username, firstName string, score, total, bonus int, duration time.Duration) (bool, error) {
+	// Результат без вопросов ничего не значит, а процент от него не посчитать
+	if total <= 0 {
+		return false, nil
 	}
+	// Сравниваем без округления, чтобы 49.9% не проходили порог в 50%.
+	// Результат ниже порога не создает запись и не может стать лучшим, но попытка засчитывается
+	qualified := score*100 >= ls.minPercent*total
 
+	now := time.Now()
 	percentage := (score * 100) / total
 	newEntry := LeaderboardEntry{
 		UserID:     userID,
@@ -161,138 +486,303 @@ func (gs *GistLeaderboardService) AddEntry(userID int64, username, firstName str
 		Score:      score,
 		Total:      total,
 		Percentage: percentage,
-		Date:       time.Now().Format("02.01.2006 15:04"),
+		Date:       now.Format(entryDateLayout),
+		LastPlayed: now.Format(entryDateLayout),
+		Attempts:   1,
+		Bonus:      bonus,
+		Duration:   int(duration.Seconds()),
+		CreatedAt:  now,
 	}
 
-	// Ищем существующую запись
-	found := false
-	for i, entry := range leaderboard.Entries {
-		if entry.UserID == userID {
-			found = true
-			// Обновляем если результат лучше
-			if percentage > entry.Percentage || (percentage == entry.Percentage && score > entry.Score) {
-				leaderboard.Entries[i] = newEntry
+	isNewBest := false
+	merge := func(entry *LeaderboardEntry) *LeaderboardEntry {
+		if entry == nil {
+			if !qualified {
+				return nil
 			}
-			break
+			isNewBest = true
+			return &newEntry
+		}
+
+		// Имя обновляем при каждой попытке, даже если результат не улучшился
+		merged := *entry
+		merged.Username = username
+		merged.FirstName = firstName
+		merged.Attempts++
+		merged.Bonus += bonus
+		merged.LastPlayed = newEntry.LastPlayed
+		// Обновляем если результат лучше
+		isNewBest = qualified && compareResults(newEntry, merged) > 0
+		if isNewBest {
+			best := newEntry
+			best.Attempts = merged.Attempts
+			best.Bonus = merged.Bonus
+			merged = best
+		}
+		return &merged
+	}
+
+	// SQLite читает и перезаписывает запись в одной транзакции, поэтому два одновременных результата
+	// игрока (в том числе из разных процессов) не теряют лучший
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		if err := index.updateEntry(userID, merge); err != nil {
+			return false, fmt.Errorf("failed to save leaderboard entry: %w", err)
 		}
+		return isNewBest, nil
 	}
+	if err := ls.updateEntry(userID, merge); err != nil {
+		return false, err
+	}
+	return isNewBest, nil
+}
 
-	// Если не нашли - добавляем новую запись
-	if !found {
-		leaderboard.Entries = append(leaderboard.Entries, newEntry)
+// updateEntry читает запись игрока из Store и сохраняет то, что вернет update (nil - ничего не сохранять).
+// Атомарность обеспечивает ls.mu, поэтому хранилище должно использоваться одним сервисом
+func (ls *StoreLeaderboardService) updateEntry(userID int64, update func(entry *LeaderboardEntry) *LeaderboardEntry) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	key := strconv.FormatInt(userID, 10)
+	var existing *LeaderboardEntry
+	value, err := ls.store.Get(leaderboardNamespace, key)
+	switch {
+	case err == nil:
+		var entry LeaderboardEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("invalid leaderboard entry %s: %w", key, err)
+		}
+		existing = &entry
+	case !errors.Is(err, ErrNotFound):
+		return fmt.Errorf("failed to load leaderboard entry: %w", err)
 	}
 
-	if err := gs.saveToGist(leaderboard); err != nil {
-		fmt.Printf("Error saving to gist: %v\n", err)
-		return false
+	updated := update(existing)
+	if updated == nil {
+		return nil
 	}
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("failed to encode leaderboard entry: %w", err)
+	}
+	if err := ls.store.Set(leaderboardNamespace, key, data); err != nil {
+		return fmt.Errorf("failed to save leaderboard entry: %w", err)
+	}
+	return nil
+}
 
-	return true
+func (ls *StoreLeaderboardService) GetTop(limit int) ([]LeaderboardEntry, error) {
+	return ls.GetTopFiltered(limit, 0)
 }
 
-func (gs *GistLeaderboardService) GetTop(limit int) []LeaderboardEntry {
-	leaderboard, err := gs.loadFromGist()
-	if err != nil {
-		fmt.Printf("Error loading leaderboard: %v\n", err)
-		return nil
+// GetTopFiltered возвращает топ игроков, прошедших не меньше minAttempts викторин
+func (ls *StoreLeaderboardService) GetTopFiltered(limit, minAttempts int) ([]LeaderboardEntry, error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		return index.topEntries(0, limit, minAttempts)
 	}
 
+	entries, err := ls.entries()
+	if err != nil {
+		return nil, err
+	}
 	// Сортируем по проценту и количеству очков
-	sorted := make([]LeaderboardEntry, len(leaderboard.Entries))
-	copy(sorted, leaderboard.Entries)
+	return limitEntries(sortEntries(filterMinAttempts(entries, minAttempts)), limit), nil
+}
 
-	sort.Slice(sorted, func(i, j int) bool {
-		if sorted[i].Percentage == sorted[j].Percentage {
-			return sorted[i].Score > sorted[j].Score
-		}
-		return sorted[i].Percentage > sorted[j].Percentage
-	})
+// GetTopByAttempts возвращает самых активных игроков по количеству пройденных викторин
+// среди прошедших не меньше minAttempts викторин
+func (ls *StoreLeaderboardService) GetTopByAttempts(limit, minAttempts int) ([]LeaderboardEntry, error) {
+	entries, err := ls.entries()
+	if err != nil {
+		return nil, err
+	}
+	return limitEntries(sortByAttempts(filterMinAttempts(entries, minAttempts)), limit), nil
+}
 
-	if limit > len(sorted) {
-		limit = len(sorted)
+// GetTopComposite возвращает топ по комбинированному рейтингу точности и скорости (см. CompositeScore)
+// среди игроков, прошедших не меньше minAttempts викторин
+func (ls *StoreLeaderboardService) GetTopComposite(limit int, timePenalty float64, minAttempts int) ([]LeaderboardEntry, error) {
+	entries, err := ls.entries()
+	if err != nil {
+		return nil, err
 	}
+	return limitEntries(sortByComposite(filterMinAttempts(entries, minAttempts), timePenalty), limit), nil
+}
 
-	return sorted[:limit]
+// GetTopByPeriod возвращает топ игроков, показавших лучший результат за период,
+// среди прошедших не меньше minAttempts викторин
+func (ls *StoreLeaderboardService) GetTopByPeriod(limit int, period Period, minAttempts int) ([]LeaderboardEntry, error) {
+	entries, err := ls.entries()
+	if err != nil {
+		return nil, err
+	}
+	filtered := filterMinAttempts(filterPeriod(entries, period, time.Now()), minAttempts)
+	return limitEntries(sortEntries(filtered), limit), nil
 }
 
-func (gs *GistLeaderboardService) GetUserPosition(userID int64) (int, *LeaderboardEntry) {
-	top := gs.GetTop(len(gs.GetTop(1000))) // Получаем все записи
-	for i, entry := range top {
-		if entry.UserID == userID {
-			return i + 1, &entry
+// GetUserPosition возвращает место игрока и его запись, -1 - игрока нет в лидерборде
+func (ls *StoreLeaderboardService) GetUserPosition(userID int64) (int, *LeaderboardEntry, error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		entry, above, err := index.entryRank(userID, 0)
+		if err != nil || entry == nil {
+			return -1, nil, err
 		}
+		return above + 1, entry, nil
 	}
-	return -1, nil
-}
 
-// MemoryLeaderboardService - fallback вариант
-type MemoryLeaderboardService struct {
-	leaderboard *Leaderboard
+	entries, err := ls.entries()
+	if err != nil {
+		return -1, nil, err
+	}
+	position, entry := findPosition(sortEntries(entries), userID)
+	return position, entry, nil
 }
 
-func NewMemoryLeaderboardService() *MemoryLeaderboardService {
-	return &MemoryLeaderboardService{
-		leaderboard: &Leaderboard{
-			Entries: make([]LeaderboardEntry, 0),
-		},
+// GetTopWithPosition возвращает страницу лидерборда: limit записей начиная с места offset+1 среди игроков,
+// прошедших не меньше minAttempts викторин, их общее число и место игрока userID среди них
+func (ls *StoreLeaderboardService) GetTopWithPosition(offset, limit, minAttempts int, userID int64) (LeaderboardPage, error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		return indexedPage(index, offset, limit, minAttempts, userID)
+	}
+
+	entries, err := ls.entries()
+	if err != nil {
+		return LeaderboardPage{Position: -1}, err
 	}
+
+	sorted := sortEntries(filterMinAttempts(entries, minAttempts))
+	position, _ := findPosition(sorted, userID)
+	return LeaderboardPage{
+		Entries:  rangeEntries(sorted, offset, limit),
+		Players:  len(sorted),
+		Position: position,
+	}, nil
 }
 
-func (ms *MemoryLeaderboardService) AddEntry(userID int64, username, firstName string, score, total int) bool {
-	ms.leaderboard.mu.Lock()
-	defer ms.leaderboard.mu.Unlock()
+// GetUserRank возвращает место игрока userID среди прошедших не меньше minAttempts викторин и их число,
+// как в подвале лидерборда. Игрок ниже порога получает место, которое занял бы среди них, и сам
+// учитывается в числе игроков. -1 - игрока нет в лидерборде
+func (ls *StoreLeaderboardService) GetUserRank(userID int64, minAttempts int) (position, players int, err error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		return indexedRank(index, userID, minAttempts)
+	}
 
-	percentage := (score * 100) / total
-	newEntry := LeaderboardEntry{
-		UserID:     userID,
-		Username:   username,
-		FirstName:  firstName,
-		Score:      score,
-		Total:      total,
-		Percentage: percentage,
-		Date:       time.Now().Format("02.01.2006 15:04"),
+	entries, err := ls.entries()
+	if err != nil {
+		return -1, 0, err
 	}
 
-	for i, entry := range ms.leaderboard.Entries {
-		if entry.UserID == userID {
-			if percentage > entry.Percentage || (percentage == entry.Percentage && score > entry.Score) {
-				ms.leaderboard.Entries[i] = newEntry
-			}
-			return true
+	ranked := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.UserID == userID || passesMinAttempts(entry, minAttempts) {
+			ranked = append(ranked, entry)
 		}
 	}
+	sorted := sortEntries(ranked)
+	position, _ = findPosition(sorted, userID)
+	return position, len(sorted), nil
+}
 
-	ms.leaderboard.Entries = append(ms.leaderboard.Entries, newEntry)
-	return true
+// GetUserStats возвращает личную статистику игрока за одну загрузку из хранилища
+func (ls *StoreLeaderboardService) GetUserStats(userID int64) (UserStats, bool, error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		entry, above, err := index.entryRank(userID, 0)
+		if err != nil || entry == nil {
+			return UserStats{}, false, err
+		}
+		players, err := index.countEntries(0)
+		if err != nil {
+			return UserStats{}, false, err
+		}
+		return UserStats{Position: above + 1, Players: players, Best: *entry}, true, nil
+	}
+
+	entries, err := ls.entries()
+	if err != nil {
+		return UserStats{}, false, err
+	}
+	sorted := sortEntries(entries)
+	position, entry := findPosition(sorted, userID)
+	if entry == nil {
+		return UserStats{}, false, nil
+	}
+	return UserStats{Position: position, Players: len(sorted), Best: *entry}, true, nil
 }
 
-func (ms *MemoryLeaderboardService) GetTop(limit int) []LeaderboardEntry {
-	ms.leaderboard.mu.RLock()
-	defer ms.leaderboard.mu.RUnlock()
+// Count возвращает количество игроков в лидерборде
+func (ls *StoreLeaderboardService) Count() (int, error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		return index.countEntries(0)
+	}
 
-	sorted := make([]LeaderboardEntry, len(ms.leaderboard.Entries))
-	copy(sorted, ms.leaderboard.Entries)
+	entries, err := ls.entries()
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
 
-	sort.Slice(sorted, func(i, j int) bool {
-		if sorted[i].Percentage == sorted[j].Percentage {
-			return sorted[i].Score > sorted[j].Score
+// Store возвращает хранилище лидерборда
+func (ls *StoreLeaderboardService) Store() Store {
+	return ls.store
+}
+
+// Backend возвращает тип хранилища лидерборда
+func (ls *StoreLeaderboardService) Backend() string {
+	return ls.backend
+}
+
+// FindByUsername ищет игроков по username за одну загрузку из хранилища
+func (ls *StoreLeaderboardService) FindByUsername(query string) ([]RankedEntry, error) {
+	if index, ok := ls.store.(leaderboardIndex); ok {
+		if query = searchQuery(query); query == "" {
+			return nil, nil
 		}
-		return sorted[i].Percentage > sorted[j].Percentage
-	})
+		return index.findEntries(query)
+	}
 
-	if limit > len(sorted) {
-		limit = len(sorted)
+	entries, err := ls.entries()
+	if err != nil {
+		return nil, err
 	}
+	return findByUsername(entries, query), nil
+}
 
-	return sorted[:limit]
+// indexedPage - GetTopWithPosition запросами к leaderboardIndex
+func indexedPage(index leaderboardIndex, offset, limit, minAttempts int, userID int64) (LeaderboardPage, error) {
+	page := LeaderboardPage{Position: -1}
+	var err error
+	if page.Entries, err = index.topEntries(offset, limit, minAttempts); err != nil {
+		return page, err
+	}
+	if page.Players, err = index.countEntries(minAttempts); err != nil {
+		return page, err
+	}
+
+	entry, above, err := index.entryRank(userID, minAttempts)
+	if err != nil {
+		return page, err
+	}
+	if entry != nil && passesMinAttempts(*entry, minAttempts) {
+		page.Position = above + 1
+	}
+	return page, nil
 }
 
-func (ms *MemoryLeaderboardService) GetUserPosition(userID int64) (int, *LeaderboardEntry) {
-	top := ms.GetTop(len(ms.leaderboard.Entries))
-	for i, entry := range top {
-		if entry.UserID == userID {
-			return i + 1, &entry
-		}
+// indexedRank - GetUserRank запросами к leaderboardIndex
+func indexedRank(index leaderboardIndex, userID int64, minAttempts int) (position, players int, err error) {
+	if players, err = index.countEntries(minAttempts); err != nil {
+		return -1, 0, err
 	}
-	return -1, nil
+	entry, above, err := index.entryRank(userID, minAttempts)
+	if err != nil {
+		return -1, 0, err
+	}
+	if entry == nil {
+		return -1, players, nil
+	}
+	// Игрок ниже порога сам учитывается в числе игроков, как в GetUserRank
+	if !passesMinAttempts(*entry, minAttempts) {
+		players++
+	}
+	return above + 1, players, nil
 }
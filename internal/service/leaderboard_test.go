@@ -0,0 +1,803 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addResults сохраняет результаты игрока attempts раз подряд
+func addResults(t *testing.T, ls LeaderboardService, userID int64, username string, score, total, attempts int) {
+	t.Helper()
+	for range attempts {
+		if _, err := ls.AddEntry(userID, username, username, score, total, 0, time.Minute); err != nil {
+			t.Fatalf("AddEntry(%d): %v", userID, err)
+		}
+	}
+}
+
+// userIDs возвращает ID игроков в порядке записей
+func userIDs(entries []LeaderboardEntry) []int64 {
+	ids := make([]int64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.UserID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMinAttemptsAppliesToEveryBoard(t *testing.T) {
+	ls := NewMemoryLeaderboardService()
+	// Новичок с одной идеальной попыткой не должен обходить постоянных игроков
+	addResults(t, ls, 1, "rookie", 10, 10, 1)
+	addResults(t, ls, 2, "regular", 8, 10, 3)
+	addResults(t, ls, 3, "veteran", 6, 10, 5)
+
+	want := []int64{2, 3}
+
+	top, err := ls.GetTopFiltered(10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); !equalIDs(got, want) {
+		t.Errorf("GetTopFiltered = %v, want %v", got, want)
+	}
+
+	top, err = ls.GetTopByPeriod(10, PeriodWeek, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); !equalIDs(got, want) {
+		t.Errorf("GetTopByPeriod(week) = %v, want %v", got, want)
+	}
+
+	top, err = ls.GetTopByPeriod(10, PeriodMonth, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); !equalIDs(got, want) {
+		t.Errorf("GetTopByPeriod(month) = %v, want %v", got, want)
+	}
+
+	top, err = ls.GetTopComposite(10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); !equalIDs(got, want) {
+		t.Errorf("GetTopComposite = %v, want %v", got, want)
+	}
+
+	top, err = ls.GetTopByAttempts(10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, wantActive := userIDs(top), []int64{3, 2}; !equalIDs(got, wantActive) {
+		t.Errorf("GetTopByAttempts = %v, want %v", got, wantActive)
+	}
+
+	// Без ограничения новичок снова первый
+	top, err = ls.GetTopByPeriod(10, PeriodAll, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, wantAll := userIDs(top), []int64{1, 2, 3}; !equalIDs(got, wantAll) {
+		t.Errorf("GetTopByPeriod(all, 0) = %v, want %v", got, wantAll)
+	}
+}
+
+// putEntry сохраняет запись лидерборда как есть, с любым временем результата
+func putEntry(t *testing.T, store Store, entry LeaderboardEntry) {
+	t.Helper()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(leaderboardNamespace, fmt.Sprint(entry.UserID), data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetTopByPeriod(t *testing.T) {
+	store := NewMemoryStore()
+	ls := NewStoreLeaderboardService(store, "memory")
+	now := time.Now()
+	for _, entry := range []LeaderboardEntry{
+		{UserID: 1, Score: 9, Total: 10, Percentage: 90, Attempts: 1, CreatedAt: now.Add(-time.Hour)},
+		{UserID: 2, Score: 10, Total: 10, Percentage: 100, Attempts: 1, CreatedAt: now.AddDate(0, 0, -3)},
+		{UserID: 3, Score: 10, Total: 10, Percentage: 100, Attempts: 1, CreatedAt: now.AddDate(0, 0, -10)},
+		{UserID: 4, Score: 8, Total: 10, Percentage: 80, Attempts: 1, CreatedAt: now.AddDate(0, 0, -20)},
+		{UserID: 5, Score: 10, Total: 10, Percentage: 100, Attempts: 5, CreatedAt: now.AddDate(0, -2, 0)},
+		// Запись без времени попадает только в лидерборд за все время
+		{UserID: 6, Score: 7, Total: 10, Percentage: 70, Attempts: 1},
+	} {
+		putEntry(t, store, entry)
+	}
+
+	tests := []struct {
+		period Period
+		want   []int64
+	}{
+		{PeriodWeek, []int64{2, 1}},
+		{PeriodMonth, []int64{2, 3, 1, 4}},
+		{PeriodAll, []int64{2, 3, 5, 1, 4, 6}},
+	}
+	for _, tt := range tests {
+		top, err := ls.GetTopByPeriod(10, tt.period, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := userIDs(top); !equalIDs(got, tt.want) {
+			t.Errorf("period %d: top = %v, want %v", tt.period, got, tt.want)
+		}
+	}
+
+	top, err := ls.GetTopByPeriod(2, PeriodMonth, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); !equalIDs(got, []int64{2, 3}) {
+		t.Errorf("month top 2 = %v, want [2 3]", got)
+	}
+}
+
+func TestLeaderboardEntryJSON(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	// Новый формат: время хранится в created_at, Date выводится из него
+	var entry LeaderboardEntry
+	if err := json.Unmarshal([]byte(`{"user_id": 1, "score": 5, "total": 10, "created_at": "2026-10-16T09:30:00Z"}`), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if !entry.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreatedAt = %v, want %v", entry.CreatedAt, createdAt)
+	}
+	if want := createdAt.Local().Format(entryDateLayout); entry.Date != want {
+		t.Errorf("Date = %q, want %q", entry.Date, want)
+	}
+
+	// Старый формат: только Date в локальном времени сервера
+	var legacy LeaderboardEntry
+	if err := json.Unmarshal([]byte(`{"user_id": 2, "score": 5, "total": 10, "date": "16.10.2026 09:30"}`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local); !legacy.CreatedAt.Equal(want) {
+		t.Errorf("legacy CreatedAt = %v, want %v", legacy.CreatedAt, want)
+	}
+	if legacy.Date != "16.10.2026 09:30" {
+		t.Errorf("legacy Date = %q, want it unchanged", legacy.Date)
+	}
+
+	// Неразбираемая дата не ломает чтение записи, время остается неизвестным
+	var broken LeaderboardEntry
+	if err := json.Unmarshal([]byte(`{"user_id": 3, "date": "вчера"}`), &broken); err != nil {
+		t.Fatal(err)
+	}
+	if !broken.CreatedAt.IsZero() || broken.Date != "вчера" {
+		t.Errorf("unparsable date gave CreatedAt %v, Date %q", broken.CreatedAt, broken.Date)
+	}
+
+	// Запись сохраняется с created_at и читается обратно без потерь
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"created_at":"2026-10-16T09:30:00Z"`) {
+		t.Errorf("marshaled entry has no created_at: %s", data)
+	}
+	var roundTrip LeaderboardEntry
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatal(err)
+	}
+	if !roundTrip.CreatedAt.Equal(createdAt) || roundTrip.Date != entry.Date {
+		t.Errorf("round trip = %v/%q, want %v/%q", roundTrip.CreatedAt, roundTrip.Date, createdAt, entry.Date)
+	}
+	if data, _ := json.Marshal(LeaderboardEntry{UserID: 4}); strings.Contains(string(data), "created_at") {
+		t.Errorf("entry without time has created_at: %s", data)
+	}
+}
+
+func TestAddEntryStoresCreatedAt(t *testing.T) {
+	for _, backend := range storeBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			store, reopen := backend.open(t)
+			before := time.Now()
+			addResults(t, NewStoreLeaderboardService(store, backend.name), 1, "player", 7, 10, 1)
+
+			// Хранилища с диском перечитываются, чтобы время прошло через сохраненный JSON
+			if reopen != nil {
+				store = reopen()
+			}
+			top, err := NewStoreLeaderboardService(store, backend.name).GetTop(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(top) != 1 {
+				t.Fatalf("%d entries after reopening, want 1", len(top))
+			}
+			if got := top[0].CreatedAt; got.Before(before) || got.After(time.Now()) {
+				t.Errorf("CreatedAt = %v, want the time of AddEntry", got)
+			}
+			if want := top[0].CreatedAt.Local().Format(entryDateLayout); top[0].Date != want {
+				t.Errorf("Date = %q, want %q", top[0].Date, want)
+			}
+		})
+	}
+}
+
+func TestPeriodSince(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	if got := PeriodAll.Since(now); !got.IsZero() {
+		t.Errorf("all time starts at %v, want zero time", got)
+	}
+	if got, want := PeriodWeek.Since(now), time.Date(2026, 3, 24, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("week starts at %v, want %v", got, want)
+	}
+	// 31 февраля нет - AddDate нормализует дату в 3 марта
+	if got, want := PeriodMonth.Since(now), time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("month starts at %v, want %v", got, want)
+	}
+}
+
+func TestCompositeScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       LeaderboardEntry
+		timePenalty float64
+		want        float64
+	}{
+		{"accurate and fast", LeaderboardEntry{Score: 9, Total: 10, Duration: 50}, 1, 85},
+		{"accurate and slow", LeaderboardEntry{Score: 8, Total: 10, Duration: 80}, 1, 72},
+		{"no penalty", LeaderboardEntry{Score: 8, Total: 10, Duration: 80}, 0, 80},
+		{"empty quiz", LeaderboardEntry{}, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := CompositeScore(tt.entry, tt.timePenalty); got != tt.want {
+			t.Errorf("%s: CompositeScore = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetTopComposite(t *testing.T) {
+	ls := NewMemoryLeaderboardService()
+	results := []struct {
+		userID   int64
+		score    int
+		duration time.Duration
+	}{
+		{1, 9, 50 * time.Second},   // 90%, 5 с на вопрос
+		{2, 10, 200 * time.Second}, // 100%, 20 с на вопрос
+		{3, 8, 10 * time.Second},   // 80%, 1 с на вопрос
+		{4, 10, 0},                 // время неизвестно (результат до появления времени)
+	}
+	for _, r := range results {
+		if _, err := ls.AddEntry(r.userID, "", "player", r.score, 10, 0, r.duration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		timePenalty float64
+		want        []int64
+	}{
+		{0, []int64{2, 1, 3}}, // только точность
+		{1, []int64{1, 2, 3}}, // 85, 80, 79
+		{3, []int64{3, 1, 2}}, // 77, 75, 40
+	}
+	for _, tt := range tests {
+		top, err := ls.GetTopComposite(10, tt.timePenalty, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := userIDs(top); !equalIDs(got, tt.want) {
+			t.Errorf("GetTopComposite(penalty %g) = %v, want %v", tt.timePenalty, got, tt.want)
+		}
+	}
+
+	// Обычный топ по точности не зависит от времени
+	top, err := ls.GetTopFiltered(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); len(got) != 4 || got[2] != 1 || got[3] != 3 {
+		t.Errorf("GetTopFiltered = %v, want players 1 and 3 after both perfect results", got)
+	}
+}
+
+func TestRangeEntries(t *testing.T) {
+	var sorted []LeaderboardEntry
+	for id := int64(1); id <= 25; id++ {
+		sorted = append(sorted, LeaderboardEntry{UserID: id})
+	}
+
+	tests := []struct {
+		offset, limit int
+		wantFirst     int64
+		wantLen       int
+	}{
+		{0, 10, 1, 10},
+		{10, 10, 11, 10},
+		{20, 10, 21, 5}, // последняя страница неполная
+		{25, 10, 0, 0},
+		{40, 10, 0, 0},
+		{-5, 10, 1, 10}, // отрицательное смещение - с начала
+	}
+	for _, tt := range tests {
+		got := rangeEntries(sorted, tt.offset, tt.limit)
+		if len(got) != tt.wantLen {
+			t.Errorf("rangeEntries(%d, %d): %d entries, want %d", tt.offset, tt.limit, len(got), tt.wantLen)
+			continue
+		}
+		if tt.wantLen > 0 && got[0].UserID != tt.wantFirst {
+			t.Errorf("rangeEntries(%d, %d) starts with %d, want %d", tt.offset, tt.limit, got[0].UserID, tt.wantFirst)
+		}
+	}
+}
+
+func TestGetTopWithPosition(t *testing.T) {
+	ls := NewMemoryLeaderboardService()
+	addResults(t, ls, 1, "alice", 9, 10, 2)
+	addResults(t, ls, 2, "bob", 7, 10, 2)
+	addResults(t, ls, 3, "carol", 10, 10, 2)
+	addResults(t, ls, 4, "dave", 5, 10, 2)
+	addResults(t, ls, 5, "eve", 10, 10, 1)
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		minAttempts   int
+		userID        int64
+		wantIDs       []int64
+		wantPlayers   int
+		wantPosition  int
+	}{
+		{"first page", 0, 2, 0, 2, []int64{3, 5}, 5, 4},
+		{"second page", 2, 2, 0, 2, []int64{1, 2}, 5, 4},
+		{"past the end", 10, 2, 0, 4, []int64{}, 5, 5},
+		{"filtered player", 0, 10, 2, 5, []int64{3, 1, 2, 4}, 4, -1},
+		{"filtered rank", 0, 1, 2, 4, []int64{3}, 4, 4},
+		{"unknown player", 0, 1, 0, 42, []int64{3}, 5, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := ls.GetTopWithPosition(tt.offset, tt.limit, tt.minAttempts, tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := userIDs(page.Entries); !equalIDs(got, tt.wantIDs) {
+				t.Errorf("Entries = %v, want %v", got, tt.wantIDs)
+			}
+			if page.Players != tt.wantPlayers {
+				t.Errorf("Players = %d, want %d", page.Players, tt.wantPlayers)
+			}
+			if page.Position != tt.wantPosition {
+				t.Errorf("Position = %d, want %d", page.Position, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestGetUserRank(t *testing.T) {
+	ls := NewMemoryLeaderboardService()
+	addResults(t, ls, 1, "rookie", 10, 10, 1)
+	addResults(t, ls, 2, "regular", 8, 10, 3)
+	addResults(t, ls, 3, "veteran", 6, 10, 5)
+
+	tests := []struct {
+		name         string
+		userID       int64
+		minAttempts  int
+		wantPosition int
+		wantPlayers  int
+	}{
+		{"no filter", 2, 0, 2, 3},
+		// Новичок выше по результату не сдвигает место прошедших порог, как и в подвале лидерборда
+		{"filtered", 2, 2, 1, 2},
+		{"filtered last", 3, 2, 2, 2},
+		{"below the threshold", 1, 2, 1, 3},
+		{"unknown player", 42, 2, -1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position, players, err := ls.GetUserRank(tt.userID, tt.minAttempts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if position != tt.wantPosition || players != tt.wantPlayers {
+				t.Errorf("GetUserRank = %d of %d, want %d of %d", position, players, tt.wantPosition, tt.wantPlayers)
+			}
+			if tt.wantPosition == -1 || tt.userID == 1 {
+				return
+			}
+			page, err := ls.GetTopWithPosition(0, 10, tt.minAttempts, tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Position != position || page.Players != players {
+				t.Errorf("GetTopWithPosition = %d of %d, GetUserRank = %d of %d", page.Position, page.Players, position, players)
+			}
+		})
+	}
+}
+
+// countingStore считает загрузки и записи в хранилище
+type countingStore struct {
+	Store
+	lists int
+	sets  int
+}
+
+func (s *countingStore) Set(namespace, key string, value []byte) error {
+	s.sets++
+	return s.Store.Set(namespace, key, value)
+}
+
+func (s *countingStore) List(namespace string) (map[string][]byte, error) {
+	s.lists++
+	return s.Store.List(namespace)
+}
+
+func benchmarkLeaderboard(b *testing.B) (*StoreLeaderboardService, *countingStore) {
+	b.Helper()
+	store := &countingStore{Store: NewMemoryStore()}
+	ls := NewStoreLeaderboardService(store, "memory")
+	for i := range 1000 {
+		if _, err := ls.AddEntry(int64(i+1), "player", "player", i%11, 10, 0, time.Minute); err != nil {
+			b.Fatal(err)
+		}
+	}
+	store.lists = 0
+	return ls, store
+}
+
+// BenchmarkLeaderboardPage сравнивает страницу и место игрока отдельными запросами
+// с одним вызовом GetTopWithPosition
+func BenchmarkLeaderboardPage(b *testing.B) {
+	b.Run("separate", func(b *testing.B) {
+		ls, store := benchmarkLeaderboard(b)
+		for b.Loop() {
+			if _, err := ls.GetTopFiltered(10, 0); err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := ls.GetUserPosition(500); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(store.lists)/float64(b.N), "loads/op")
+	})
+	b.Run("combined", func(b *testing.B) {
+		ls, store := benchmarkLeaderboard(b)
+		for b.Loop() {
+			if _, err := ls.GetTopWithPosition(0, 10, 0, 500); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(store.lists)/float64(b.N), "loads/op")
+	})
+}
+
+func TestAddEntryRefreshesName(t *testing.T) {
+	ls := NewMemoryLeaderboardService()
+	if _, err := ls.AddEntry(1, "old_nick", "Old", 9, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// Игрок сменил ник и сыграл хуже - лучший результат остается, а имя обновляется
+	isNewBest, err := ls.AddEntry(1, "new_nick", "New", 3, 10, 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isNewBest {
+		t.Error("lower score reported as a new best")
+	}
+
+	top, err := ls.GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 {
+		t.Fatalf("GetTop = %+v, want one entry", top)
+	}
+	entry := top[0]
+	if entry.Username != "new_nick" || entry.FirstName != "New" {
+		t.Errorf("name = %q/%q, want new_nick/New", entry.Username, entry.FirstName)
+	}
+	if entry.Score != 9 || entry.Attempts != 2 {
+		t.Errorf("score %d, attempts %d, want 9 and 2", entry.Score, entry.Attempts)
+	}
+}
+
+func TestMinLeaderboardPercent(t *testing.T) {
+	for _, backend := range storeBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			store, _ := backend.open(t)
+			ls := NewStoreLeaderboardService(store, backend.name)
+			ls.SetMinPercent(50)
+
+			add := func(score, total int) bool {
+				t.Helper()
+				isNewBest, err := ls.AddEntry(1, "player", "Player", score, total, 0, time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return isNewBest
+			}
+
+			// Ниже порога результат не сохраняется, в том числе 49.9%
+			if add(4, 10) || add(499, 1000) {
+				t.Error("result below the threshold became a new best")
+			}
+			if count, err := ls.Count(); err != nil || count != 0 {
+				t.Fatalf("Count = %d, %v: a result below the threshold was stored", count, err)
+			}
+
+			// Ровно на пороге и выше - сохраняется
+			if !add(5, 10) {
+				t.Error("result at the threshold was not stored")
+			}
+			if !add(7, 10) {
+				t.Error("result above the threshold did not become a new best")
+			}
+
+			// Слабый результат не меняет лучший счет, но попытка засчитывается и имя обновляется
+			isNewBest, err := ls.AddEntry(1, "renamed", "Renamed", 1, 10, 0, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isNewBest {
+				t.Error("result below the threshold became a new best")
+			}
+			_, entry, err := ls.GetUserPosition(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry == nil || entry.Score != 7 || entry.Percentage != 70 || entry.Attempts != 3 {
+				t.Errorf("entry = %+v, want score 7 (70%%) after 3 attempts", entry)
+			}
+			if entry != nil && (entry.Username != "renamed" || entry.FirstName != "Renamed") {
+				t.Errorf("name = %q/%q, want renamed/Renamed", entry.Username, entry.FirstName)
+			}
+		})
+	}
+}
+
+func TestMinLeaderboardPercentOption(t *testing.T) {
+	fg := newFakeGist(t)
+	dir := t.TempDir()
+	backends := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"memory", nil},
+		{"file", map[string]string{"LEADERBOARD_FILE": filepath.Join(dir, "leaderboard.json")}},
+		{"sqlite", map[string]string{"LEADERBOARD_SQLITE_PATH": filepath.Join(dir, "leaderboard.db")}},
+		{"gist", map[string]string{"GITHUB_GIST_ID": "gist-id", "GITHUB_TOKEN": "token"}},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, key := range []string{"GITHUB_GIST_ID", "GITHUB_TOKEN", "LEADERBOARD_SQLITE_PATH", "LEADERBOARD_FILE"} {
+				t.Setenv(key, backend.env[key])
+			}
+			ls, err := NewLeaderboardService(LeaderboardOptions{MinPercent: 30, GistClient: fg.client()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ls.Backend() != backend.name {
+				t.Fatalf("backend = %s, want %s", ls.Backend(), backend.name)
+			}
+
+			if isNewBest, err := ls.AddEntry(1, "low", "Low", 2, 10, 0, time.Minute); err != nil || isNewBest {
+				t.Errorf("20%% result: isNewBest %v, err %v", isNewBest, err)
+			}
+			if isNewBest, err := ls.AddEntry(2, "high", "High", 3, 10, 0, time.Minute); err != nil || !isNewBest {
+				t.Errorf("30%% result: isNewBest %v, err %v", isNewBest, err)
+			}
+			top, err := ls.GetTop(10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := userIDs(top); !equalIDs(got, []int64{2}) {
+				t.Errorf("top = %v, want only the result at the threshold", got)
+			}
+		})
+	}
+}
+
+func TestLeaderboardGistFallback(t *testing.T) {
+	t.Setenv("GITHUB_GIST_ID", "gist-id")
+	t.Setenv("GITHUB_TOKEN", "token")
+	t.Setenv("LEADERBOARD_SQLITE_PATH", "")
+	t.Setenv("LEADERBOARD_FILE", "")
+
+	var requests int
+	failing := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		requests++
+		return nil, errors.New("connection refused")
+	})}
+	opts := LeaderboardOptions{
+		GistClient: failing,
+		GistRetry:  RetryPolicy{Attempts: 1},
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// Недоступный Gist проверяется при запуске, и бот работает с лидербордом в памяти
+	ls, err := NewLeaderboardService(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls.Backend() != "memory" {
+		t.Errorf("backend = %q, want the memory fallback", ls.Backend())
+	}
+	if requests == 0 {
+		t.Error("gist was not checked at startup")
+	}
+
+	opts.FailFast = true
+	if _, err := NewLeaderboardService(opts); err == nil {
+		t.Error("FailFast: unavailable gist did not fail the startup")
+	}
+
+	// Доступный Gist используется как обычно
+	opts.GistClient = newFakeGist(t).client()
+	ls, err = NewLeaderboardService(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls.Backend() != "gist" {
+		t.Errorf("backend = %q, want gist", ls.Backend())
+	}
+}
+
+func TestAddEntryWithoutQuestions(t *testing.T) {
+	for _, backend := range storeBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			store, _ := backend.open(t)
+			ls := NewStoreLeaderboardService(store, backend.name)
+
+			isNewBest, err := ls.AddEntry(1, "player", "Player", 0, 0, 0, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isNewBest {
+				t.Error("empty quiz became a new best")
+			}
+			if count, err := ls.Count(); err != nil || count != 0 {
+				t.Errorf("Count = %d, %v: an empty quiz was recorded", count, err)
+			}
+		})
+	}
+
+	for _, precision := range []int{0, 1, 2} {
+		if got := FormatPercentage(0, 0, precision); got != "0" {
+			t.Errorf("FormatPercentage(0, 0, %d) = %q, want 0", precision, got)
+		}
+	}
+}
+
+func TestFileLeaderboard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "leaderboard.json")
+
+	// Запись сохраняется сразу после AddEntry, без временных файлов рядом
+	ls := NewFileLeaderboardService(path)
+	addResults(t, ls, 1, "alice", 8, 10, 1)
+	addResults(t, ls, 1, "alice", 6, 10, 1) // худший результат не заменяет лучший
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("leaderboard was not saved: %v", err)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	// Новый сервис читает сохраненный лидерборд при запуске
+	top, err := NewFileLeaderboardService(path).GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].Score != 8 || top[0].Attempts != 2 {
+		t.Errorf("loaded leaderboard = %+v, want alice's best 8/10 after 2 attempts", top)
+	}
+
+	// Поврежденный файл откладывается, и лидерборд начинается с нуля
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := NewFileLeaderboardService(path)
+	corrupt.store.(*FileStore).SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if top, err := corrupt.GetTop(10); err != nil || len(top) != 0 {
+		t.Errorf("corrupt file: GetTop = %v, %v, want an empty leaderboard", top, err)
+	}
+	if data, err := os.ReadFile(path + ".corrupt"); err != nil || string(data) != "{not json" {
+		t.Errorf("corrupt file was not kept aside: %q, %v", data, err)
+	}
+	addResults(t, corrupt, 2, "bob", 5, 10, 1)
+	if top, _ := NewFileLeaderboardService(path).GetTop(10); len(top) != 1 || top[0].UserID != 2 {
+		t.Errorf("after recovery = %+v, want only bob", top)
+	}
+
+	t.Setenv("GITHUB_GIST_ID", "")
+	t.Setenv("LEADERBOARD_SQLITE_PATH", "")
+	t.Setenv("LEADERBOARD_FILE", path)
+	selected, err := NewLeaderboardService(LeaderboardOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected.Backend() != "file" {
+		t.Errorf("LEADERBOARD_FILE selected %q, want file", selected.Backend())
+	}
+}
+
+func TestLeaderboardErrorsPropagate(t *testing.T) {
+	fg := newFakeGist(t)
+	ls := NewGistLeaderboardServiceWithClient("gist-id", "token", 0, fg.client())
+	gist := ls.Store().(*GistStore)
+	gist.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gist.SetRetryPolicy(RetryPolicy{Attempts: 1})
+	addResults(t, ls, 1, "alice", 7, 10, 1)
+
+	fg.fail(1000, http.StatusInternalServerError)
+	calls := map[string]func() error{
+		"AddEntry": func() error {
+			isNewBest, err := ls.AddEntry(2, "bob", "Bob", 9, 10, 0, time.Minute)
+			if isNewBest {
+				t.Error("AddEntry reported a new best although saving failed")
+			}
+			return err
+		},
+		"GetTop": func() error {
+			top, err := ls.GetTop(10)
+			if top != nil {
+				t.Errorf("GetTop returned %v with an error", top)
+			}
+			return err
+		},
+		"GetTopFiltered":   func() error { _, err := ls.GetTopFiltered(10, 0); return err },
+		"GetTopByAttempts": func() error { _, err := ls.GetTopByAttempts(10, 0); return err },
+		"GetTopComposite":  func() error { _, err := ls.GetTopComposite(10, 0, 0); return err },
+		"GetTopByPeriod":   func() error { _, err := ls.GetTopByPeriod(10, PeriodWeek, 0); return err },
+		"GetUserPosition":  func() error { _, _, err := ls.GetUserPosition(1); return err },
+		"GetUserRank":      func() error { _, _, err := ls.GetUserRank(1, 2); return err },
+		"GetTopWithPosition": func() error {
+			page, err := ls.GetTopWithPosition(0, 10, 0, 1)
+			if page.Position != -1 {
+				t.Errorf("GetTopWithPosition position = %d with an error, want -1", page.Position)
+			}
+			return err
+		},
+		"GetUserStats":   func() error { _, _, err := ls.GetUserStats(1); return err },
+		"Count":          func() error { _, err := ls.Count(); return err },
+		"FindByUsername": func() error { _, err := ls.FindByUsername("alice"); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("%s: err = %v, want the Gist failure", name, err)
+		}
+	}
+
+	// Gist снова доступен - ошибок нет, прежние данные на месте
+	fg.fail(0, 0)
+	top, err := ls.GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userIDs(top); !equalIDs(got, []int64{1}) {
+		t.Errorf("top after recovery = %v, want [1]", got)
+	}
+}
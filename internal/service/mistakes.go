@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// mistakesNamespace - пространство имен ошибок в Store, ключ - ID пользователя
+const mistakesNamespace = "mistakes"
+
+// QuestionMistakes - сколько раз пользователь ошибся в вопросе
+type QuestionMistakes struct {
+	QuestionID int    `json:"question_id"`
+	Question   string `json:"question"`
+	Count      int    `json:"count"`
+}
+
+// PendingMistake - неправильный ответ игрока, накопленный в сессии до завершения викторины
+type PendingMistake struct {
+	UserID     int64
+	QuestionID int
+	Question   string
+}
+
+// MistakeStats собирает ошибки каждого пользователя по вопросам в Store
+type MistakeStats struct {
+	mu     sync.Mutex // RecordAll читает и перезаписывает ошибки пользователя, это должно быть атомарно
+	store  Store
+	logger *slog.Logger
+}
+
+// NewMistakeStats хранит ошибки пользователей в памяти
+func NewMistakeStats() *MistakeStats {
+	return NewStoreMistakeStats(NewMemoryStore(), nil)
+}
+
+// NewStoreMistakeStats хранит ошибки пользователей в store. logger == nil - slog.Default()
+func NewStoreMistakeStats(store Store, logger *slog.Logger) *MistakeStats {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &MistakeStats{store: store, logger: logger}
+}
+
+// load возвращает ошибки пользователя, пустой список - если их еще нет
+func (s *MistakeStats) load(userID int64) ([]QuestionMistakes, error) {
+	value, err := s.store.Get(mistakesNamespace, strconv.FormatInt(userID, 10))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var mistakes []QuestionMistakes
+	if err := json.Unmarshal(value, &mistakes); err != nil {
+		return nil, err
+	}
+	return mistakes, nil
+}
+
+// Record учитывает неправильный ответ пользователя на вопрос
+func (s *MistakeStats) Record(userID int64, question QuizQuestion) {
+	s.RecordAll([]PendingMistake{{UserID: userID, QuestionID: question.ID, Question: question.Question}})
+}
+
+// RecordAll учитывает неправильные ответы, накопленные за викторину: ошибки каждого
+// пользователя читаются из хранилища и записываются в него один раз
+func (s *MistakeStats) RecordAll(mistakes []PendingMistake) {
+	if len(mistakes) == 0 {
+		return
+	}
+
+	var users []int64
+	byUser := make(map[int64][]PendingMistake)
+	for _, mistake := range mistakes {
+		if _, seen := byUser[mistake.UserID]; !seen {
+			users = append(users, mistake.UserID)
+		}
+		byUser[mistake.UserID] = append(byUser[mistake.UserID], mistake)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userID := range users {
+		s.record(userID, byUser[userID])
+	}
+}
+
+// record добавляет ошибки пользователя к сохраненным одной записью в хранилище
+func (s *MistakeStats) record(userID int64, mistakes []PendingMistake) {
+	questions, err := s.load(userID)
+	if err != nil {
+		s.logger.Error("loading mistakes failed", "user_id", userID, "err", err)
+		return
+	}
+
+	for _, mistake := range mistakes {
+		i := 0
+		for i < len(questions) && questions[i].QuestionID != mistake.QuestionID {
+			i++
+		}
+		if i == len(questions) {
+			questions = append(questions, QuestionMistakes{QuestionID: mistake.QuestionID})
+		}
+		questions[i].Question = mistake.Question
+		questions[i].Count++
+	}
+
+	data, err := json.Marshal(questions)
+	if err == nil {
+		err = s.store.Set(mistakesNamespace, strconv.FormatInt(userID, 10), data)
+	}
+	if err != nil {
+		s.logger.Error("saving mistakes failed", "user_id", userID, "err", err)
+	}
+}
+
+// Top возвращает до limit вопросов, в которых пользователь ошибался чаще всего
+func (s *MistakeStats) Top(userID int64, limit int) []QuestionMistakes {
+	result, err := s.load(userID)
+	if err != nil {
+		s.logger.Error("loading mistakes failed", "user_id", userID, "err", err)
+		return nil
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].QuestionID < result[j].QuestionID
+		}
+		return result[i].Count > result[j].Count
+	})
+
+	if limit > len(result) {
+		limit = len(result)
+	}
+	return result[:limit]
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+)
+
+// ChatPreferences - настройки отдельного чата
+type ChatPreferences struct {
+	// HideLeaderboard - скрыть лидерборд в чате (по умолчанию показывается)
+	HideLeaderboard bool `json:"hide_leaderboard"`
+
+	// NotifyOvertake - сообщать игроку, что его обогнали в лидерборде. Личные сообщения без запроса
+	// похожи на спам, поэтому уведомления включаются в /settings (по умолчанию выключены)
+	NotifyOvertake bool `json:"notify_overtake"`
+
+	// BotBlocked - игрок заблокировал бота: уведомления ему не отправляются, пока он снова не сыграет
+	BotBlocked bool `json:"bot_blocked,omitempty"`
+}
+
+// defaultPreferences - настройки чата, который их еще не менял
+func defaultPreferences() ChatPreferences {
+	return ChatPreferences{}
+}
+
+// preferencesNamespace - пространство имен настроек в Store, ключ - ID чата
+const preferencesNamespace = "preferences"
+
+// PreferencesService хранит настройки чатов в Store
+type PreferencesService struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// NewPreferencesService хранит настройки чатов в памяти. logger == nil - slog.Default()
+func NewPreferencesService(logger *slog.Logger) *PreferencesService {
+	return NewStorePreferencesService(NewMemoryStore(), logger)
+}
+
+func NewStorePreferencesService(store Store, logger *slog.Logger) *PreferencesService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PreferencesService{store: store, logger: logger}
+}
+
+// Get возвращает настройки чата или настройки по умолчанию. Настройки, которых не было
+// при сохранении, тоже получают значения по умолчанию
+func (ps *PreferencesService) Get(chatID int64) ChatPreferences {
+	prefs := defaultPreferences()
+
+	value, err := ps.store.Get(preferencesNamespace, strconv.FormatInt(chatID, 10))
+	if err != nil {
+		return prefs
+	}
+	if err := json.Unmarshal(value, &prefs); err != nil {
+		ps.logger.Error("loading chat preferences failed", "chat_id", chatID, "err", err)
+	}
+	return prefs
+}
+
+// Set сохраняет настройки чата
+func (ps *PreferencesService) Set(chatID int64, prefs ChatPreferences) {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		ps.logger.Error("encoding chat preferences failed", "chat_id", chatID, "err", err)
+		return
+	}
+
+	if err := ps.store.Set(preferencesNamespace, strconv.FormatInt(chatID, 10), data); err != nil {
+		ps.logger.Error("saving chat preferences failed", "chat_id", chatID, "err", err)
+	}
+}
@@ -1,15 +1,496 @@
 package service
 
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
 type QuizQuestion struct {
 	ID       int
 	Question string
 	Options  []string
 	Correct  int
+
+	// Bonus - бонусный вопрос, задается после основной викторины и приносит дополнительные очки
+	Bonus bool
+
+	// Important - ответ нужно подтвердить вторым нажатием, чтобы избежать случайных ошибок
+	Important bool
+
+	// Practice - вопрос входит в набор для тренировки (/practice)
+	Practice bool
+
+	// Retired - устаревший вопрос: хранится для истории, но в викторины не попадает
+	Retired bool
+
+	// TimeLimit - время на ответ, по истечении которого вопрос засчитывается как неправильный.
+	// 0 - без ограничения
+	TimeLimit time.Duration
+
+	// Category - категория вопроса из префикса [категория] в файле, пустая - без категории
+	Category string
+
+	// Tags - произвольные теги вопроса в нижнем регистре, по ним можно начать викторину (/quiz tag:<тег>)
+	Tags []string
+
+	// Explanation - необязательное пояснение к правильному ответу, показывается после ответа
+	Explanation string
+
+	// Difficulty - сложность от 1 до MaxDifficulty, правильный ответ приносит столько очков. 0 - как 1
+	Difficulty int
+
+	// Image - картинка к вопросу: ссылка http(s) или file_id Telegram, пустая - вопрос без картинки
+	Image string
+
+	// OrderedAnswer - вопрос "расставьте по порядку": индексы всех вариантов в правильном порядке.
+	// Игрок нажимает варианты по очереди, ответ верен только при полном совпадении. Correct
+	// у такого вопроса - первый вариант последовательности. Пустой - обычный вопрос
+	OrderedAnswer []int
+
+	// CorrectSet - вопрос с несколькими правильными ответами: индексы всех правильных вариантов
+	// по возрастанию. Игрок отмечает варианты и подтверждает выбор. Correct у такого вопроса -
+	// первый правильный вариант. Пустой - обычный вопрос
+	CorrectSet []int
+
+	// AcceptedAnswers - равноценные формулировки правильного ответа. После ответа показывается
+	// случайная из них или сам вариант Correct, чтобы подсказка не повторялась слово в слово
+	AcceptedAnswers []string
+}
+
+// Ordered проверяет, что вопрос требует расставить варианты по порядку
+func (q QuizQuestion) Ordered() bool {
+	return len(q.OrderedAnswer) > 0
+}
+
+// Multi проверяет, что у вопроса несколько правильных ответов
+func (q QuizQuestion) Multi() bool {
+	return len(q.CorrectSet) > 0
+}
+
+// IsCorrectOption проверяет, что вариант index - правильный ответ (один из правильных у вопроса
+// с несколькими ответами). У вопроса на порядок правильным считается только первый вариант
+func (q QuizQuestion) IsCorrectOption(index int) bool {
+	if q.Multi() {
+		return slices.Contains(q.CorrectSet, index)
+	}
+	return index == q.Correct
+}
+
+// CorrectText возвращает правильный ответ для показа игроку: вариант Correct,
+// у вопроса на порядок - все варианты в правильном порядке, у вопроса с несколькими
+// ответами - все правильные варианты через запятую
+func (q QuizQuestion) CorrectText() string {
+	switch {
+	case q.Ordered():
+		return q.orderText(q.OrderedAnswer)
+	case q.Multi():
+		return q.listText(q.CorrectSet)
+	}
+	return q.Options[q.Correct]
+}
+
+// RevealText возвращает правильный ответ для показа после ответа: у вопроса с AcceptedAnswers -
+// вариант Correct или одну из его формулировок, выбранную randIntn, у остальных - CorrectText
+func (q QuizQuestion) RevealText(randIntn func(int) int) string {
+	if len(q.AcceptedAnswers) == 0 || q.Ordered() || q.Multi() {
+		return q.CorrectText()
+	}
+	if i := randIntn(len(q.AcceptedAnswers) + 1); i > 0 {
+		return q.AcceptedAnswers[i-1]
+	}
+	return q.Options[q.Correct]
+}
+
+// listText перечисляет варианты с индексами indexes через запятую
+func (q QuizQuestion) listText(indexes []int) string {
+	parts := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		if i >= 0 && i < len(q.Options) {
+			parts = append(parts, q.Options[i])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// orderText записывает варианты в порядке order через стрелку
+func (q QuizQuestion) orderText(order []int) string {
+	parts := make([]string, 0, len(order))
+	for _, i := range order {
+		if i >= 0 && i < len(q.Options) {
+			parts = append(parts, q.Options[i])
+		}
+	}
+	return strings.Join(parts, " → ")
+}
+
+// MaxDifficulty - максимальная сложность вопроса
+const MaxDifficulty = 3
+
+// Weight возвращает количество очков за правильный ответ на вопрос
+func (q QuizQuestion) Weight() int {
+	if q.Difficulty < 1 {
+		return 1
+	}
+	return q.Difficulty
+}
+
+// MaxPoints возвращает максимум очков за вопрос. При частичном зачете (partial) каждый правильный
+// вариант вопроса с несколькими ответами приносит Weight очков, иначе вопрос стоит Weight
+func (q QuizQuestion) MaxPoints(partial bool) int {
+	if partial && q.Multi() {
+		return q.Weight() * len(q.CorrectSet)
+	}
+	return q.Weight()
+}
+
+// PartialPoints возвращает очки частичного зачета за выбранные варианты chosen вопроса
+// с несколькими ответами: Weight за каждый правильный вариант минус Weight за каждый
+// неправильный, но не меньше нуля
+func (q QuizQuestion) PartialPoints(chosen []int) int {
+	hits := 0
+	for _, i := range chosen {
+		if q.IsCorrectOption(i) {
+			hits++
+		} else {
+			hits--
+		}
+	}
+	return q.Weight() * max(hits, 0)
+}
+
+// HasTag проверяет, отмечен ли вопрос тегом (без учета регистра)
+func (q QuizQuestion) HasTag(tag string) bool {
+	for _, t := range q.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnswerRecord - ответ пользователя на один вопрос викторины
+type AnswerRecord struct {
+	Question QuizQuestion
+	Selected int
+	Correct  bool
+
+	// Order - порядок нажатых вариантов в ответе на вопрос на порядок
+	Order []int
+
+	// Chosen - отмеченные варианты в ответе на вопрос с несколькими ответами
+	Chosen []int
+}
+
+// AnswerText возвращает ответ игрока для показа, пустой - ответа не было (время вышло)
+func (r AnswerRecord) AnswerText() string {
+	if r.Question.Ordered() {
+		return r.Question.orderText(r.Order)
+	}
+	if r.Question.Multi() {
+		return r.Question.listText(r.Chosen)
+	}
+	if r.Selected < 0 || r.Selected >= len(r.Question.Options) {
+		return ""
+	}
+	return r.Question.Options[r.Selected]
 }
 
 type QuizSession struct {
 	UserID          int64
 	CurrentQuestion int
-	Score           int
+	Score           int // очки за правильные ответы с учетом сложности вопросов
 	Questions       []QuizQuestion
+
+	// Practice - тренировка: вопросы идут по кругу, результат не попадает в лидерборд.
+	// Category - категория тренировки, пустая - все вопросы тренировки
+	Practice bool
+	Category string
+	Answered int
+
+	// StartedAt - время начала викторины, по нему считается время прохождения
+	StartedAt time.Time
+
+	// QuestionSentAt - время отправки текущего вопроса, используется для статистики времени ответа
+	QuestionSentAt time.Time
+
+	// Answers - ответы пользователя по порядку, нужны для разбора ошибок после викторины
+	Answers []AnswerRecord
+
+	// Mistakes - неправильные ответы для /mistakes, записываются в MistakeStats одной операцией
+	// при завершении викторины, а не на каждый ответ
+	Mistakes []PendingMistake
+
+	// BonusAsked - бонусный вопрос добавлен последним в Questions, BonusPoints - очки за него
+	BonusAsked  bool
+	BonusPoints int
+
+	// Streak - текущая серия правильных ответов подряд, MaxStreak - лучшая серия за викторину
+	Streak    int
+	MaxStreak int
+
+	// SkipsRemaining - сколько вопросов еще можно пропустить без ответа
+	SkipsRemaining int
+
+	// FiftyFiftyUsed - подсказка 50/50 уже использована (она доступна один раз за викторину),
+	// HiddenOptions - варианты текущего вопроса, убранные подсказкой
+	FiftyFiftyUsed bool
+	HiddenOptions  []int
+
+	// Sequence - варианты текущего вопроса на порядок в порядке нажатий
+	Sequence []int
+
+	// Selection - отмеченные варианты текущего вопроса с несколькими ответами по возрастанию
+	Selection []int
+
+	// PartialCredit - вопросы с несколькими ответами оцениваются частичным зачетом (см. QuizQuestion.PartialPoints)
+	PartialCredit bool
+
+	// CurrentAnswered - ответ на текущий вопрос уже принят (или истекло время), повторные нажатия игнорируются.
+	// Сбрасывается, когда показывается следующий вопрос
+	CurrentAnswered bool
+
+	// AwaitingContinue - ответ получен, ждем нажатия "Далее" перед следующим вопросом
+	AwaitingContinue bool
+
+	// Paused - викторина на паузе: ответы не принимаются, переход к следующему вопросу отложен
+	Paused bool
+
+	// Timer - таймер ограничения времени текущего вопроса, nil если ограничения нет.
+	// Не сохраняется: после восстановления таймер запускается заново
+	Timer *time.Timer `json:"-"`
+
+	// Recovered - викторина восстановлена после перезапуска бота, игрок еще не получил уведомление об этом
+	Recovered bool `json:"-"`
+
+	// Player - пользователь, отвечающий на вопросы; нужен, чтобы завершить викторину по таймауту
+	Player *Player
+
+	// ChallengerID - ID пользователя, чей вызов принят в этой викторине, 0 - обычная викторина
+	ChallengerID int64
+
+	// ThreadID - тема форума, в которой идет викторина, 0 - чат без тем
+	ThreadID int
+
+	// PhotoMessage - MessageID указывает на фото с подписью: его текст нельзя заменить
+	// через editMessageText, поэтому следующее сообщение викторины отправляется новым
+	PhotoMessage bool
+
+	// PollID - MessageID указывает на опрос-викторину PollID: его нельзя отредактировать, а ответ
+	// приходит обновлением poll_answer. Не сохраняется: после восстановления вопрос отправляется заново
+	PollID string `json:"-"`
+
+	// MessageID - сообщение с текущим вопросом, которое редактируется вместо отправки новых.
+	// 0 - сообщения еще нет, следующий вопрос будет отправлен новым сообщением
+	MessageID int
+}
+
+// Player - данные игрока для сохранения результата
+type Player struct {
+	ID        int64
+	Username  string
+	FirstName string
+}
+
+// StopTimer останавливает таймер текущего вопроса, если он запущен
+func (s *QuizSession) StopTimer() {
+	if s.Timer != nil {
+		s.Timer.Stop()
+		s.Timer = nil
+	}
+}
+
+// FiftyFiftyAvailable проверяет, можно ли использовать подсказку 50/50 на текущем вопросе:
+// она еще не использована, это не тренировка и неправильных вариантов хотя бы два
+func (s *QuizSession) FiftyFiftyAvailable() bool {
+	if s.FiftyFiftyUsed || s.Practice || s.CurrentQuestion >= len(s.Questions) {
+		return false
+	}
+	// В вопросе на порядок нужны все варианты
+	question := s.Questions[s.CurrentQuestion]
+	if question.Ordered() {
+		return false
+	}
+	// Подсказка убирает половину неправильных вариантов: при одном неправильном убрать нечего
+	wrong := 0
+	for i := range question.Options {
+		if !question.IsCorrectOption(i) {
+			wrong++
+		}
+	}
+	return wrong >= 2
+}
+
+// OptionTapped возвращает, каким по счету (с 1) игрок нажал вариант index текущего вопроса на порядок, 0 - не нажимал
+func (s *QuizSession) OptionTapped(index int) int {
+	for i, tapped := range s.Sequence {
+		if tapped == index {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// OptionSelected проверяет, отмечен ли вариант index текущего вопроса с несколькими ответами
+func (s *QuizSession) OptionSelected(index int) bool {
+	return slices.Contains(s.Selection, index)
+}
+
+// OptionHidden проверяет, убран ли вариант index текущего вопроса подсказкой 50/50
+func (s *QuizSession) OptionHidden(index int) bool {
+	for _, hidden := range s.HiddenOptions {
+		if hidden == index {
+			return true
+		}
+	}
+	return false
+}
+
+// StreakBonusEvery - за каждые StreakBonusEvery правильных ответов подряд начисляется бонусное очко
+const StreakBonusEvery = 3
+
+// RecordStreak обновляет серию правильных ответов: правильный ответ продлевает ее, неправильный - сбрасывает.
+// Возвращает бонусные очки, начисленные за серию (они уже добавлены в BonusPoints)
+func (s *QuizSession) RecordStreak(correct bool) int {
+	if !correct {
+		s.Streak = 0
+		return 0
+	}
+
+	s.Streak++
+	if s.Streak > s.MaxStreak {
+		s.MaxStreak = s.Streak
+	}
+	if s.Streak%StreakBonusEvery != 0 {
+		return 0
+	}
+
+	s.BonusPoints++
+	return 1
+}
+
+// Total возвращает количество основных вопросов викторины (без бонусного)
+func (s *QuizSession) Total() int {
+	if s.BonusAsked {
+		return len(s.Questions) - 1
+	}
+	return len(s.Questions)
+}
+
+// MaxScore возвращает максимально возможное количество очков за основные вопросы с учетом сложности
+// и частичного зачета
+func (s *QuizSession) MaxScore() int {
+	total := 0
+	for _, question := range s.Questions[:s.Total()] {
+		total += question.MaxPoints(s.PartialCredit)
+	}
+	return total
+}
+
+// IsBonus проверяет, является ли вопрос с индексом index бонусным
+func (s *QuizSession) IsBonus(index int) bool {
+	return s.BonusAsked && index == len(s.Questions)-1
+}
+
+// SplitBonusQuestions разделяет вопросы на основные и бонусные
+func SplitBonusQuestions(questions []QuizQuestion) (regular, bonus []QuizQuestion) {
+	for _, question := range questions {
+		if question.Bonus {
+			bonus = append(bonus, question)
+		} else {
+			regular = append(regular, question)
+		}
+	}
+	return regular, bonus
+}
+
+// PracticeQuestions возвращает вопросы, отмеченные для тренировки
+func PracticeQuestions(questions []QuizQuestion) []QuizQuestion {
+	var practice []QuizQuestion
+	for _, question := range questions {
+		if question.Practice {
+			practice = append(practice, question)
+		}
+	}
+	return practice
+}
+
+// QuestionsWithTag возвращает вопросы, отмеченные тегом tag
+func QuestionsWithTag(questions []QuizQuestion, tag string) []QuizQuestion {
+	var tagged []QuizQuestion
+	for _, question := range questions {
+		if question.HasTag(tag) {
+			tagged = append(tagged, question)
+		}
+	}
+	return tagged
+}
+
+// Categories возвращает отсортированный список непустых категорий вопросов
+func Categories(questions []QuizQuestion) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, question := range questions {
+		if question.Category != "" && !seen[question.Category] {
+			seen[question.Category] = true
+			categories = append(categories, question.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// CategoryCount - категория и количество вопросов в ней
+type CategoryCount struct {
+	Category string
+	Count    int
+}
+
+// CategoryCounts считает вопросы в каждой непустой категории. byCount - сначала самые большие
+// категории, иначе по алфавиту. uncategorized - число вопросов без категории
+func CategoryCounts(questions []QuizQuestion, byCount bool) (counts []CategoryCount, uncategorized int) {
+	index := make(map[string]int)
+	for _, question := range questions {
+		if question.Category == "" {
+			uncategorized++
+			continue
+		}
+		if i, exists := index[question.Category]; exists {
+			counts[i].Count++
+			continue
+		}
+		index[question.Category] = len(counts)
+		counts = append(counts, CategoryCount{Category: question.Category, Count: 1})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if byCount && counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Category < counts[j].Category
+	})
+	return counts, uncategorized
+}
+
+// QuestionsInCategory возвращает вопросы категории category
+func QuestionsInCategory(questions []QuizQuestion, category string) []QuizQuestion {
+	var result []QuizQuestion
+	for _, question := range questions {
+		if question.Category == category {
+			result = append(result, question)
+		}
+	}
+	return result
+}
+
+// ActiveQuestions возвращает вопросы без устаревших (Retired)
+func ActiveQuestions(questions []QuizQuestion) []QuizQuestion {
+	active := make([]QuizQuestion, 0, len(questions))
+	for _, question := range questions {
+		if !question.Retired {
+			active = append(active, question)
+		}
+	}
+	return active
 }
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"slices"
+	"time"
+)
+
+// QuizEngine - логика викторины без привязки к Telegram: создание сессии, подсчет очков,
+// переход между вопросами и итоги. Показ вопросов, таймеры и сохранение результата остаются
+// на стороне интерфейса (бота, веб-фронтенда)
+type QuizEngine struct {
+	// Bonus - источник бонусного вопроса, который задается после основных. nil - без бонусного вопроса
+	Bonus func() (QuizQuestion, bool)
+
+	// Refill - новый круг вопросов тренировки session (например, из ее Category), когда текущие закончились.
+	// nil или пустой срез - тренировка завершается
+	Refill func(session *QuizSession) []QuizQuestion
+}
+
+// AnswerResult - результат ответа на один вопрос
+type AnswerResult struct {
+	Question QuizQuestion
+	Correct  bool
+
+	// Bonus - ответ на бонусный вопрос: правильный ответ приносит бонусное очко вместо обычных
+	Bonus bool
+
+	// Points - очки за правильный ответ на основной вопрос, StreakBonus - бонусные очки за серию
+	Points      int
+	StreakBonus int
+}
+
+// QuizResult - итоги викторины
+type QuizResult struct {
+	Score int
+
+	// Total - максимально возможные очки за основные вопросы, в тренировке - число ответов
+	Total int
+
+	BonusPoints int
+	MaxStreak   int
+	Duration    time.Duration
+	Answers     []AnswerRecord
+}
+
+// Passed проверяет, набран ли проходной процент passPercentage
+func (r QuizResult) Passed(passPercentage int) bool {
+	return r.Score*100 >= passPercentage*r.Total
+}
+
+// StartSession создает сессию викторины пользователя userID с уже выбранными вопросами
+func (e *QuizEngine) StartSession(userID int64, questions []QuizQuestion) *QuizSession {
+	return &QuizSession{
+		UserID:    userID,
+		Questions: questions,
+		StartedAt: time.Now(),
+	}
+}
+
+// StartPractice создает сессию тренировки по категории category (пустая - все вопросы):
+// вопросы идут по кругу, результат не попадает в лидерборд
+func (e *QuizEngine) StartPractice(userID int64, questions []QuizQuestion, category string) *QuizSession {
+	session := e.StartSession(userID, questions)
+	session.Practice = true
+	session.Category = category
+	return session
+}
+
+// Answer засчитывает ответ optionIndex на текущий вопрос сессии (-1 - ответа нет, например, истекло время)
+// и переходит к следующему вопросу. done - вопросов больше нет, викторину пора завершать через Finish.
+// Вопросы на порядок и с несколькими ответами так засчитываются только как неправильные:
+// их варианты нажимаются через Tap и Toggle. Индекс текущего вопроса должен быть проверен вызывающим
+func (e *QuizEngine) Answer(session *QuizSession, optionIndex int) (result AnswerResult, done bool) {
+	question := session.Questions[session.CurrentQuestion]
+	return e.grade(session, AnswerRecord{
+		Question: question,
+		Selected: optionIndex,
+		Correct:  !question.Ordered() && !question.Multi() && optionIndex == question.Correct,
+		Order:    session.Sequence,
+	})
+}
+
+// Tap отмечает нажатие варианта optionIndex в вопросе на порядок. Повторное нажатие того же
+// варианта игнорируется. Когда нажаты все варианты, последовательность сравнивается с OrderedAnswer
+// и ответ засчитывается как в Answer: answered == true, done - вопросов больше нет
+func (e *QuizEngine) Tap(session *QuizSession, optionIndex int) (result AnswerResult, answered, done bool) {
+	question := session.Questions[session.CurrentQuestion]
+	if session.OptionTapped(optionIndex) > 0 {
+		return result, false, false
+	}
+
+	session.Sequence = append(session.Sequence, optionIndex)
+	if len(session.Sequence) < len(question.OrderedAnswer) {
+		return result, false, false
+	}
+
+	result, done = e.grade(session, AnswerRecord{
+		Question: question,
+		Selected: -1,
+		Correct:  slices.Equal(session.Sequence, question.OrderedAnswer),
+		Order:    session.Sequence,
+	})
+	return result, true, done
+}
+
+// Toggle отмечает вариант optionIndex текущего вопроса с несколькими ответами или снимает отметку
+func (e *QuizEngine) Toggle(session *QuizSession, optionIndex int) {
+	if i := slices.Index(session.Selection, optionIndex); i >= 0 {
+		session.Selection = slices.Delete(session.Selection, i, i+1)
+		return
+	}
+	session.Selection = append(session.Selection, optionIndex)
+	slices.Sort(session.Selection)
+}
+
+// Submit засчитывает отмеченные варианты текущего вопроса с несколькими ответами: ответ верен,
+// если отмечены ровно все правильные. При частичном зачете очки начисляются и за неполный ответ.
+// submitted == false - ничего не отмечено, сессия не изменена
+func (e *QuizEngine) Submit(session *QuizSession) (result AnswerResult, submitted, done bool) {
+	if len(session.Selection) == 0 {
+		return result, false, false
+	}
+
+	question := session.Questions[session.CurrentQuestion]
+	result, done = e.grade(session, AnswerRecord{
+		Question: question,
+		Selected: -1,
+		Correct:  slices.Equal(session.Selection, question.CorrectSet),
+		Chosen:   session.Selection,
+	})
+	return result, true, done
+}
+
+// ResetOrder сбрасывает нажатые варианты текущего вопроса на порядок, чтобы начать заново
+func (e *QuizEngine) ResetOrder(session *QuizSession) {
+	session.Sequence = nil
+}
+
+// grade записывает ответ на текущий вопрос, начисляет очки и переходит к следующему вопросу
+func (e *QuizEngine) grade(session *QuizSession, record AnswerRecord) (result AnswerResult, done bool) {
+	question := record.Question
+
+	result.Question = question
+	result.Correct = record.Correct
+	result.Bonus = session.IsBonus(session.CurrentQuestion)
+
+	session.CurrentAnswered = true
+	session.Answers = append(session.Answers, record)
+
+	switch {
+	case result.Correct && result.Bonus:
+		session.BonusPoints++
+	case result.Correct && session.Practice:
+		// В тренировке процент считается от числа ответов, поэтому сложность не учитывается
+		result.Points = 1
+	case session.PartialCredit && !session.Practice && !result.Bonus && question.Multi():
+		result.Points = question.PartialPoints(record.Chosen)
+	case result.Correct:
+		result.Points = question.Weight()
+	}
+	session.Score += result.Points
+	result.StreakBonus = session.RecordStreak(result.Correct)
+
+	return result, !e.advance(session)
+}
+
+// Skip пропускает текущий вопрос без ответа и очков, расходуя один пропуск. Серия правильных
+// ответов не прерывается. skipped == false - пропусков не осталось, сессия не изменена
+func (e *QuizEngine) Skip(session *QuizSession) (skipped, done bool) {
+	if session.SkipsRemaining <= 0 {
+		return false, false
+	}
+
+	session.SkipsRemaining--
+	session.CurrentAnswered = true
+	return true, !e.advance(session)
+}
+
+// UseFiftyFifty применяет подсказку 50/50 к текущему вопросу: убирает половину неправильных
+// вариантов (см. HiddenOptions). Возвращает false, если подсказка недоступна
+func (e *QuizEngine) UseFiftyFifty(session *QuizSession) bool {
+	if !session.FiftyFiftyAvailable() {
+		return false
+	}
+
+	session.FiftyFiftyUsed = true
+	session.HiddenOptions = FiftyFiftyOptions(session.Questions[session.CurrentQuestion])
+	return true
+}
+
+// advance переходит к следующему вопросу: в тренировке начинает новый круг, после основных
+// вопросов добавляет бонусный. Возвращает false, если вопросов больше нет
+func (e *QuizEngine) advance(session *QuizSession) bool {
+	session.Answered++
+	session.CurrentQuestion++
+	session.HiddenOptions = nil
+	session.Sequence = nil
+	session.Selection = nil
+
+	if session.Practice && session.CurrentQuestion >= len(session.Questions) {
+		// В тренировке вопросы закончились - идем на новый круг
+		session.Questions = nil
+		if e.Refill != nil {
+			session.Questions = e.Refill(session)
+		}
+		session.CurrentQuestion = 0
+	}
+	if !session.Practice && !session.BonusAsked && session.CurrentQuestion >= len(session.Questions) && e.Bonus != nil {
+		// Основные вопросы закончились - задаем бонусный, если он есть
+		if bonus, ok := e.Bonus(); ok {
+			session.Questions = append(session.Questions, bonus)
+			session.BonusAsked = true
+		}
+	}
+
+	return session.CurrentQuestion < len(session.Questions)
+}
+
+// Finish останавливает таймер вопроса и подводит итоги сессии
+func (e *QuizEngine) Finish(session *QuizSession) QuizResult {
+	session.StopTimer()
+
+	result := QuizResult{
+		Score:       session.Score,
+		BonusPoints: session.BonusPoints,
+		MaxStreak:   session.MaxStreak,
+		Duration:    time.Since(session.StartedAt),
+		Answers:     session.Answers,
+	}
+	if session.Practice {
+		result.Total = session.Answered
+	} else {
+		result.Total = session.MaxScore()
+	}
+	return result
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func orderedQuestion() QuizQuestion {
+	return QuizQuestion{ID: 1, Question: "Порядок", Options: []string{"a", "b", "c", "d"}, Correct: 2, OrderedAnswer: []int{2, 0, 3, 1}}
+}
+
+func TestEngineFullQuiz(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Question: "2 + 2?", Options: []string{"3", "4", "5"}, Correct: 1},
+		{ID: 2, Question: "Столица Франции?", Options: []string{"Париж", "Рим"}, Correct: 0, Difficulty: 2},
+		{ID: 3, Question: "По алфавиту", Options: []string{"в", "а", "б"}, Correct: 1, OrderedAnswer: []int{1, 2, 0}},
+		{ID: 4, Question: "Четные", Options: []string{"1", "2", "3", "4"}, Correct: 1, CorrectSet: []int{1, 3}},
+		{ID: 5, Question: "3 * 3?", Options: []string{"6", "9"}, Correct: 1},
+	}
+	bonus := QuizQuestion{ID: 99, Question: "Бонус", Options: []string{"да", "нет"}, Correct: 0}
+	engine := &QuizEngine{Bonus: func() (QuizQuestion, bool) { return bonus, true }}
+	session := engine.StartSession(42, questions)
+	if session.UserID != 42 || session.CurrentQuestion != 0 || session.Total() != len(questions) {
+		t.Fatalf("new session: user %d, question %d, total %d", session.UserID, session.CurrentQuestion, session.Total())
+	}
+
+	// 1: правильно
+	if result, done := engine.Answer(session, 1); !result.Correct || result.Points != 1 || done {
+		t.Fatalf("question 1: %+v, done %v", result, done)
+	}
+	// 2: неправильно
+	if result, done := engine.Answer(session, 1); result.Correct || result.Points != 0 || done {
+		t.Fatalf("question 2: %+v, done %v", result, done)
+	}
+	// 3: вопрос на порядок засчитывается после нажатия всех вариантов
+	for _, option := range []int{1, 2} {
+		if _, answered, _ := engine.Tap(session, option); answered {
+			t.Fatalf("question 3 answered after tap %d", option)
+		}
+	}
+	if result, answered, done := engine.Tap(session, 0); !answered || !result.Correct || done {
+		t.Fatalf("question 3: %+v, answered %v, done %v", result, answered, done)
+	}
+	// 4: несколько ответов отмечаются и отправляются
+	engine.Toggle(session, 1)
+	engine.Toggle(session, 3)
+	if result, submitted, done := engine.Submit(session); !submitted || !result.Correct || done {
+		t.Fatalf("question 4: %+v, submitted %v, done %v", result, submitted, done)
+	}
+	// 5: время вышло - ответа нет. После основных вопросов задается бонусный
+	if result, done := engine.Answer(session, -1); result.Correct || done {
+		t.Fatalf("question 5: %+v, done %v", result, done)
+	}
+	if !session.IsBonus(session.CurrentQuestion) || session.Questions[session.CurrentQuestion].ID != bonus.ID {
+		t.Fatalf("current question %d is not the bonus one", session.CurrentQuestion)
+	}
+	if result, done := engine.Answer(session, 0); !result.Correct || !result.Bonus || result.Points != 0 || !done {
+		t.Fatalf("bonus question: %+v, done %v", result, done)
+	}
+
+	result := engine.Finish(session)
+	// Очки: 1 + 0 + 1 + 1 + 0 из 1 + 2 + 1 + 1 + 1, бонусный вопрос - отдельное бонусное очко
+	if result.Score != 3 || result.Total != 6 || result.BonusPoints != 1 {
+		t.Errorf("result %d/%d, bonus %d, want 3/6, bonus 1", result.Score, result.Total, result.BonusPoints)
+	}
+	if result.MaxStreak != 2 {
+		t.Errorf("MaxStreak = %d, want 2", result.MaxStreak)
+	}
+	if !result.Passed(50) || result.Passed(51) {
+		t.Errorf("3/6 must pass 50%% and fail 51%%")
+	}
+	var answered []int
+	var correct []bool
+	for _, answer := range result.Answers {
+		answered = append(answered, answer.Question.ID)
+		correct = append(correct, answer.Correct)
+	}
+	if want := []int{1, 2, 3, 4, 5, 99}; !slices.Equal(answered, want) {
+		t.Errorf("answered questions %v, want %v", answered, want)
+	}
+	if want := []bool{true, false, true, true, false, true}; !slices.Equal(correct, want) {
+		t.Errorf("correct answers %v, want %v", correct, want)
+	}
+	if got := result.Answers[2].Order; !slices.Equal(got, []int{1, 2, 0}) {
+		t.Errorf("recorded order %v, want [1 2 0]", got)
+	}
+	if got := result.Answers[3].Chosen; !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("recorded choice %v, want [1 3]", got)
+	}
+	if result.Answers[4].Selected != -1 {
+		t.Errorf("timed out answer recorded as option %d", result.Answers[4].Selected)
+	}
+}
+
+func TestEnginePractice(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Question: "Да?", Options: []string{"да", "нет"}, Correct: 0, Difficulty: 3},
+		{ID: 2, Question: "Нет?", Options: []string{"да", "нет"}, Correct: 1},
+	}
+	rounds := 0
+	engine := &QuizEngine{Refill: func(*QuizSession) []QuizQuestion {
+		rounds++
+		if rounds > 1 {
+			return nil // второй круг - последний
+		}
+		return questions
+	}}
+	session := engine.StartPractice(7, questions, "")
+
+	var done bool
+	answers := 0
+	for !done {
+		_, done = engine.Answer(session, 0)
+		answers++
+		if answers > 10 {
+			t.Fatal("practice never ended")
+		}
+	}
+
+	// В тренировке сложность не учитывается, а процент считается от числа ответов
+	result := engine.Finish(session)
+	if answers != 4 || result.Score != 2 || result.Total != 4 {
+		t.Errorf("%d answers, result %d/%d, want 4 answers and 2/4", answers, result.Score, result.Total)
+	}
+}
+
+func TestShuffleOptionsRemapsOrder(t *testing.T) {
+	question := orderedQuestion()
+	for seed := range int64(20) {
+		shuffled := shuffleOptionsWithRand(question, rand.New(rand.NewSource(seed)))
+		if got, want := shuffled.CorrectText(), question.CorrectText(); got != want {
+			t.Fatalf("seed %d: CorrectText = %q, want %q", seed, got, want)
+		}
+		if shuffled.Correct != shuffled.OrderedAnswer[0] {
+			t.Fatalf("seed %d: Correct = %d, OrderedAnswer = %v", seed, shuffled.Correct, shuffled.OrderedAnswer)
+		}
+	}
+}
+
+func TestTapOrderedQuestion(t *testing.T) {
+	tests := []struct {
+		name        string
+		taps        []int
+		wantCorrect bool
+	}{
+		{"right order", []int{2, 0, 3, 1}, true},
+		{"wrong order", []int{0, 2, 3, 1}, false},
+		{"repeated taps ignored", []int{2, 2, 0, 0, 3, 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &QuizEngine{}
+			session := engine.StartSession(1, []QuizQuestion{orderedQuestion()})
+
+			var result AnswerResult
+			var answered, done bool
+			for i, option := range tt.taps {
+				result, answered, done = engine.Tap(session, option)
+				if answered != (i == len(tt.taps)-1) {
+					t.Fatalf("tap %d: answered = %v", i, answered)
+				}
+			}
+			if !done {
+				t.Error("quiz is not done after the only question")
+			}
+			if result.Correct != tt.wantCorrect {
+				t.Errorf("Correct = %v, want %v", result.Correct, tt.wantCorrect)
+			}
+			if got := session.Answers[0].Order; !slices.Equal(got, slices.Compact(slices.Clone(tt.taps))) {
+				t.Errorf("recorded order = %v", got)
+			}
+		})
+	}
+}
+
+func TestResetOrder(t *testing.T) {
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, []QuizQuestion{orderedQuestion()})
+
+	engine.Tap(session, 0)
+	engine.Tap(session, 1)
+	if session.OptionTapped(1) != 2 {
+		t.Fatalf("OptionTapped(1) = %d, want 2", session.OptionTapped(1))
+	}
+	engine.ResetOrder(session)
+	if session.OptionTapped(0) != 0 {
+		t.Error("taps survived ResetOrder")
+	}
+
+	for _, option := range []int{2, 0, 3} {
+		if _, answered, _ := engine.Tap(session, option); answered {
+			t.Fatal("answered before every option was tapped")
+		}
+	}
+	if result, answered, _ := engine.Tap(session, 1); !answered || !result.Correct {
+		t.Errorf("answered = %v, Correct = %v after tapping in order", answered, result.Correct)
+	}
+}
+
+func TestAnswerOrderedQuestionIsWrong(t *testing.T) {
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, []QuizQuestion{orderedQuestion()})
+
+	// Истекшее время засчитывает вопрос на порядок как неправильный, даже если индекс совпал с Correct
+	if result, _ := engine.Answer(session, 2); result.Correct {
+		t.Error("ordered question answered through Answer")
+	}
+}
+
+func TestRefillKeepsPracticeCategory(t *testing.T) {
+	var refilled []string
+	engine := &QuizEngine{Refill: func(session *QuizSession) []QuizQuestion {
+		refilled = append(refilled, session.Category)
+		return []QuizQuestion{{ID: 2, Options: []string{"a", "b"}, Category: session.Category}}
+	}}
+	session := engine.StartPractice(1, []QuizQuestion{{ID: 1, Options: []string{"a", "b"}, Category: "История"}}, "История")
+
+	for range 3 {
+		if _, done := engine.Answer(session, 0); done {
+			t.Fatal("practice finished while Refill returned questions")
+		}
+	}
+	if want := []string{"История", "История", "История"}; !slices.Equal(refilled, want) {
+		t.Errorf("Refill categories = %v, want %v", refilled, want)
+	}
+}
+
+func TestQuizResultPassed(t *testing.T) {
+	tests := []struct {
+		score, total, passPercentage int
+		want                         bool
+	}{
+		{1, 4, 50, false},
+		{2, 4, 50, true},
+		{3, 4, 50, true},
+		{2, 3, 67, false},
+		{0, 4, 0, true},
+	}
+	for _, tt := range tests {
+		result := QuizResult{Score: tt.score, Total: tt.total}
+		if got := result.Passed(tt.passPercentage); got != tt.want {
+			t.Errorf("%d/%d at %d%%: Passed = %t, want %t", tt.score, tt.total, tt.passPercentage, got, tt.want)
+		}
+	}
+}
+
+func TestAnswerStreak(t *testing.T) {
+	// true - правильный ответ, false - неправильный
+	answers := []bool{true, true, true, false, true, true, true, true, true, true, false}
+	wantStreak := []int{1, 2, 3, 0, 1, 2, 3, 4, 5, 6, 0}
+	wantBonus := []int{0, 0, 1, 0, 0, 0, 1, 0, 0, 1, 0}
+
+	var questions []QuizQuestion
+	for i := range answers {
+		questions = append(questions, QuizQuestion{ID: i + 1, Question: "Вопрос", Options: []string{"да", "нет"}})
+	}
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, questions)
+
+	bonus := 0
+	for i, correct := range answers {
+		option := 1
+		if correct {
+			option = 0
+		}
+		result, _ := engine.Answer(session, option)
+		if session.Streak != wantStreak[i] {
+			t.Errorf("answer %d: streak = %d, want %d", i+1, session.Streak, wantStreak[i])
+		}
+		if result.StreakBonus != wantBonus[i] {
+			t.Errorf("answer %d: streak bonus = %d, want %d", i+1, result.StreakBonus, wantBonus[i])
+		}
+		bonus += wantBonus[i]
+	}
+
+	quizResult := engine.Finish(session)
+	if quizResult.MaxStreak != 6 {
+		t.Errorf("MaxStreak = %d, want 6", quizResult.MaxStreak)
+	}
+	if quizResult.BonusPoints != bonus {
+		t.Errorf("BonusPoints = %d, want %d", quizResult.BonusPoints, bonus)
+	}
+	if quizResult.Score != 9 {
+		t.Errorf("Score = %d, want 9: the streak bonus is counted apart from the score", quizResult.Score)
+	}
+}
+
+func TestWeightedScore(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Question: "Легкий", Options: []string{"да", "нет"}, Difficulty: 1},
+		{ID: 2, Question: "Средний", Options: []string{"да", "нет"}, Difficulty: 2},
+		{ID: 3, Question: "Трудный", Options: []string{"да", "нет"}, Difficulty: 3},
+		{ID: 4, Question: "Без сложности", Options: []string{"да", "нет"}},
+	}
+
+	tests := []struct {
+		name      string
+		answers   []int // 0 - правильный ответ, 1 - неправильный
+		wantScore int
+	}{
+		{"all correct", []int{0, 0, 0, 0}, 7},
+		{"only the hard one", []int{1, 1, 0, 1}, 3},
+		{"all but the hard one", []int{0, 0, 1, 0}, 4},
+		{"all wrong", []int{1, 1, 1, 1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &QuizEngine{}
+			session := engine.StartSession(1, questions)
+			for i, option := range tt.answers {
+				result, _ := engine.Answer(session, option)
+				want := 0
+				if option == 0 {
+					want = questions[i].Weight()
+				}
+				if result.Points != want {
+					t.Errorf("question %d: %d points, want %d", i+1, result.Points, want)
+				}
+			}
+
+			// Процент считается от максимума взвешенных очков, а не от числа вопросов
+			result := engine.Finish(session)
+			if result.Score != tt.wantScore || result.Total != 7 {
+				t.Errorf("result %d/%d, want %d/7", result.Score, result.Total, tt.wantScore)
+			}
+		})
+	}
+
+	// Трудный вопрос приносит больше легкого
+	if easy, hard := questions[0].Weight(), questions[2].Weight(); hard <= easy {
+		t.Errorf("hard question weight %d is not above easy %d", hard, easy)
+	}
+}
+
+func TestSkipKeepsStreak(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Question: "Вопрос", Options: []string{"да", "нет"}},
+		{ID: 2, Question: "Вопрос", Options: []string{"да", "нет"}},
+		{ID: 3, Question: "Вопрос", Options: []string{"да", "нет"}},
+		{ID: 4, Question: "Вопрос", Options: []string{"да", "нет"}},
+	}
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, questions)
+	session.SkipsRemaining = 1
+
+	engine.Answer(session, 0)
+	engine.Answer(session, 0)
+	if skipped, _ := engine.Skip(session); !skipped {
+		t.Fatal("skip was not used")
+	}
+	if result, _ := engine.Answer(session, 0); result.StreakBonus != 1 || session.Streak != 3 {
+		t.Errorf("after a skip: streak = %d, bonus = %d, want 3 and 1", session.Streak, result.StreakBonus)
+	}
+}
+
+func multiQuestion() QuizQuestion {
+	return QuizQuestion{ID: 1, Question: "Четные", Options: []string{"1", "2", "3", "4"}, Correct: 1, CorrectSet: []int{1, 3}, Difficulty: 2}
+}
+
+func TestSubmitMultiAnswer(t *testing.T) {
+	tests := []struct {
+		name        string
+		partial     bool
+		chosen      []int
+		wantCorrect bool
+		wantPoints  int
+		wantTotal   int
+	}{
+		{"exact full", false, []int{3, 1}, true, 2, 2},
+		{"exact partial", false, []int{1}, false, 0, 2},
+		{"exact over-selection", false, []int{0, 1, 3}, false, 0, 2},
+		{"partial full", true, []int{1, 3}, true, 4, 4},
+		{"partial one of two", true, []int{1}, false, 2, 4},
+		{"partial over-selection", true, []int{0, 1, 3}, false, 2, 4},
+		{"partial clamped at zero", true, []int{0, 1, 2}, false, 0, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &QuizEngine{}
+			session := engine.StartSession(1, []QuizQuestion{multiQuestion()})
+			session.PartialCredit = tt.partial
+
+			for _, option := range tt.chosen {
+				engine.Toggle(session, option)
+			}
+			result, submitted, done := engine.Submit(session)
+			if !submitted || !done {
+				t.Fatalf("submitted = %v, done = %v", submitted, done)
+			}
+			if result.Correct != tt.wantCorrect || result.Points != tt.wantPoints {
+				t.Errorf("Correct = %v, Points = %d, want %v, %d", result.Correct, result.Points, tt.wantCorrect, tt.wantPoints)
+			}
+
+			final := engine.Finish(session)
+			if final.Score != tt.wantPoints || final.Total != tt.wantTotal {
+				t.Errorf("result %d/%d, want %d/%d", final.Score, final.Total, tt.wantPoints, tt.wantTotal)
+			}
+			if got := session.Answers[0].Chosen; !slices.Equal(got, slices.Sorted(slices.Values(tt.chosen))) {
+				t.Errorf("recorded choice = %v", got)
+			}
+		})
+	}
+}
+
+func TestToggleMultiAnswer(t *testing.T) {
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, []QuizQuestion{multiQuestion(), multiQuestion()})
+
+	if _, submitted, _ := engine.Submit(session); submitted {
+		t.Fatal("empty choice was submitted")
+	}
+	engine.Toggle(session, 3)
+	engine.Toggle(session, 0)
+	engine.Toggle(session, 0)
+	if !slices.Equal(session.Selection, []int{3}) || !session.OptionSelected(3) || session.OptionSelected(0) {
+		t.Fatalf("Selection = %v after toggling 0 twice", session.Selection)
+	}
+
+	// Истекшее время засчитывает вопрос как неправильный, отметки переходят к следующему вопросу сброшенными
+	if result, _ := engine.Answer(session, 1); result.Correct {
+		t.Error("multi-answer question answered through Answer")
+	}
+	if len(session.Selection) != 0 {
+		t.Errorf("Selection = %v on the next question", session.Selection)
+	}
+}
+
+func TestShuffleOptionsRemapsCorrectSet(t *testing.T) {
+	question := multiQuestion()
+	for seed := range int64(20) {
+		shuffled := ShuffleOptionsSeeded(question, seed)
+		// Правильные варианты перечисляются в порядке их новых мест
+		got := strings.Split(shuffled.CorrectText(), ", ")
+		if slices.Sort(got); !slices.Equal(got, []string{"2", "4"}) {
+			t.Fatalf("seed %d: CorrectText = %q", seed, shuffled.CorrectText())
+		}
+		if !slices.IsSorted(shuffled.CorrectSet) || shuffled.Correct != shuffled.CorrectSet[0] {
+			t.Fatalf("seed %d: CorrectSet = %v, Correct = %d", seed, shuffled.CorrectSet, shuffled.Correct)
+		}
+	}
+}
+
+func TestFiftyFiftyKeepsEveryCorrectOption(t *testing.T) {
+	question := QuizQuestion{Options: []string{"a", "b", "c", "d", "e", "f"}, Correct: 0, CorrectSet: []int{0, 4}}
+	for seed := range int64(20) {
+		hidden := fiftyFiftyWithRand(question, rand.New(rand.NewSource(seed)))
+		if len(hidden) != 2 || slices.Contains(hidden, 0) || slices.Contains(hidden, 4) {
+			t.Fatalf("seed %d: hidden = %v", seed, hidden)
+		}
+	}
+}
+
+func TestSkipUsesAllowance(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Question: "Вопрос", Options: []string{"да", "нет"}},
+		{ID: 2, Question: "Вопрос", Options: []string{"да", "нет"}},
+		{ID: 3, Question: "Вопрос", Options: []string{"да", "нет"}},
+	}
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, questions)
+	session.SkipsRemaining = 1
+
+	if skipped, done := engine.Skip(session); !skipped || done {
+		t.Fatalf("Skip = %v, %v, want true, false", skipped, done)
+	}
+	if session.SkipsRemaining != 0 || session.CurrentQuestion != 1 || session.Score != 0 {
+		t.Fatalf("after a skip: skips %d, question %d, score %d", session.SkipsRemaining, session.CurrentQuestion, session.Score)
+	}
+	if skipped, _ := engine.Skip(session); skipped || session.CurrentQuestion != 1 {
+		t.Errorf("skipped with no skips left, question %d", session.CurrentQuestion)
+	}
+}
+
+func TestFiftyFiftySingleUse(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Question: "Вопрос", Options: []string{"a", "b", "c", "d", "e"}, Correct: 2},
+		{ID: 2, Question: "Вопрос", Options: []string{"a", "b", "c", "d"}, Correct: 0},
+	}
+	engine := &QuizEngine{}
+	session := engine.StartSession(1, questions)
+
+	if !engine.UseFiftyFifty(session) {
+		t.Fatal("lifeline not available on the first question")
+	}
+	if len(session.HiddenOptions) != 2 || session.OptionHidden(2) {
+		t.Fatalf("hidden = %v, want two wrong options", session.HiddenOptions)
+	}
+	if engine.UseFiftyFifty(session) {
+		t.Error("lifeline used twice on one question")
+	}
+
+	engine.Answer(session, 2)
+	if len(session.HiddenOptions) != 0 {
+		t.Errorf("hidden options carried over to the next question: %v", session.HiddenOptions)
+	}
+	if engine.UseFiftyFifty(session) {
+		t.Error("lifeline used again in the same quiz")
+	}
+}
+
+func TestFiftyFiftyNeedsTwoWrongOptions(t *testing.T) {
+	engine := &QuizEngine{}
+	questions := []QuizQuestion{
+		{ID: 1, Question: "Четные", Options: []string{"1", "2", "4"}, Correct: 1, CorrectSet: []int{1, 2}},
+	}
+	session := engine.StartSession(1, questions)
+
+	if session.FiftyFiftyAvailable() {
+		t.Error("lifeline offered with one wrong option")
+	}
+	if engine.UseFiftyFifty(session) || session.FiftyFiftyUsed {
+		t.Error("lifeline used up on a question where nothing can be hidden")
+	}
+
+	// С двумя неправильными вариантами подсказка доступна
+	session = engine.StartSession(1, []QuizQuestion{
+		{ID: 2, Question: "Четные", Options: []string{"1", "2", "3", "4"}, Correct: 1, CorrectSet: []int{1, 3}},
+	})
+	if !session.FiftyFiftyAvailable() {
+		t.Error("lifeline not offered with two wrong options")
+	}
+}
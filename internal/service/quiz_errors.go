@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoQuestions - в файле нет ни одного вопроса
+	ErrNoQuestions = errors.New("no valid questions found in file")
+
+	// ErrBadFormat - строка файла не соответствует формату вопросов
+	ErrBadFormat = errors.New("bad question format")
+)
+
+// ParseError - ошибка разбора конкретной строки файла с вопросами.
+// errors.Is(err, ErrBadFormat) истинно для любой ParseError
+type ParseError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("error parsing line %d '%s': %v", e.Line, e.Text, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ParseError) Is(target error) bool {
+	return target == ErrBadFormat
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jsonQuestion - вопрос в JSON файле. Options можно не указывать, тогда используются DefaultOptions
+type jsonQuestion struct {
+	ID        int      `json:"id"`
+	Question  string   `json:"question"`
+	Options   []string `json:"options"`
+	Correct   int      `json:"correct"`
+	Category  string   `json:"category"`
+	Tags      []string `json:"tags"`
+	Bonus     bool     `json:"bonus"`
+	Important bool     `json:"important"`
+	Practice  bool     `json:"practice"`
+	Retired   bool     `json:"retired"`
+
+	// Explanation - необязательное пояснение к правильному ответу
+	Explanation string `json:"explanation"`
+
+	// Difficulty - сложность от 1 до MaxDifficulty, 0 - по умолчанию
+	Difficulty int `json:"difficulty"`
+
+	// Image - картинка к вопросу: ссылка http(s) или file_id Telegram
+	Image string `json:"image"`
+
+	// TimeLimit - время на ответ в секундах, 0 - без ограничения
+	TimeLimit int `json:"time_limit"`
+
+	// OrderedAnswer - правильный порядок вариантов для вопроса "расставьте по порядку", Correct тогда не нужен
+	OrderedAnswer []int `json:"ordered_answer"`
+
+	// CorrectOptions - все правильные варианты вопроса с несколькими ответами, Correct тогда не нужен
+	CorrectOptions []int `json:"correct_options"`
+
+	// AcceptedAnswers - другие формулировки правильного ответа, после ответа показывается случайная
+	AcceptedAnswers []string `json:"accepted_answers"`
+}
+
+// ParseQuizQuestionsJSON парсит вопросы из JSON файла с массивом вопросов.
+// Вопросы без ID получают порядковый номер в массиве, как в TXT формате
+func ParseQuizQuestionsJSON(filename string) ([]QuizQuestion, error) {
+	questions, err := readQuestionsJSON(filename)
+	if err != nil {
+		return nil, err
+	}
+	return numberQuestions(questions), nil
+}
+
+// readQuestionsJSON парсит вопросы из JSON файла, оставляя ID 0 вопросам без "id"
+func readQuestionsJSON(filename string) ([]QuizQuestion, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	var raw []jsonQuestion
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadFormat, err)
+	}
+
+	questions := make([]QuizQuestion, 0, len(raw))
+	for i, q := range raw {
+		question, err := q.toQuizQuestion()
+		if err != nil {
+			return nil, fmt.Errorf("%w: question %d: %v", ErrBadFormat, i+1, err)
+		}
+		questions = append(questions, question)
+	}
+
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	return questions, nil
+}
+
+// toQuizQuestion проверяет вопрос из JSON по тем же правилам, что и строку TXT файла
+func (q jsonQuestion) toQuizQuestion() (QuizQuestion, error) {
+	if strings.TrimSpace(q.Question) == "" {
+		return QuizQuestion{}, fmt.Errorf("question cannot be empty")
+	}
+
+	options := q.Options
+	if options == nil {
+		options = DefaultOptions
+	}
+	if len(options) < 2 {
+		return QuizQuestion{}, fmt.Errorf("question needs at least 2 options, got %d", len(options))
+	}
+	for _, option := range options {
+		if strings.TrimSpace(option) == "" {
+			return QuizQuestion{}, fmt.Errorf("answer option cannot be empty")
+		}
+	}
+
+	correct := q.Correct
+	if len(q.OrderedAnswer) > 0 {
+		if q.Options == nil {
+			return QuizQuestion{}, fmt.Errorf("ordered answer needs custom options")
+		}
+		if err := validateOrder(q.OrderedAnswer, len(options)); err != nil {
+			return QuizQuestion{}, err
+		}
+		correct = q.OrderedAnswer[0]
+	}
+	var set []int
+	if len(q.CorrectOptions) > 0 {
+		if q.Options == nil {
+			return QuizQuestion{}, fmt.Errorf("multiple answers need custom options")
+		}
+		set = slices.Sorted(slices.Values(q.CorrectOptions))
+		if err := validateCorrectSet(set, len(options)); err != nil {
+			return QuizQuestion{}, err
+		}
+		correct = set[0]
+	}
+	if correct < 0 || correct >= len(options) {
+		return QuizQuestion{}, fmt.Errorf("correctness must be between 0 and %d, got %d", len(options)-1, q.Correct)
+	}
+	if q.TimeLimit < 0 {
+		return QuizQuestion{}, fmt.Errorf("invalid time limit %d", q.TimeLimit)
+	}
+	if q.Difficulty < 0 || q.Difficulty > MaxDifficulty {
+		return QuizQuestion{}, fmt.Errorf("difficulty must be between 1 and %d, got %d", MaxDifficulty, q.Difficulty)
+	}
+
+	if q.ID < 0 {
+		return QuizQuestion{}, fmt.Errorf("invalid id %d", q.ID)
+	}
+
+	var tags []string
+	for _, tag := range q.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	var accepted []string
+	for _, answer := range q.AcceptedAnswers {
+		if answer = strings.TrimSpace(answer); answer != "" {
+			accepted = append(accepted, answer)
+		}
+	}
+
+	return QuizQuestion{
+		ID:          q.ID,
+		Question:    q.Question,
+		Options:     append([]string(nil), options...),
+		Correct:     correct,
+		Bonus:       q.Bonus,
+		Important:   q.Important,
+		Practice:    q.Practice,
+		Retired:     q.Retired,
+		TimeLimit:   time.Duration(q.TimeLimit) * time.Second,
+		Category:    strings.TrimSpace(q.Category),
+		Tags:        tags,
+		Explanation: strings.TrimSpace(q.Explanation),
+		Difficulty:  q.Difficulty,
+		Image:       strings.TrimSpace(q.Image),
+
+		OrderedAnswer: append([]int(nil), q.OrderedAnswer...),
+		CorrectSet:    set,
+
+		AcceptedAnswers: accepted,
+	}, nil
+}
+
+// readQuestionsFileAny выбирает парсер по расширению файла: .json - JSON, остальные - TXT формат.
+// Вопросы без явного ID остаются с ID 0, их нумерует MergeQuestions
+func readQuestionsFileAny(filename string) ([]QuizQuestion, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return readQuestionsJSON(filename)
+	}
+	return readQuestionsFile(filename)
+}
@@ -2,63 +2,294 @@ package service
 
 import (
 	"bufio"
+	"embed"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
-// ParseQuizQuestions парсит вопросы из TXT файла
+// embeddedQuestions - базовый набор вопросов, вшитый в бинарник
+//
+//go:embed questions.txt
+var embeddedQuestions embed.FS
+
+// DefaultOptions - варианты ответа для вопросов в TXT формате
+var DefaultOptions = []string{"👍Халяль", "🐖Харам"}
+
+// AnswerLabels сопоставляет текстовые метки правильного ответа индексам в DefaultOptions.
+// Метки можно использовать в файле вместо 0/1, например "Свинина" haram
+var AnswerLabels = map[string]int{
+	"halal":  0,
+	"haram":  1,
+	"халяль": 0,
+	"харам":  1,
+}
+
+// ParseQuizQuestions парсит вопросы из TXT файла. Вопросы без id:N получают порядковый номер в файле
 func ParseQuizQuestions(filename string) ([]QuizQuestion, error) {
+	questions, err := readQuestionsFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return numberQuestions(questions), nil
+}
+
+// readQuestionsFile парсит вопросы из TXT файла, оставляя ID 0 вопросам без id:N
+func readQuestionsFile(filename string) ([]QuizQuestion, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %v", err)
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
+	return readQuestions(file)
+}
+
+// parseQuestions парсит вопросы в TXT формате из reader, вопросы без id:N нумеруются по порядку
+func parseQuestions(r io.Reader) ([]QuizQuestion, error) {
+	questions, err := readQuestions(r)
+	if err != nil {
+		return nil, err
+	}
+	return numberQuestions(questions), nil
+}
+
+// numberQuestions дает вопросам без ID (ID 0) их порядковый номер в файле
+func numberQuestions(questions []QuizQuestion) []QuizQuestion {
+	for i := range questions {
+		if questions[i].ID == 0 {
+			questions[i].ID = i + 1
+		}
+	}
+	return questions
+}
+
+// readQuestions парсит вопросы в TXT формате из reader. ID задается флагом id:N, без него остается 0
+func readQuestions(r io.Reader) ([]QuizQuestion, error) {
 	var questions []QuizQuestion
-	scanner := bufio.NewScanner(file)
-	questionID := 1
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
 
 	for scanner.Scan() {
+		lineNum++
 		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue // Пропускаем пустые строки
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue // Пропускаем пустые строки и комментарии, они не занимают порядковый номер вопроса
 		}
 
-		// Парсим строку: "вопрос" <цифра>
-		question, correct, err := parseQuestionLine(line)
+		// Необязательный префикс категории: [категория] "вопрос" ...
+		category, rest, err := parseCategory(line)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing line '%s': %v", line, err)
+			return nil, &ParseError{Line: lineNum, Text: line, Err: err}
 		}
 
-		questions = append(questions, QuizQuestion{
-			ID:       questionID,
-			Question: question,
-			Options:  []string{"👍Халяль", "🐖Харам"},
-			Correct:  correct,
-		})
-		questionID++
+		// Необязательное пояснение в конце строки: ... :: пояснение
+		rest, explanation := splitExplanation(rest)
+
+		// Парсим строку: "вопрос"[|вариант|...|] <цифра, метка или порядок> [флаги] [id:N] [time:секунды] [difficulty:1-3] [tags:тег1,тег2]
+		parsed, err := parseQuestionLine(rest)
+		if err != nil {
+			return nil, &ParseError{Line: lineNum, Text: line, Err: err}
+		}
+
+		quizQuestion := QuizQuestion{
+			Question:      parsed.question,
+			Options:       parsed.options,
+			Correct:       parsed.correct,
+			OrderedAnswer: parsed.order,
+			CorrectSet:    parsed.set,
+			Category:      category,
+			Explanation:   explanation,
+		}
+		for _, flag := range parsed.flags {
+			switch {
+			case flag == "bonus":
+				quizQuestion.Bonus = true
+			case flag == "important":
+				quizQuestion.Important = true
+			case flag == "practice":
+				quizQuestion.Practice = true
+			case flag == "retired":
+				quizQuestion.Retired = true
+			case strings.HasPrefix(flag, "id:"):
+				// Постоянный ID вопроса: id:12. По нему файл вопросов заменяет вшитый вопрос
+				id, err := strconv.Atoi(strings.TrimPrefix(flag, "id:"))
+				if err != nil || id <= 0 {
+					return nil, &ParseError{Line: lineNum, Text: line, Err: fmt.Errorf("invalid id %q", flag)}
+				}
+				quizQuestion.ID = id
+			case strings.HasPrefix(flag, "time:"):
+				// Время на ответ в секундах: time:30
+				seconds, err := strconv.Atoi(strings.TrimPrefix(flag, "time:"))
+				if err != nil || seconds <= 0 {
+					return nil, &ParseError{Line: lineNum, Text: line, Err: fmt.Errorf("invalid time limit %q", flag)}
+				}
+				quizQuestion.TimeLimit = time.Duration(seconds) * time.Second
+			case strings.HasPrefix(flag, "difficulty:"):
+				// Сложность от 1 до MaxDifficulty: difficulty:3
+				difficulty, err := strconv.Atoi(strings.TrimPrefix(flag, "difficulty:"))
+				if err != nil || difficulty < 1 || difficulty > MaxDifficulty {
+					return nil, &ParseError{Line: lineNum, Text: line, Err: fmt.Errorf("invalid difficulty %q", flag)}
+				}
+				quizQuestion.Difficulty = difficulty
+			case strings.HasPrefix(flag, "image:"):
+				// Картинка к вопросу: image:https://example.com/cat.jpg или image:<file_id>
+				image := strings.TrimPrefix(flag, "image:")
+				if image == "" {
+					return nil, &ParseError{Line: lineNum, Text: line, Err: fmt.Errorf("empty image %q", flag)}
+				}
+				quizQuestion.Image = image
+			case strings.HasPrefix(flag, "tags:"):
+				// Теги через запятую без пробелов: tags:мясо,пост
+				for _, tag := range strings.Split(strings.TrimPrefix(flag, "tags:"), ",") {
+					if tag != "" {
+						quizQuestion.Tags = append(quizQuestion.Tags, tag)
+					}
+				}
+			default:
+				return nil, &ParseError{Line: lineNum, Text: line, Err: fmt.Errorf("unknown flag %q", flag)}
+			}
+		}
+
+		questions = append(questions, quizQuestion)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %v", err)
+		// Например, слишком длинная строка: сообщаем, где чтение остановилось
+		return nil, fmt.Errorf("error reading file after line %d: %w", lineNum, err)
 	}
 
 	if len(questions) == 0 {
-		return nil, fmt.Errorf("no valid questions found in file")
+		return nil, ErrNoQuestions
 	}
 
 	return questions, nil
 }
 
-// parseQuestionLine парсит одну строку с вопросом
-func parseQuestionLine(line string) (string, int, error) {
+// ParseEmbeddedQuestions парсит базовый набор вопросов, вшитый в бинарник
+func ParseEmbeddedQuestions() ([]QuizQuestion, error) {
+	file, err := embeddedQuestions.Open("questions.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded questions: %w", err)
+	}
+	defer file.Close()
+
+	return parseQuestions(file)
+}
+
+// MergeQuestions накладывает override на base: вопрос с тем же ID заменяется, вопрос с новым ID
+// добавляется в конец. Вопросы override без ID (ID 0) всегда добавляются и получают ID после
+// самого большого из обоих наборов, поэтому случайно заменить вшитый вопрос нельзя
+func MergeQuestions(base, override []QuizQuestion) []QuizQuestion {
+	merged := make([]QuizQuestion, len(base))
+	copy(merged, base)
+
+	index := make(map[int]int, len(merged))
+	nextID := 1
+	for i, question := range merged {
+		index[question.ID] = i
+		nextID = max(nextID, question.ID+1)
+	}
+	for _, question := range override {
+		nextID = max(nextID, question.ID+1)
+	}
+
+	for _, question := range override {
+		if question.ID == 0 {
+			question.ID = nextID
+			nextID++
+		}
+		if i, exists := index[question.ID]; exists {
+			merged[i] = question
+			continue
+		}
+		index[question.ID] = len(merged)
+		merged = append(merged, question)
+	}
+
+	return merged
+}
+
+// parseCategory отделяет префикс [категория] от строки с вопросом
+func parseCategory(line string) (string, string, error) {
+	if !strings.HasPrefix(line, "[") {
+		return "", line, nil
+	}
+
+	end := strings.Index(line, "]")
+	if end < 0 {
+		return "", "", fmt.Errorf("invalid format: no closing bracket in category")
+	}
+
+	category := strings.TrimSpace(line[1:end])
+	if category == "" {
+		return "", "", fmt.Errorf("category cannot be empty")
+	}
+	return category, strings.TrimSpace(line[end+1:]), nil
+}
+
+// explanationDelimiter отделяет пояснение к ответу от остальной строки вопроса
+const explanationDelimiter = "::"
+
+// splitExplanation отделяет пояснение после explanationDelimiter. Разделитель ищется только
+// после закрывающей кавычки вопроса, чтобы "::" можно было использовать в тексте вопроса
+func splitExplanation(line string) (string, string) {
+	line = strings.TrimLeftFunc(line, unicode.IsSpace)
+	if !strings.HasPrefix(line, `"`) {
+		// Строку без открывающей кавычки отклонит parseQuestionLine
+		return line, ""
+	}
+	quoteEnd := strings.Index(line[1:], `"`) + 1
+	if quoteEnd <= 0 {
+		return line, ""
+	}
+
+	i := strings.Index(line[quoteEnd:], explanationDelimiter)
+	if i < 0 {
+		return line, ""
+	}
+	i += quoteEnd
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+len(explanationDelimiter):])
+}
+
+// parsedLine - строка с вопросом, разобранная parseQuestionLine
+type parsedLine struct {
+	question string
+	options  []string
+	correct  int
+	order    []int    // порядок вариантов для вопроса на порядок
+	set      []int    // правильные варианты для вопроса с несколькими ответами
+	flags    []string // слова после индикатора правильного ответа в нижнем регистре
+}
+
+// parseQuestionLine парсит одну строку с вопросом. Поддерживаются три формата:
+//
+//	"вопрос" <цифра или метка> [флаги]         - варианты DefaultOptions
+//	"вопрос"|вариант1|вариант2|...|<индекс> [флаги] - свои варианты ответа
+//	"вопрос"|вариант1|вариант2|...|2,0,1 [флаги]    - расставить варианты по порядку: индексы через запятую
+//	"вопрос"|вариант1|вариант2|...|0+2 [флаги]      - несколько правильных ответов: индексы через плюс
+//
+// Слова после индикатора правильного ответа возвращаются как флаги в нижнем регистре
+func parseQuestionLine(line string) (parsedLine, error) {
+	// Вопрос должен начинаться с кавычки, пробелы перед ней допускаются
+	line = strings.TrimLeftFunc(line, unicode.IsSpace)
+	if !strings.HasPrefix(line, `"`) {
+		return parsedLine{}, fmt.Errorf("invalid format: question must start with a quote")
+	}
+
 	// Ищем закрывающую кавычку
 	quoteEnd := strings.Index(line[1:], `"`) + 1
 	if quoteEnd <= 0 {
-		return "", 0, fmt.Errorf("invalid format: no closing quote")
+		return parsedLine{}, fmt.Errorf("invalid format: no closing quote")
 	}
 
 	// Извлекаем вопрос (без кавычек)
@@ -67,54 +298,210 @@ func parseQuestionLine(line string) (string, int, error) {
 	// Остаток строки после кавычки
 	remaining := strings.TrimSpace(line[quoteEnd+1:])
 
-	// Парсим цифру (0 или 1)
+	options := DefaultOptions
+	customOptions := strings.HasPrefix(remaining, "|")
+	if customOptions {
+		// Последнее поле - индекс правильного ответа и флаги
+		fields := strings.Split(remaining[1:], "|")
+		options = nil
+		for _, option := range fields[:len(fields)-1] {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				return parsedLine{}, fmt.Errorf("answer option cannot be empty")
+			}
+			options = append(options, option)
+		}
+		if len(options) < 2 {
+			return parsedLine{}, fmt.Errorf("question needs at least 2 options, got %d", len(options))
+		}
+		remaining = strings.TrimSpace(fields[len(fields)-1])
+	}
+
+	// Парсим цифру (0 или 1) или текстовую метку
 	if len(remaining) == 0 {
-		return "", 0, fmt.Errorf("no correctness indicator found")
+		return parsedLine{}, fmt.Errorf("no correctness indicator found")
 	}
 
-	correct, err := strconv.Atoi(string(remaining[0]))
+	// Индексы через запятую - порядок вариантов, через плюс - несколько правильных ответов.
+	// Оба возможны только для своих вариантов ответа
+	var order, set []int
+	var correct int
+	var err error
+	if indicator := strings.Fields(remaining)[0]; strings.Contains(indicator, ",") {
+		if !customOptions {
+			return parsedLine{}, fmt.Errorf("ordered answer %q needs custom options", indicator)
+		}
+		if order, err = parseOrder(indicator, len(options)); err == nil {
+			correct = order[0]
+		}
+	} else if strings.Contains(indicator, "+") {
+		if !customOptions {
+			return parsedLine{}, fmt.Errorf("multiple answers %q need custom options", indicator)
+		}
+		if set, err = parseCorrectSet(indicator, len(options)); err == nil {
+			correct = set[0]
+		}
+	} else {
+		correct, err = parseCorrectIndicator(remaining)
+	}
 	if err != nil {
-		return "", 0, fmt.Errorf("invalid correctness indicator: %v", err)
+		return parsedLine{}, err
 	}
 
-	if correct != 0 && correct != 1 {
-		return "", 0, fmt.Errorf("correctness must be 0 or 1, got %d", correct)
+	if correct < 0 || correct >= len(options) {
+		return parsedLine{}, fmt.Errorf("correctness must be between 0 and %d, got %d", len(options)-1, correct)
 	}
 
 	// Валидация вопроса
 	if utf8.RuneCountInString(question) == 0 {
-		return "", 0, fmt.Errorf("question cannot be empty")
+		return parsedLine{}, fmt.Errorf("question cannot be empty")
+	}
+
+	var flags []string
+	for _, field := range strings.Fields(remaining)[1:] {
+		// Ссылка или file_id картинки чувствительны к регистру, остальные флаги - нет
+		if name, value, found := strings.Cut(field, ":"); found && strings.EqualFold(name, "image") {
+			flags = append(flags, "image:"+value)
+			continue
+		}
+		flags = append(flags, strings.ToLower(field))
+	}
+
+	return parsedLine{
+		question: question,
+		options:  append([]string(nil), options...),
+		correct:  correct,
+		order:    order,
+		set:      set,
+		flags:    flags,
+	}, nil
+}
+
+// parseCorrectSet разбирает правильные варианты "0+2" и возвращает их по возрастанию
+func parseCorrectSet(indicator string, options int) ([]int, error) {
+	var set []int
+	for _, field := range strings.Split(indicator, "+") {
+		i, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiple answers %q", indicator)
+		}
+		set = append(set, i)
+	}
+	slices.Sort(set)
+	if err := validateCorrectSet(set, options); err != nil {
+		return nil, err
 	}
+	return set, nil
+}
+
+// parseOrder разбирает порядок вариантов "2,0,1" и проверяет, что в нем каждый вариант ровно один раз
+func parseOrder(indicator string, options int) ([]int, error) {
+	var order []int
+	for _, field := range strings.Split(indicator, ",") {
+		i, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ordered answer %q", indicator)
+		}
+		order = append(order, i)
+	}
+	if err := validateOrder(order, options); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
 
-	return question, correct, nil
+// parseCorrectIndicator разбирает индикатор правильного ответа: цифру или метку из AnswerLabels
+func parseCorrectIndicator(remaining string) (int, error) {
+	if remaining[0] >= '0' && remaining[0] <= '9' {
+		correct, err := strconv.Atoi(strings.Fields(remaining)[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid correctness indicator: %v", err)
+		}
+		return correct, nil
+	}
+
+	label := strings.ToLower(strings.Fields(remaining)[0])
+	correct, exists := AnswerLabels[label]
+	if !exists {
+		return 0, fmt.Errorf("unknown correctness label %q", label)
+	}
+	return correct, nil
 }
 
-// LoadQuizQuestions загружает вопросы из файла или возвращает дефолтные при ошибке
-func LoadQuizQuestions(filename string) []QuizQuestion {
-	questions, err := ParseQuizQuestions(filename)
+// LoadQuizQuestions загружает вшитый набор вопросов и накладывает на него вопросы из файла.
+// Файл с расширением .json разбирается как JSON, остальные - как TXT.
+// Вопрос файла с ID (id:N в TXT, "id" в JSON) заменяет вшитый вопрос с тем же ID, вопросы без ID добавляются (см. MergeQuestions).
+// Если файла нет или он некорректен, используется только вшитый набор,
+// а если не удалось разобрать и его - вопросы по умолчанию. logger == nil - slog.Default()
+func LoadQuizQuestions(filename string, logger *slog.Logger) []QuizQuestion {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	base, err := ParseEmbeddedQuestions()
+	if err == nil {
+		err = ValidateQuestions(base)
+	}
 	if err != nil {
-		fmt.Printf("Warning: Failed to load questions from %s: %v\n", filename, err)
-		fmt.Println("Using default questions...")
-		return DefaultQuizQuestions()
+		logger.Warn("failed to load embedded questions, using defaults", "err", err)
+		base = DefaultQuizQuestions()
+	}
+
+	override, err := readQuestionsFileAny(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		logger.Info("loaded embedded questions, no override file", "questions", len(base), "file", filename)
+		return base
+	case errors.Is(err, ErrBadFormat), errors.Is(err, ErrNoQuestions):
+		logger.Warn("invalid questions file, using embedded questions", "file", filename, "questions", len(base), "err", err)
+		return base
+	case err != nil:
+		logger.Error("failed to read questions file, using embedded questions", "file", filename, "questions", len(base), "err", err)
+		return base
 	}
 
-	fmt.Printf("Successfully loaded %d questions from %s\n", len(questions), filename)
+	questions := MergeQuestions(base, override)
+	if err := ValidateQuestions(questions); err != nil {
+		logger.Warn("invalid questions, using embedded questions", "file", filename, "questions", len(base), "err", err)
+		return base
+	}
+	logger.Info("loaded questions", "questions", len(questions), "embedded", len(base), "from_file", len(override), "file", filename)
 	return questions
 }
 
+// ReloadQuizQuestions заново собирает вопросы так же, как LoadQuizQuestions, но не подменяет
+// некорректный файл вшитым набором, а возвращает ошибку. Отсутствие файла ошибкой не считается
+func ReloadQuizQuestions(filename string) ([]QuizQuestion, error) {
+	base, err := ParseEmbeddedQuestions()
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := readQuestionsFileAny(filename)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	questions := MergeQuestions(base, override)
+	if err := ValidateQuestions(questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
 // DefaultQuizQuestions возвращает вопросы по умолчанию
 func DefaultQuizQuestions() []QuizQuestion {
 	return []QuizQuestion{
 		{
 			ID:       1,
 			Question: "Свинина",
-			Options:  []string{"👍Халяль", "🐖Харам"},
+			Options:  append([]string(nil), DefaultOptions...),
 			Correct:  1,
 		},
 		{
 			ID:       2,
 			Question: "Курица",
-			Options:  []string{"👍Халяль", "🐖Харам"},
+			Options:  append([]string(nil), DefaultOptions...),
 			Correct:  0,
 		},
 	}
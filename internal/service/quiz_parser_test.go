@@ -0,0 +1,687 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseOrderedQuestion(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader(`"Расставьте по возрастанию"|3|1|2|1,2,0 important`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	question := questions[0]
+	if !question.Ordered() {
+		t.Fatal("question is not ordered")
+	}
+	if want := []int{1, 2, 0}; !slices.Equal(question.OrderedAnswer, want) {
+		t.Errorf("OrderedAnswer = %v, want %v", question.OrderedAnswer, want)
+	}
+	if question.Correct != 1 {
+		t.Errorf("Correct = %d, want the first option of the order", question.Correct)
+	}
+	if !question.Important {
+		t.Error("flags after the order were lost")
+	}
+	if got, want := question.CorrectText(), "1 → 2 → 3"; got != want {
+		t.Errorf("CorrectText = %q, want %q", got, want)
+	}
+}
+
+func TestParseOrderedQuestionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"repeated option", `"Порядок"|a|b|c|0,0,1`},
+		{"missing option", `"Порядок"|a|b|c|0,1`},
+		{"out of range", `"Порядок"|a|b|c|0,1,3`},
+		{"not a number", `"Порядок"|a|b|c|0,x,1`},
+		{"default options", `"Порядок" 0,1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseQuestions(strings.NewReader(tt.line)); err == nil {
+				t.Errorf("parseQuestions(%q) succeeded", tt.line)
+			}
+		})
+	}
+}
+
+func TestParseOrderedQuestionJSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "questions.json")
+	data := `[{"question": "Порядок", "options": ["a", "b", "c"], "ordered_answer": [2, 0, 1]}]`
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	questions, err := ParseQuizQuestionsJSON(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 0, 1}; !slices.Equal(questions[0].OrderedAnswer, want) {
+		t.Errorf("OrderedAnswer = %v, want %v", questions[0].OrderedAnswer, want)
+	}
+
+	bad := `[{"question": "Порядок", "options": ["a", "b", "c"], "ordered_answer": [2, 2, 1]}]`
+	if err := os.WriteFile(filename, []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseQuizQuestionsJSON(filename); err == nil {
+		t.Error("repeated option in ordered_answer was accepted")
+	}
+}
+
+func TestParseMultiAnswerQuestion(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader(`"Простые числа"|2|4|5|9|2+0 difficulty:2`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	question := questions[0]
+	if want := []int{0, 2}; !slices.Equal(question.CorrectSet, want) {
+		t.Errorf("CorrectSet = %v, want %v", question.CorrectSet, want)
+	}
+	if question.Correct != 0 || question.Difficulty != 2 {
+		t.Errorf("Correct = %d, Difficulty = %d", question.Correct, question.Difficulty)
+	}
+	if got, want := question.CorrectText(), "2, 5"; got != want {
+		t.Errorf("CorrectText = %q, want %q", got, want)
+	}
+
+	for _, line := range []string{
+		`"Ответы"|a|b|c|0+0`,
+		`"Ответы"|a|b|c|0+3`,
+		`"Ответы"|a|b|c|0+x`,
+		`"Ответы"|a|b|c|1+`,
+		`"Ответы" 0+1`,
+	} {
+		if _, err := parseQuestions(strings.NewReader(line)); err == nil {
+			t.Errorf("parseQuestions(%q) succeeded", line)
+		}
+	}
+}
+
+func TestParseMultiAnswerQuestionJSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "questions.json")
+	data := `[{"question": "Ответы", "options": ["a", "b", "c"], "correct_options": [2, 1]}]`
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	questions, err := ParseQuizQuestionsJSON(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2}; !slices.Equal(questions[0].CorrectSet, want) || questions[0].Correct != 1 {
+		t.Errorf("CorrectSet = %v, Correct = %d", questions[0].CorrectSet, questions[0].Correct)
+	}
+
+	bad := `[{"question": "Ответы", "options": ["a", "b", "c"], "correct_options": [1]}]`
+	if err := os.WriteFile(filename, []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseQuizQuestionsJSON(filename); err == nil {
+		t.Error("a single correct option in correct_options was accepted")
+	}
+}
+
+func TestParseQuestionIDs(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader("\"Первый\" 0\n# комментарий\n\"Второй\" 1 id:40\n\"Третий\" 0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(questions), []int{1, 40, 3}; !slices.Equal(got, want) {
+		t.Errorf("IDs = %v, want %v", got, want)
+	}
+
+	if _, err := parseQuestions(strings.NewReader(`"Вопрос" 0 id:0`)); err == nil {
+		t.Error("id:0 was accepted")
+	}
+}
+
+func TestParseExplanation(t *testing.T) {
+	tests := []struct {
+		line            string
+		wantQuestion    string
+		wantCorrect     int
+		wantExplanation string
+		wantOptions     int
+	}{
+		{`"Свинина" 1`, "Свинина", 1, "", 2},
+		{`"Свинина" 1 :: Мясо в пост не едят`, "Свинина", 1, "Мясо в пост не едят", 2},
+		{`"Свинина" 1::без пробелов`, "Свинина", 1, "без пробелов", 2},
+		{`"2 + 2?"|3|4|5|1 id:7 :: Дважды два - четыре`, "2 + 2?", 1, "Дважды два - четыре", 3},
+		{`[Еда] "Гречка" 0 :: Крупа`, "Гречка", 0, "Крупа", 2},
+		// "::" внутри вопроса - часть текста, а не разделитель
+		{`"Что значит :: в C++?"|оператор|комментарий|0`, "Что значит :: в C++?", 0, "", 2},
+		{`"Что значит :: в C++?"|оператор|комментарий|0 :: Разрешение области видимости`, "Что значит :: в C++?", 0, "Разрешение области видимости", 2},
+		// Пустое пояснение - его нет
+		{`"Свинина" 1 ::`, "Свинина", 1, "", 2},
+	}
+	for _, tt := range tests {
+		questions, err := parseQuestions(strings.NewReader(tt.line))
+		if err != nil {
+			t.Errorf("%s: %v", tt.line, err)
+			continue
+		}
+		question := questions[0]
+		if question.Question != tt.wantQuestion || question.Correct != tt.wantCorrect || len(question.Options) != tt.wantOptions {
+			t.Errorf("%s: question %q, correct %d, %d options", tt.line, question.Question, question.Correct, len(question.Options))
+		}
+		if question.Explanation != tt.wantExplanation {
+			t.Errorf("%s: explanation %q, want %q", tt.line, question.Explanation, tt.wantExplanation)
+		}
+	}
+
+	// Файл без пояснений разбирается как раньше
+	questions, err := parseQuestions(strings.NewReader("\"Свинина\" 1\n\"Гречка\" 0 :: Крупа\n\"Курица\" 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var explanations []string
+	for _, question := range questions {
+		explanations = append(explanations, question.Explanation)
+	}
+	if want := []string{"", "Крупа", ""}; !slices.Equal(explanations, want) {
+		t.Errorf("explanations = %q, want %q", explanations, want)
+	}
+}
+
+func TestParseDifficulty(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader("\"Легкий\" 0\n\"Трудный\" 1 difficulty:3\n\"Средний\"|a|b|0 difficulty:2 id:5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var difficulties []int
+	for _, question := range questions {
+		difficulties = append(difficulties, question.Difficulty)
+	}
+	if want := []int{0, 3, 2}; !slices.Equal(difficulties, want) {
+		t.Errorf("difficulties = %v, want %v", difficulties, want)
+	}
+	if weight := questions[0].Weight(); weight != 1 {
+		t.Errorf("question without difficulty weighs %d, want 1", weight)
+	}
+
+	for _, line := range []string{
+		`"Вопрос" 0 difficulty:0`,
+		`"Вопрос" 0 difficulty:4`,
+		`"Вопрос" 0 difficulty:трудно`,
+		`"Вопрос" 0 difficulty:`,
+	} {
+		if _, err := parseQuestions(strings.NewReader(line)); !errors.Is(err, ErrBadFormat) {
+			t.Errorf("%s: err = %v, want ErrBadFormat", line, err)
+		}
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Комментарии и пустые строки между вопросами не занимают порядковые номера
+	mixed := write("mixed.txt", "# Вопросы о посте\n\n\"Свинина\" 1\n  # комментарий с отступом\n\"Гречка\" 0\n\n# --- Раздел: рыба ---\n\"Лосось\" 0\n")
+	questions, err := ParseQuizQuestions(mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, question := range questions {
+		texts = append(texts, question.Question)
+	}
+	if want := []string{"Свинина", "Гречка", "Лосось"}; !slices.Equal(texts, want) {
+		t.Errorf("questions = %q, want %q", texts, want)
+	}
+	if got, want := ids(questions), []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("IDs = %v, want %v: comments must not take question numbers", got, want)
+	}
+
+	// Файл из одних комментариев - вопросов нет, и бот берет вшитый набор
+	commentsOnly := write("comments.txt", "# один комментарий\n\n   # и еще один\n")
+	if _, err := ParseQuizQuestions(commentsOnly); !errors.Is(err, ErrNoQuestions) {
+		t.Errorf("comment-only file: %v, want ErrNoQuestions", err)
+	}
+	embedded, err := ParseEmbeddedQuestions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := LoadQuizQuestions(commentsOnly, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if !slices.Equal(ids(loaded), ids(embedded)) {
+		t.Errorf("comment-only file loaded %d questions, want the %d embedded ones", len(loaded), len(embedded))
+	}
+}
+
+func TestParseQuestionLineFields(t *testing.T) {
+	tests := []struct {
+		line string
+		want parsedLine
+	}{
+		{`"Столица?"|Рим|Париж|1 Important id:5`, parsedLine{question: "Столица?", options: []string{"Рим", "Париж"}, correct: 1, flags: []string{"important", "id:5"}}},
+		{`"По порядку"|a|b|c|2,0,1`, parsedLine{question: "По порядку", options: []string{"a", "b", "c"}, correct: 2, order: []int{2, 0, 1}}},
+		{`"Четные"|1|2|4|1+2 image:Pic.png`, parsedLine{question: "Четные", options: []string{"1", "2", "4"}, correct: 1, set: []int{1, 2}, flags: []string{"image:Pic.png"}}},
+	}
+	for _, tt := range tests {
+		got, err := parseQuestionLine(tt.line)
+		if err != nil {
+			t.Errorf("%q: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: parsed %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuestionLineQuotes(t *testing.T) {
+	// Пробелы перед открывающей кавычкой допускаются, даже если вызывающий код их не обрезал
+	for _, line := range []string{` "Свинина" 1`, "\t\"Свинина\" 1", `   "Свинина" 1 :: Мясо`} {
+		parsed, err := parseQuestionLine(line)
+		if err != nil {
+			t.Errorf("%q: %v", line, err)
+			continue
+		}
+		if parsed.question != "Свинина" || parsed.correct != 1 {
+			t.Errorf("%q: question %q, correct %d", line, parsed.question, parsed.correct)
+		}
+	}
+
+	tests := []struct {
+		line, wantErr string
+	}{
+		{`Свинина" 1`, "must start with a quote"},
+		{`Свинина 1`, "must start with a quote"},
+		{`1 "Свинина"`, "must start with a quote"},
+		{`'Свинина' 1`, "must start with a quote"},
+		{`«Свинина» 1`, "must start with a quote"},
+		{`"Свинина 1`, "no closing quote"},
+		{``, "must start with a quote"},
+	}
+	for _, tt := range tests {
+		_, err := parseQuestionLine(tt.line)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: err = %v, want %q", tt.line, err, tt.wantErr)
+		}
+	}
+
+	// В файле такая строка дает ParseError с текстом строки, а не молча неверный вопрос
+	_, err := parseQuestions(strings.NewReader("\"Свинина\" 1\nГречка\" 0"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 || parseErr.Text != `Гречка" 0` {
+		t.Errorf("err = %v, want a ParseError for line 2", err)
+	}
+}
+
+func TestParseErrorLineNumber(t *testing.T) {
+	// Плохая строка в середине файла: номер считается с учетом комментариев и пустых строк
+	var lines []string
+	for i := 1; i <= 60; i++ {
+		switch {
+		case i == 42:
+			lines = append(lines, `"Сломанный вопрос" может_быть`)
+		case i%10 == 0:
+			lines = append(lines, "# раздел")
+		case i%7 == 0:
+			lines = append(lines, "")
+		default:
+			lines = append(lines, fmt.Sprintf(`"Вопрос %d" %d`, i, i%2))
+		}
+	}
+	path := filepath.Join(t.TempDir(), "questions.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseQuizQuestions(path)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("err = %v, want *ParseError", err)
+	}
+	if parseErr.Line != 42 {
+		t.Errorf("error at line %d, want 42", parseErr.Line)
+	}
+	if !strings.HasPrefix(err.Error(), "error parsing line 42 ") {
+		t.Errorf("error %q does not start with the line number", err)
+	}
+
+	// Ошибка чтения (слишком длинная строка) сообщает, после какой строки чтение остановилось
+	long := "\"Вопрос\" 0\n\"Вопрос\" 1\n\"" + strings.Repeat("я", bufio.MaxScanTokenSize) + "\" 0\n"
+	_, err = parseQuestions(strings.NewReader(long))
+	if err == nil || !strings.Contains(err.Error(), "after line 2") {
+		t.Errorf("long line: err = %v, want the last line read", err)
+	}
+}
+
+func TestMergeQuestions(t *testing.T) {
+	base := []QuizQuestion{
+		{ID: 1, Question: "Свинина", Correct: 1},
+		{ID: 2, Question: "Курица", Correct: 0},
+		{ID: 5, Question: "Говядина", Correct: 0},
+	}
+	override := []QuizQuestion{
+		{ID: 2, Question: "Курица", Correct: 1}, // замена по ID
+		{Question: "Баранина", Correct: 0},      // без ID - добавляется
+		{ID: 7, Question: "Утка", Correct: 0},   // новый ID - добавляется
+		{Question: "Кролик", Correct: 0},        // следующий свободный ID
+	}
+
+	merged := MergeQuestions(base, override)
+	if got, want := ids(merged), []int{1, 2, 5, 8, 7, 9}; !slices.Equal(got, want) {
+		t.Fatalf("IDs = %v, want %v", got, want)
+	}
+	if merged[1].Correct != 1 {
+		t.Error("question #2 was not replaced")
+	}
+	if merged[0].Question != "Свинина" || merged[2].Question != "Говядина" {
+		t.Error("questions without an override changed")
+	}
+	if base[1].Correct != 0 {
+		t.Error("base was modified")
+	}
+}
+
+func TestLoadQuizQuestionsOverride(t *testing.T) {
+	base, err := ParseEmbeddedQuestions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantLen   int
+		wantFirst string
+	}{
+		{
+			name:      "questions without ids are appended",
+			file:      "questions.txt",
+			content:   "\"Новый вопрос\" 0\n\"Еще один\" 1",
+			wantLen:   len(base) + 2,
+			wantFirst: base[0].Question,
+		},
+		{
+			name:      "id replaces an embedded question",
+			file:      "questions.txt",
+			content:   "\"Замена первого\" 1 id:1",
+			wantLen:   len(base),
+			wantFirst: "Замена первого",
+		},
+		{
+			name:      "json id replaces, no id appends",
+			file:      "questions.json",
+			content:   `[{"id": 1, "question": "Замена первого", "correct": 0}, {"question": "Новый вопрос", "correct": 1}]`,
+			wantLen:   len(base) + 1,
+			wantFirst: "Замена первого",
+		},
+		{
+			name:      "duplicate of an embedded question keeps the embedded set",
+			file:      "questions.txt",
+			content:   fmt.Sprintf("%q 0", base[1].Question),
+			wantLen:   len(base),
+			wantFirst: base[0].Question,
+		},
+		{
+			name:      "embedded text under its id is a replacement, not a duplicate",
+			file:      "questions.txt",
+			content:   fmt.Sprintf("%q 1 id:%d important", base[1].Question, base[1].ID),
+			wantLen:   len(base),
+			wantFirst: base[0].Question,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filename, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			questions := LoadQuizQuestions(filename, logger)
+			if len(questions) != tt.wantLen {
+				t.Errorf("len = %d, want %d", len(questions), tt.wantLen)
+			}
+			if questions[0].Question != tt.wantFirst {
+				t.Errorf("first question = %q, want %q", questions[0].Question, tt.wantFirst)
+			}
+			if err := ValidateQuestions(questions); err != nil {
+				t.Errorf("loaded questions are invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseRetiredQuestion(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader("\"Старый вопрос\"|a|b|0 retired\n\"Новый вопрос\"|a|b|1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !questions[0].Retired || questions[1].Retired {
+		t.Fatalf("Retired = %t, %t, want true, false", questions[0].Retired, questions[1].Retired)
+	}
+	if active := ActiveQuestions(questions); len(active) != 1 || active[0].Question != "Новый вопрос" {
+		t.Errorf("ActiveQuestions = %+v, want only the new question", active)
+	}
+}
+
+func TestParseErrorTypes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	_, err := ParseQuizQuestions(filepath.Join(dir, "missing.txt"))
+	if !errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrBadFormat) {
+		t.Errorf("missing file: %v, want fs.ErrNotExist", err)
+	}
+
+	_, err = ParseQuizQuestions(write("empty.txt", "# только комментарий\n\n"))
+	if !errors.Is(err, ErrNoQuestions) {
+		t.Errorf("empty file: %v, want ErrNoQuestions", err)
+	}
+
+	_, err = ParseQuizQuestions(write("bad.txt", "\"Верно\"|a|b|0\nбез кавычек|a|b|0\n"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("bad line: %v, want *ParseError", err)
+	}
+	if parseErr.Line != 2 || parseErr.Text != "без кавычек|a|b|0" {
+		t.Errorf("ParseError at line %d %q, want line 2", parseErr.Line, parseErr.Text)
+	}
+	if !errors.Is(err, ErrBadFormat) {
+		t.Errorf("ParseError %v is not ErrBadFormat", err)
+	}
+
+	_, err = ParseQuizQuestionsJSON(write("bad.json", "{not json"))
+	if !errors.Is(err, ErrBadFormat) {
+		t.Errorf("bad JSON: %v, want ErrBadFormat", err)
+	}
+	_, err = ParseQuizQuestionsJSON(write("empty.json", "[]"))
+	if !errors.Is(err, ErrNoQuestions) {
+		t.Errorf("empty JSON: %v, want ErrNoQuestions", err)
+	}
+}
+
+func TestParseQuestionImage(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader(`"Кто на фото?"|Кот|Пес|0 image:https://example.com/Cat.JPG Practice`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Регистр ссылки сохраняется, в отличие от остальных флагов
+	if got := questions[0].Image; got != "https://example.com/Cat.JPG" {
+		t.Errorf("Image = %q", got)
+	}
+	if !questions[0].Practice {
+		t.Error("flag after the image was lost")
+	}
+
+	filename := filepath.Join(t.TempDir(), "questions.json")
+	data := `[{"question": "Кто на фото?", "options": ["Кот", "Пес"], "image": "AgACAgIAAxkBAAIB"}]`
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	questions, err = ParseQuizQuestionsJSON(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := questions[0].Image; got != "AgACAgIAAxkBAAIB" {
+		t.Errorf("JSON Image = %q", got)
+	}
+}
+
+func TestParseQuestionTags(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader(`"Можно ли мясо в пост?"|Да|Нет|1 tags:Мясо,пост,,праздники
+"Сколько дней длится пост?"|40|7|0 tags:пост
+"Без тегов"|Да|Нет|0`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := questions[0].Tags, []string{"мясо", "пост", "праздники"}; !slices.Equal(got, want) {
+		t.Errorf("Tags = %q, want %q", got, want)
+	}
+	if len(questions[2].Tags) != 0 {
+		t.Errorf("question without tags got %q", questions[2].Tags)
+	}
+
+	filename := filepath.Join(t.TempDir(), "questions.json")
+	data := `[{"question": "Можно ли мясо в пост?", "options": ["Да", "Нет"], "tags": [" Мясо ", "пост", ""]}]`
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	questions, err = ParseQuizQuestionsJSON(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := questions[0].Tags, []string{"мясо", "пост"}; !slices.Equal(got, want) {
+		t.Errorf("JSON Tags = %q, want %q", got, want)
+	}
+}
+
+func TestParseAcceptedAnswers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "questions.json")
+	data := `[{"question": "Северная столица?", "options": ["Москва", "Санкт-Петербург"], "correct": 1, "accepted_answers": [" Питер ", "", "Петербург"]}]`
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	questions, err := ParseQuizQuestionsJSON(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := questions[0].AcceptedAnswers, []string{"Питер", "Петербург"}; !slices.Equal(got, want) {
+		t.Errorf("AcceptedAnswers = %q, want %q", got, want)
+	}
+}
+
+func TestParseQuestionCategory(t *testing.T) {
+	questions, err := parseQuestions(strings.NewReader(`[Еда] "Можно ли мясо в пост?"|Да|Нет|1
+"Без категории"|Да|Нет|0
+[ Напитки ]"Можно ли кофе?"|Да|Нет|0`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ category, question string }{
+		{"Еда", "Можно ли мясо в пост?"},
+		{"", "Без категории"},
+		{"Напитки", "Можно ли кофе?"},
+	}
+	for i, w := range want {
+		if questions[i].Category != w.category || questions[i].Question != w.question {
+			t.Errorf("line %d: category %q, question %q, want %q, %q",
+				i+1, questions[i].Category, questions[i].Question, w.category, w.question)
+		}
+	}
+}
+
+func TestParseQuizQuestionsJSON(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.json", `[
+		{"question": "Можно ли мясо в пост?", "options": ["Да", "Нет"], "correct": 1, "category": " Еда "},
+		{"id": 10, "question": "Можно ли кофе?", "correct": 0}
+	]`)
+	questions, err := ParseQuizQuestionsJSON(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("%d questions, want 2", len(questions))
+	}
+	first, second := questions[0], questions[1]
+	if first.ID != 1 || first.Correct != 1 || first.Category != "Еда" || !slices.Equal(first.Options, []string{"Да", "Нет"}) {
+		t.Errorf("first question = %+v", first)
+	}
+	// Без options используются варианты по умолчанию
+	if second.ID != 10 || !slices.Equal(second.Options, DefaultOptions) {
+		t.Errorf("second question = %+v, want id 10 with the default options", second)
+	}
+
+	for name, content := range map[string]string{
+		"syntax.json":  `[{"question": "?"`,
+		"correct.json": `[{"question": "?", "options": ["a", "b"], "correct": 5}]`,
+		"empty.json":   `[]`,
+	} {
+		if _, err := ParseQuizQuestionsJSON(write(name, content)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+
+	// Формат выбирается по расширению, без учета регистра
+	txt := `"Вопрос из TXT"|a|b|0`
+	for _, tt := range []struct {
+		name, content, want string
+	}{
+		{"questions.json", `[{"question": "Вопрос из JSON", "options": ["a", "b"]}]`, "Вопрос из JSON"},
+		{"QUESTIONS.JSON", `[{"question": "Вопрос из JSON", "options": ["a", "b"]}]`, "Вопрос из JSON"},
+		{"questions.txt", txt, "Вопрос из TXT"},
+		{"questions", txt, "Вопрос из TXT"},
+	} {
+		questions, err := readQuestionsFileAny(write(tt.name, tt.content))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if questions[0].Question != tt.want {
+			t.Errorf("%s: question %q, want %q", tt.name, questions[0].Question, tt.want)
+		}
+	}
+
+	// Поврежденный JSON не мешает запуску: используются встроенные вопросы
+	base, err := ParseEmbeddedQuestions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if got := LoadQuizQuestions(filepath.Join(dir, "syntax.json"), logger); len(got) != len(base) {
+		t.Errorf("malformed JSON: %d questions, want the %d embedded ones", len(got), len(base))
+	}
+}
@@ -2,18 +2,26 @@ package service
 
 import (
 	"math/rand"
+	"sort"
 	"time"
 )
 
 // ShuffleQuestions перемешивает вопросы в случайном порядке
 func ShuffleQuestions(questions []QuizQuestion) []QuizQuestion {
+	return ShuffleQuestionsSeeded(questions, time.Now().UnixNano())
+}
+
+// ShuffleQuestionsSeeded перемешивает вопросы с фиксированным seed: одинаковый seed дает одинаковый порядок
+func ShuffleQuestionsSeeded(questions []QuizQuestion, seed int64) []QuizQuestion {
+	return shuffleWithRand(questions, rand.New(rand.NewSource(seed)))
+}
+
+// shuffleWithRand перемешивает копию вопросов, используя переданный генератор
+func shuffleWithRand(questions []QuizQuestion, r *rand.Rand) []QuizQuestion {
 	// Создаем копию массива, чтобы не изменять оригинал
 	shuffled := make([]QuizQuestion, len(questions))
 	copy(shuffled, questions)
 
-	// Инициализируем генератор случайных чисел
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
 	// Перемешиваем вопросы используя алгоритм Фишера-Йейтса
 	for i := len(shuffled) - 1; i > 0; i-- {
 		j := r.Intn(i + 1)
@@ -23,13 +31,99 @@ func ShuffleQuestions(questions []QuizQuestion) []QuizQuestion {
 	return shuffled
 }
 
+// ShuffleOptions возвращает копию вопроса с перемешанными вариантами ответа,
+// Correct, OrderedAnswer и CorrectSet указывают на новые места вариантов
+func ShuffleOptions(q QuizQuestion) QuizQuestion {
+	return shuffleOptionsWithRand(q, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// ShuffleOptionsSeeded перемешивает варианты ответа с фиксированным seed: одинаковый seed дает одинаковый порядок
+func ShuffleOptionsSeeded(q QuizQuestion, seed int64) QuizQuestion {
+	return shuffleOptionsWithRand(q, rand.New(rand.NewSource(seed)))
+}
+
+// shuffleOptionsWithRand перемешивает варианты ответа вопроса, используя переданный генератор
+func shuffleOptionsWithRand(q QuizQuestion, r *rand.Rand) QuizQuestion {
+	options := make([]string, len(q.Options))
+	copy(options, q.Options)
+
+	// position[k] - текущее место варианта, который исходно был k-м; origin[i] - исходный номер варианта на месте i
+	position := make([]int, len(options))
+	origin := make([]int, len(options))
+	for i := range options {
+		position[i], origin[i] = i, i
+	}
+	for i := len(options) - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		options[i], options[j] = options[j], options[i]
+
+		// Следим за вариантами, пока они переезжают
+		origin[i], origin[j] = origin[j], origin[i]
+		position[origin[i]], position[origin[j]] = i, j
+	}
+
+	q.Options = options
+	q.Correct = position[q.Correct]
+	if q.Ordered() {
+		order := make([]int, len(q.OrderedAnswer))
+		for k, option := range q.OrderedAnswer {
+			order[k] = position[option]
+		}
+		q.OrderedAnswer = order
+	}
+	if q.Multi() {
+		set := make([]int, len(q.CorrectSet))
+		for k, option := range q.CorrectSet {
+			set[k] = position[option]
+		}
+		sort.Ints(set)
+		q.CorrectSet = set
+		q.Correct = set[0]
+	}
+	return q
+}
+
+// FiftyFiftyOptions выбирает половину неправильных вариантов вопроса (с округлением вниз),
+// которые убирает подсказка 50/50. Правильные варианты в список никогда не попадают
+func FiftyFiftyOptions(q QuizQuestion) []int {
+	return fiftyFiftyWithRand(q, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// fiftyFiftyWithRand выбирает убираемые варианты, используя переданный генератор
+func fiftyFiftyWithRand(q QuizQuestion, r *rand.Rand) []int {
+	var wrong []int
+	for i := range q.Options {
+		if !q.IsCorrectOption(i) {
+			wrong = append(wrong, i)
+		}
+	}
+
+	r.Shuffle(len(wrong), func(i, j int) {
+		wrong[i], wrong[j] = wrong[j], wrong[i]
+	})
+	hidden := wrong[:len(wrong)/2]
+	sort.Ints(hidden)
+	return hidden
+}
+
 // ShuffleQuestionsWithLimit перемешивает вопросы и возвращает только limit штук
 func ShuffleQuestionsWithLimit(questions []QuizQuestion, limit int) []QuizQuestion {
 	shuffled := ShuffleQuestions(questions)
+	return limitQuestions(shuffled, limit)
+}
+
+// ShuffleQuestionsWithLimitSeeded работает как ShuffleQuestionsWithLimit, но с фиксированным seed:
+// для одного и того же seed (например, даты) выбор и порядок вопросов всегда совпадают
+func ShuffleQuestionsWithLimitSeeded(questions []QuizQuestion, limit int, seed int64) []QuizQuestion {
+	shuffled := ShuffleQuestionsSeeded(questions, seed)
+	return limitQuestions(shuffled, limit)
+}
 
-	if limit <= 0 || limit > len(shuffled) {
-		limit = len(shuffled)
+// limitQuestions обрезает список до limit вопросов, limit <= 0 означает все вопросы
+func limitQuestions(questions []QuizQuestion, limit int) []QuizQuestion {
+	if limit <= 0 || limit > len(questions) {
+		limit = len(questions)
 	}
 
-	return shuffled[:limit]
+	return questions[:limit]
 }
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func manyQuestions(n int) []QuizQuestion {
+	questions := make([]QuizQuestion, n)
+	for i := range questions {
+		questions[i] = QuizQuestion{ID: i + 1, Question: fmt.Sprintf("Вопрос %d", i+1), Options: []string{"a", "b", "c", "d"}, Correct: i % 4}
+	}
+	return questions
+}
+
+func TestShuffleQuestionsSeeded(t *testing.T) {
+	questions := manyQuestions(20)
+
+	first := ShuffleQuestionsSeeded(questions, 7)
+	second := ShuffleQuestionsSeeded(questions, 7)
+	if !slices.Equal(ids(first), ids(second)) {
+		t.Errorf("same seed gave different orders: %v and %v", ids(first), ids(second))
+	}
+	if slices.Equal(ids(first), ids(questions)) {
+		t.Errorf("questions were not shuffled: %v", ids(first))
+	}
+
+	sorted := slices.Clone(ids(first))
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, ids(questions)) {
+		t.Errorf("shuffle lost or duplicated questions: %v", ids(first))
+	}
+}
+
+func TestShuffleQuestionsWithLimitSeeded(t *testing.T) {
+	questions := manyQuestions(30)
+	original := slices.Clone(questions)
+
+	first := ShuffleQuestionsWithLimitSeeded(questions, 10, 20261016)
+	second := ShuffleQuestionsWithLimitSeeded(questions, 10, 20261016)
+	if len(first) != 10 {
+		t.Fatalf("len = %d, want 10", len(first))
+	}
+	if !slices.EqualFunc(first, second, func(a, b QuizQuestion) bool { return a.ID == b.ID }) {
+		t.Errorf("same seed gave different quizzes: %v and %v", ids(first), ids(second))
+	}
+
+	other := ShuffleQuestionsWithLimitSeeded(questions, 10, 20261017)
+	if slices.Equal(ids(first), ids(other)) {
+		t.Errorf("different seeds gave the same quiz %v", ids(first))
+	}
+
+	if !slices.EqualFunc(questions, original, func(a, b QuizQuestion) bool { return a.ID == b.ID }) {
+		t.Error("source questions were reordered")
+	}
+}
+
+func TestShuffleOptionsSeeded(t *testing.T) {
+	question := manyQuestions(1)[0]
+	first := ShuffleOptionsSeeded(question, 42)
+	second := ShuffleOptionsSeeded(question, 42)
+	if !slices.Equal(first.Options, second.Options) || first.Correct != second.Correct {
+		t.Errorf("same seed gave %v/%d and %v/%d", first.Options, first.Correct, second.Options, second.Correct)
+	}
+	if first.Options[first.Correct] != question.Options[question.Correct] {
+		t.Errorf("correct option moved to %q", first.Options[first.Correct])
+	}
+}
+
+func TestShuffleOptions(t *testing.T) {
+	tests := []QuizQuestion{
+		{Question: "Один ответ", Options: []string{"a", "b", "c", "d", "e"}, Correct: 3},
+		{Question: "По порядку", Options: []string{"a", "b", "c", "d"}, OrderedAnswer: []int{2, 0, 3, 1}},
+		{Question: "Несколько ответов", Options: []string{"a", "b", "c", "d"}, Correct: 1, CorrectSet: []int{1, 3}},
+	}
+	for _, question := range tests {
+		moved := false
+		for range 50 {
+			shuffled := ShuffleOptions(question)
+			if !slices.Equal(shuffled.Options, question.Options) {
+				moved = true
+			}
+			if got, want := correctOptions(shuffled), correctOptions(question); !slices.Equal(got, want) {
+				t.Fatalf("%s: correct options %q, want %q", question.Question, got, want)
+			}
+			sorted := slices.Sorted(slices.Values(shuffled.Options))
+			if !slices.Equal(sorted, question.Options) {
+				t.Fatalf("%s: options %v lost or duplicated", question.Question, shuffled.Options)
+			}
+		}
+		if !moved {
+			t.Errorf("%s: options were never shuffled", question.Question)
+		}
+	}
+	if question := tests[0]; !slices.Equal(question.Options, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("source options were reordered: %v", question.Options)
+	}
+}
+
+// correctOptions - тексты правильных вариантов вопроса: у вопроса на порядок - в правильном порядке,
+// у вопроса с несколькими ответами - по алфавиту
+func correctOptions(q QuizQuestion) []string {
+	var indexes []int
+	switch {
+	case q.Ordered():
+		indexes = q.OrderedAnswer
+	case q.Multi():
+		indexes = q.CorrectSet
+	default:
+		return []string{q.Options[q.Correct]}
+	}
+
+	options := make([]string, len(indexes))
+	for i, index := range indexes {
+		options[i] = q.Options[index]
+	}
+	if q.Multi() {
+		slices.Sort(options)
+	}
+	return options
+}
+
+func ids(questions []QuizQuestion) []int {
+	result := make([]int, len(questions))
+	for i, question := range questions {
+		result[i] = question.ID
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestCategoryCounts(t *testing.T) {
+	questions := []QuizQuestion{
+		{Category: "История"},
+		{Category: "География"},
+		{Category: "История"},
+		{},
+		{Category: "Биология"},
+		{Category: "География"},
+		{Category: "История"},
+	}
+
+	counts, uncategorized := CategoryCounts(questions, true)
+	want := []CategoryCount{{"История", 3}, {"География", 2}, {"Биология", 1}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("by count = %v, want %v", counts, want)
+	}
+	if uncategorized != 1 {
+		t.Errorf("uncategorized = %d, want 1", uncategorized)
+	}
+
+	counts, _ = CategoryCounts(questions, false)
+	want = []CategoryCount{{"Биология", 1}, {"География", 2}, {"История", 3}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("by name = %v, want %v", counts, want)
+	}
+
+	counts, uncategorized = CategoryCounts([]QuizQuestion{{}, {}}, true)
+	if len(counts) != 0 || uncategorized != 2 {
+		t.Errorf("no categories: counts = %v, uncategorized = %d", counts, uncategorized)
+	}
+}
+
+func TestQuestionsWithTag(t *testing.T) {
+	questions := []QuizQuestion{
+		{ID: 1, Tags: []string{"мясо", "пост"}},
+		{ID: 2, Tags: []string{"пост"}},
+		{ID: 3, Tags: []string{"праздники"}},
+		{ID: 4},
+	}
+
+	ids := func(questions []QuizQuestion) []int {
+		var ids []int
+		for _, question := range questions {
+			ids = append(ids, question.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		tag  string
+		want []int
+	}{
+		{"пост", []int{1, 2}},
+		{"Мясо", []int{1}}, // без учета регистра
+		{"праздники", []int{3}},
+		{"рыба", nil},
+	}
+	for _, tt := range tests {
+		if got := ids(QuestionsWithTag(questions, tt.tag)); !slices.Equal(got, tt.want) {
+			t.Errorf("QuestionsWithTag(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestRevealText(t *testing.T) {
+	question := QuizQuestion{
+		Options:         []string{"Москва", "Санкт-Петербург", "Казань"},
+		Correct:         1,
+		AcceptedAnswers: []string{"Петербург", "Питер"},
+	}
+	allowed := []string{"Санкт-Петербург", "Петербург", "Питер"}
+
+	// Показываются только правильный вариант и его формулировки, и каждая из них рано или поздно
+	shown := make(map[string]bool)
+	for range 200 {
+		text := question.RevealText(rand.Intn)
+		if !slices.Contains(allowed, text) {
+			t.Fatalf("RevealText = %q, want one of %q", text, allowed)
+		}
+		shown[text] = true
+	}
+	if len(shown) != len(allowed) {
+		t.Errorf("shown %v, want every accepted answer", shown)
+	}
+
+	// Обычный вопрос показывает единственный правильный вариант
+	question.AcceptedAnswers = nil
+	if got := question.RevealText(func(int) int { t.Fatal("rand used for a standard question"); return 0 }); got != "Санкт-Петербург" {
+		t.Errorf("standard question: RevealText = %q", got)
+	}
+
+	multi := QuizQuestion{Options: []string{"a", "b", "c"}, CorrectSet: []int{0, 2}, AcceptedAnswers: []string{"x"}}
+	if got := multi.RevealText(rand.Intn); got != "a, c" {
+		t.Errorf("multi question: RevealText = %q, want all correct options", got)
+	}
+}
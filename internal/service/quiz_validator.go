@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CheckOptionCounts ищет вопросы, у которых количество вариантов ответа отличается от expected.
+// Если expected <= 0, ожидаемым считается самое частое количество вариантов в наборе.
+// Возвращает ожидаемое количество и список несовпадающих вопросов
+func CheckOptionCounts(questions []QuizQuestion, expected int) (int, []QuizQuestion) {
+	if expected <= 0 {
+		expected = mostCommonOptionCount(questions)
+	}
+
+	var mismatched []QuizQuestion
+	for _, question := range questions {
+		if len(question.Options) != expected {
+			mismatched = append(mismatched, question)
+		}
+	}
+
+	return expected, mismatched
+}
+
+// mostCommonOptionCount возвращает самое частое количество вариантов ответа (при равенстве - меньшее)
+func mostCommonOptionCount(questions []QuizQuestion) int {
+	counts := make(map[int]int)
+	for _, question := range questions {
+		counts[len(question.Options)]++
+	}
+
+	mode, best := 0, 0
+	for count, n := range counts {
+		if n > best || (n == best && count < mode) {
+			mode, best = count, n
+		}
+	}
+	return mode
+}
+
+// ValidateQuestions проверяет набор вопросов: ID и текст вопроса не повторяются (текст - без учета
+// регистра и лишних пробелов), у каждого вопроса есть варианты ответа, а индекс правильного ответа
+// указывает на один из них. Возвращает все найденные ошибки разом
+func ValidateQuestions(questions []QuizQuestion) error {
+	var errs []error
+	seen := make(map[string]int, len(questions))
+	ids := make(map[int]bool, len(questions))
+	for _, question := range questions {
+		if ids[question.ID] {
+			errs = append(errs, fmt.Errorf("question id %d is used more than once: %q", question.ID, question.Question))
+		}
+		ids[question.ID] = true
+
+		text := normalizeQuestionText(question.Question)
+		if id, exists := seen[text]; exists {
+			errs = append(errs, fmt.Errorf("question #%d duplicates question #%d: %q", question.ID, id, question.Question))
+		} else {
+			seen[text] = question.ID
+		}
+
+		if len(question.Options) == 0 {
+			errs = append(errs, fmt.Errorf("question #%d has no options", question.ID))
+			continue
+		}
+		if question.Correct < 0 || question.Correct >= len(question.Options) {
+			errs = append(errs, fmt.Errorf("question #%d: correct index %d out of range [0, %d)",
+				question.ID, question.Correct, len(question.Options)))
+		}
+		if question.Ordered() {
+			if err := validateOrder(question.OrderedAnswer, len(question.Options)); err != nil {
+				errs = append(errs, fmt.Errorf("question #%d: %w", question.ID, err))
+			}
+		}
+		if question.Multi() {
+			if err := validateCorrectSet(question.CorrectSet, len(question.Options)); err != nil {
+				errs = append(errs, fmt.Errorf("question #%d: %w", question.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateCorrectSet проверяет правильные варианты вопроса с несколькими ответами: их хотя бы два,
+// они идут по возрастанию без повторов и указывают на один из options вариантов
+func validateCorrectSet(set []int, options int) error {
+	if len(set) < 2 {
+		return fmt.Errorf("multiple answers need at least 2 correct options, got %d", len(set))
+	}
+	for k, i := range set {
+		if i < 0 || i >= options {
+			return fmt.Errorf("correct option %d out of range [0, %d)", i, options)
+		}
+		if k > 0 && set[k-1] >= i {
+			return fmt.Errorf("correct options must be ascending without repeats, got %v", set)
+		}
+	}
+	return nil
+}
+
+// validateOrder проверяет, что order перечисляет каждый из options вариантов ровно один раз
+func validateOrder(order []int, options int) error {
+	if len(order) != options {
+		return fmt.Errorf("order must list all %d options, got %d", options, len(order))
+	}
+	seen := make([]bool, options)
+	for _, i := range order {
+		if i < 0 || i >= options {
+			return fmt.Errorf("order index %d out of range [0, %d)", i, options)
+		}
+		if seen[i] {
+			return fmt.Errorf("order repeats option %d", i)
+		}
+		seen[i] = true
+	}
+	return nil
+}
+
+// normalizeQuestionText приводит текст вопроса к виду для поиска дубликатов
+func normalizeQuestionText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateQuestions(t *testing.T) {
+	valid := func() []QuizQuestion {
+		return []QuizQuestion{
+			{ID: 1, Question: "Свинина", Options: []string{"можно", "нельзя"}, Correct: 1},
+			{ID: 2, Question: "По алфавиту", Options: []string{"в", "а", "б"}, Correct: 1, OrderedAnswer: []int{1, 2, 0}},
+			{ID: 3, Question: "Четные", Options: []string{"1", "2", "3", "4"}, Correct: 1, CorrectSet: []int{1, 3}},
+		}
+	}
+	if err := ValidateQuestions(valid()); err != nil {
+		t.Fatalf("valid questions: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		change  func(questions []QuizQuestion) []QuizQuestion
+		wantErr string
+	}{
+		{"duplicate text", func(q []QuizQuestion) []QuizQuestion {
+			return append(q, QuizQuestion{ID: 4, Question: "Свинина", Options: []string{"да", "нет"}})
+		}, "question #4 duplicates question #1"},
+		{"duplicate text in another case and spacing", func(q []QuizQuestion) []QuizQuestion {
+			return append(q, QuizQuestion{ID: 4, Question: "  СВИНИНА ", Options: []string{"да", "нет"}})
+		}, "question #4 duplicates question #1"},
+		{"duplicate id", func(q []QuizQuestion) []QuizQuestion {
+			return append(q, QuizQuestion{ID: 1, Question: "Курица", Options: []string{"да", "нет"}})
+		}, "question id 1 is used more than once"},
+		{"no options", func(q []QuizQuestion) []QuizQuestion {
+			q[0].Options = nil
+			return q
+		}, "question #1 has no options"},
+		{"correct past the options", func(q []QuizQuestion) []QuizQuestion {
+			q[0].Correct = 2
+			return q
+		}, "question #1: correct index 2 out of range [0, 2)"},
+		{"negative correct", func(q []QuizQuestion) []QuizQuestion {
+			q[0].Correct = -1
+			return q
+		}, "question #1: correct index -1 out of range"},
+		{"order misses an option", func(q []QuizQuestion) []QuizQuestion {
+			q[1].OrderedAnswer = []int{1, 2}
+			return q
+		}, "question #2: order must list all 3 options"},
+		{"order repeats an option", func(q []QuizQuestion) []QuizQuestion {
+			q[1].OrderedAnswer = []int{1, 1, 0}
+			return q
+		}, "question #2: order repeats option 1"},
+		{"correct set out of range", func(q []QuizQuestion) []QuizQuestion {
+			q[2].CorrectSet = []int{1, 4}
+			return q
+		}, "question #3: correct option 4 out of range"},
+		{"correct set not ascending", func(q []QuizQuestion) []QuizQuestion {
+			q[2].CorrectSet = []int{3, 1}
+			return q
+		}, "question #3: correct options must be ascending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQuestions(tt.change(valid()))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Все ошибки сообщаются разом, а не только первая
+	questions := valid()
+	questions[0].Correct = 5
+	questions[2].Options = nil
+	err := ValidateQuestions(questions)
+	if err == nil || !strings.Contains(err.Error(), "question #1") || !strings.Contains(err.Error(), "question #3") {
+		t.Errorf("err = %v, want both broken questions", err)
+	}
+}
+
+func TestLoadQuizQuestionsRejectsInvalidFile(t *testing.T) {
+	embedded, err := ParseEmbeddedQuestions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateQuestions(embedded); err != nil {
+		t.Fatalf("embedded questions are invalid: %v", err)
+	}
+
+	// Файл с повторяющимся вопросом не подмешивается к вшитым вопросам
+	path := filepath.Join(t.TempDir(), "questions.txt")
+	content := "\"Новый вопрос?\" 0 id:10001\n\"Новый  ВОПРОС?\" 1 id:10002\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded := LoadQuizQuestions(path, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if !slices.Equal(ids(loaded), ids(embedded)) {
+		t.Errorf("loaded %d questions, want the %d embedded ones", len(loaded), len(embedded))
+	}
+	if _, err := ReloadQuizQuestions(path); err == nil || !strings.Contains(err.Error(), "duplicates") {
+		t.Errorf("ReloadQuizQuestions: err = %v, want the duplicate reported", err)
+	}
+}
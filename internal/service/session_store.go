@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+)
+
+// sessionsNamespace - пространство имен незавершенных викторин в Store, ключ - ID чата
+const sessionsNamespace = "sessions"
+
+// SessionStore сохраняет незавершенные викторины в Store, чтобы после перезапуска бота
+// продолжить их с того же вопроса
+type SessionStore struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// NewSessionStore хранит викторины в store. logger == nil - slog.Default()
+func NewSessionStore(store Store, logger *slog.Logger) *SessionStore {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SessionStore{store: store, logger: logger}
+}
+
+// Save сохраняет состояние викторины чата
+func (ss *SessionStore) Save(chatID int64, session *QuizSession) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		ss.logger.Error("encoding quiz session failed", "chat_id", chatID, "err", err)
+		return
+	}
+
+	if err := ss.store.Set(sessionsNamespace, strconv.FormatInt(chatID, 10), data); err != nil {
+		ss.logger.Error("saving quiz session failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// Delete удаляет сохраненную викторину чата
+func (ss *SessionStore) Delete(chatID int64) {
+	if err := ss.store.Delete(sessionsNamespace, strconv.FormatInt(chatID, 10)); err != nil {
+		ss.logger.Error("deleting quiz session failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// LoadAll возвращает сохраненные викторины по ID чата. Поврежденные записи пропускаются
+func (ss *SessionStore) LoadAll() map[int64]*QuizSession {
+	values, err := ss.store.List(sessionsNamespace)
+	if err != nil {
+		ss.logger.Error("loading quiz sessions failed", "err", err)
+		return nil
+	}
+
+	sessions := make(map[int64]*QuizSession, len(values))
+	for key, value := range values {
+		chatID, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			ss.logger.Error("invalid quiz session key", "key", key)
+			continue
+		}
+
+		var session QuizSession
+		if err := json.Unmarshal(value, &session); err != nil || len(session.Questions) == 0 {
+			ss.logger.Error("invalid quiz session", "chat_id", chatID, "err", err)
+			continue
+		}
+		sessions[chatID] = &session
+	}
+	return sessions
+}
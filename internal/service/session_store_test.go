@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionStoreRoundTrip(t *testing.T) {
+	sessions := NewSessionStore(NewMemoryStore(), nil)
+	session := &QuizSession{
+		UserID:          7,
+		CurrentQuestion: 1,
+		Score:           2,
+		Questions:       []QuizQuestion{{ID: 1, Question: "?", Options: []string{"a", "b"}}, {ID: 2, Question: "?", Options: []string{"a", "b"}}},
+		Answers:         []AnswerRecord{{Question: QuizQuestion{ID: 1}, Selected: 0, Correct: true}},
+		Player:          &Player{ID: 7, FirstName: "Player"},
+		Timer:           time.NewTimer(time.Hour),
+		Recovered:       true,
+	}
+	defer session.StopTimer()
+	sessions.Save(-100, session)
+
+	loaded := sessions.LoadAll()
+	restored, exists := loaded[-100]
+	if !exists {
+		t.Fatalf("LoadAll = %v, want the saved session", loaded)
+	}
+	if restored.CurrentQuestion != 1 || restored.Score != 2 || len(restored.Questions) != 2 || len(restored.Answers) != 1 {
+		t.Errorf("restored session %+v", restored)
+	}
+	if restored.Player == nil || restored.Player.FirstName != "Player" {
+		t.Errorf("Player = %+v", restored.Player)
+	}
+	// Таймер и отметка о восстановлении не сохраняются
+	if restored.Timer != nil || restored.Recovered {
+		t.Errorf("Timer = %v, Recovered = %t, want neither saved", restored.Timer, restored.Recovered)
+	}
+
+	sessions.Delete(-100)
+	if loaded := sessions.LoadAll(); len(loaded) != 0 {
+		t.Errorf("LoadAll after Delete = %v", loaded)
+	}
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStoreSchema - общая таблица пространств имен SQLiteStore, кроме лидерборда
+const sqliteStoreSchema = `
+CREATE TABLE IF NOT EXISTS store (
+	namespace TEXT NOT NULL,
+	key       TEXT NOT NULL,
+	value     TEXT NOT NULL,
+	PRIMARY KEY (namespace, key)
+);`
+
+// sqliteLeaderboardSchema - таблица лидерборда: по столбцу на поле записи, чтобы топ, место игрока
+// и поиск по имени считались запросами SQL без загрузки всех записей. Индекс leaderboard_rank
+// повторяет порядок мест (см. sqliteRankOrder)
+const sqliteLeaderboardSchema = `
+CREATE TABLE IF NOT EXISTS leaderboard (
+	user_id        INTEGER PRIMARY KEY,
+	username       TEXT    NOT NULL DEFAULT '',
+	first_name     TEXT    NOT NULL DEFAULT '',
+	username_key   TEXT    NOT NULL DEFAULT '', -- username в нижнем регистре для поиска
+	first_name_key TEXT    NOT NULL DEFAULT '', -- имя в нижнем регистре для поиска
+	score          INTEGER NOT NULL DEFAULT 0,
+	total          INTEGER NOT NULL DEFAULT 0,
+	percent        REAL    NOT NULL DEFAULT 0,  -- точный процент score*100/total
+	attempts       INTEGER NOT NULL DEFAULT 0,
+	bonus          INTEGER NOT NULL DEFAULT 0,
+	duration       INTEGER NOT NULL DEFAULT 0,
+	date           TEXT    NOT NULL DEFAULT '',
+	last_played    TEXT    NOT NULL DEFAULT '',
+	created_at     TEXT    NOT NULL DEFAULT '', -- RFC 3339 по UTC, '' - неизвестно
+	updated_at     INTEGER NOT NULL DEFAULT 0   -- время последней записи, unix
+);
+CREATE INDEX IF NOT EXISTS leaderboard_rank ON leaderboard (percent DESC, score DESC, user_id);
+CREATE INDEX IF NOT EXISTS leaderboard_username ON leaderboard (username_key);
+CREATE INDEX IF NOT EXISTS leaderboard_first_name ON leaderboard (first_name_key);`
+
+// sqliteEntryColumns - столбцы записи лидерборда в порядке scanEntry
+const sqliteEntryColumns = `user_id, username, first_name, score, total, attempts, bonus, duration, date, last_played, created_at`
+
+// sqliteRankOrder - порядок мест лидерборда, тот же, что у sortEntries: точная доля правильных
+// ответов, затем очки, при равенстве - меньший user_id
+const sqliteRankOrder = `ORDER BY percent DESC, score DESC, user_id`
+
+// sqliteRanksAbove - условие "запись o стоит выше записи leaderboard" в порядке sqliteRankOrder
+const sqliteRanksAbove = `(o.percent > leaderboard.percent OR (o.percent = leaderboard.percent AND
+	(o.score > leaderboard.score OR (o.score = leaderboard.score AND o.user_id < leaderboard.user_id))))`
+
+// SQLiteStore хранит данные в файле базы SQLite: запись одного ключа не переписывает
+// все пространство имен, как в файле или Gist
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore открывает (или создает) базу SQLite в файле path. Лидерборд из базы
+// старого формата, где запись хранилась одним JSON, переносится в столбцы
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	// Транзакции сразу берут блокировку записи: иначе две транзакции из разных процессов,
+	// прочитав запись, не смогут обе ее перезаписать
+	dsn := path + "?"
+	if strings.Contains(path, "?") {
+		dsn = path + "&"
+	}
+	db, err := sql.Open("sqlite3", dsn+"_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite не пишет из нескольких соединений одновременно, а у :memory: у каждого соединения своя база
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteStoreSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating sqlite schema in %s: %w", path, err)
+	}
+	if err := migrateJSONLeaderboard(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating sqlite leaderboard in %s: %w", path, err)
+	}
+	if _, err := db.Exec(sqliteLeaderboardSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating sqlite schema in %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrateJSONLeaderboard переносит записи из таблицы leaderboard(user_id, entry) старого формата в столбцы
+func migrateJSONLeaderboard(db *sql.DB) error {
+	var legacy int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('leaderboard') WHERE name = 'entry'`).Scan(&legacy); err != nil {
+		return err
+	}
+	if legacy == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`ALTER TABLE leaderboard RENAME TO leaderboard_json`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(sqliteLeaderboardSchema); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT user_id, entry FROM leaderboard_json`)
+	if err != nil {
+		return err
+	}
+	var entries []LeaderboardEntry
+	for rows.Next() {
+		var userID int64
+		var value string
+		if err := rows.Scan(&userID, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		var entry LeaderboardEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			rows.Close()
+			return fmt.Errorf("invalid leaderboard entry %d: %w", userID, err)
+		}
+		entry.UserID = userID
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := saveEntry(tx, entry); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DROP TABLE leaderboard_json`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close закрывает базу
+func (ss *SQLiteStore) Close() error {
+	return ss.db.Close()
+}
+
+// leaderboardKey разбирает ключ лидерборда - ID пользователя
+func leaderboardKey(key string) (int64, error) {
+	userID, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("leaderboard key %q is not a user id", key)
+	}
+	return userID, nil
+}
+
+// sqliteExecer - *sql.DB или *sql.Tx
+type sqliteExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// sqliteScanner - *sql.Row или *sql.Rows
+type sqliteScanner interface {
+	Scan(dest ...any) error
+}
+
+// saveEntry записывает запись лидерборда в ее строку таблицы
+func saveEntry(db sqliteExecer, entry LeaderboardEntry) error {
+	var percent float64
+	if entry.Total > 0 {
+		percent = float64(entry.Score*100) / float64(entry.Total)
+	}
+	var createdAt string
+	if !entry.CreatedAt.IsZero() {
+		createdAt = entry.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	_, err := db.Exec(`INSERT OR REPLACE INTO leaderboard (user_id, username, first_name, username_key, first_name_key,
+			score, total, percent, attempts, bonus, duration, date, last_played, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.UserID, entry.Username, entry.FirstName, strings.ToLower(entry.Username), strings.ToLower(entry.FirstName),
+		entry.Score, entry.Total, percent, entry.Attempts, entry.Bonus, entry.Duration,
+		entry.Date, entry.LastPlayed, createdAt, time.Now().Unix())
+	return err
+}
+
+// scanEntry читает запись лидерборда из столбцов sqliteEntryColumns, extra - следующие за ними столбцы
+func scanEntry(row sqliteScanner, extra ...any) (LeaderboardEntry, error) {
+	var entry LeaderboardEntry
+	var createdAt string
+	dest := append([]any{&entry.UserID, &entry.Username, &entry.FirstName, &entry.Score, &entry.Total,
+		&entry.Attempts, &entry.Bonus, &entry.Duration, &entry.Date, &entry.LastPlayed, &createdAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return entry, err
+	}
+
+	if entry.Total > 0 {
+		entry.Percentage = (entry.Score * 100) / entry.Total
+	}
+	if createdAt != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+		if err != nil {
+			return entry, fmt.Errorf("invalid created_at of leaderboard entry %d: %w", entry.UserID, err)
+		}
+		entry.CreatedAt = parsed
+	}
+	return entry, nil
+}
+
+// queryEntries выполняет запрос, который выбирает sqliteEntryColumns
+func (ss *SQLiteStore) queryEntries(query string, args ...any) ([]LeaderboardEntry, error) {
+	rows, err := ss.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []LeaderboardEntry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (ss *SQLiteStore) Get(namespace, key string) ([]byte, error) {
+	if namespace == leaderboardNamespace {
+		userID, err := leaderboardKey(key)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := scanEntry(ss.db.QueryRow(`SELECT `+sqliteEntryColumns+` FROM leaderboard WHERE user_id = ?`, userID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		return json.Marshal(entry)
+	}
+
+	var value string
+	err := ss.db.QueryRow(`SELECT value FROM store WHERE namespace = ? AND key = ?`, namespace, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+func (ss *SQLiteStore) Set(namespace, key string, value []byte) error {
+	if namespace == leaderboardNamespace {
+		userID, err := leaderboardKey(key)
+		if err != nil {
+			return err
+		}
+		var entry LeaderboardEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return fmt.Errorf("invalid leaderboard entry %s: %w", key, err)
+		}
+		entry.UserID = userID
+		return saveEntry(ss.db, entry)
+	}
+
+	_, err := ss.db.Exec(`INSERT INTO store (namespace, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (namespace, key) DO UPDATE SET value = excluded.value`, namespace, key, string(value))
+	return err
+}
+
+func (ss *SQLiteStore) Delete(namespace, key string) error {
+	if namespace == leaderboardNamespace {
+		userID, err := leaderboardKey(key)
+		if err != nil {
+			return err
+		}
+		_, err = ss.db.Exec(`DELETE FROM leaderboard WHERE user_id = ?`, userID)
+		return err
+	}
+
+	_, err := ss.db.Exec(`DELETE FROM store WHERE namespace = ? AND key = ?`, namespace, key)
+	return err
+}
+
+func (ss *SQLiteStore) List(namespace string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	if namespace == leaderboardNamespace {
+		entries, err := ss.queryEntries(`SELECT ` + sqliteEntryColumns + ` FROM leaderboard`)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return nil, err
+			}
+			values[strconv.FormatInt(entry.UserID, 10)] = value
+		}
+		return values, nil
+	}
+
+	rows, err := ss.db.Query(`SELECT key, value FROM store WHERE namespace = ?`, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		values[key] = []byte(value)
+	}
+	return values, rows.Err()
+}
+
+// topEntries возвращает limit записей, начиная с места offset+1, среди игроков,
+// прошедших не меньше minAttempts викторин
+func (ss *SQLiteStore) topEntries(offset, limit, minAttempts int) ([]LeaderboardEntry, error) {
+	return ss.queryEntries(`SELECT `+sqliteEntryColumns+` FROM leaderboard WHERE attempts >= ? `+sqliteRankOrder+` LIMIT ? OFFSET ?`,
+		minAttempts, max(limit, 0), max(offset, 0))
+}
+
+// countEntries возвращает число игроков, прошедших не меньше minAttempts викторин
+func (ss *SQLiteStore) countEntries(minAttempts int) (int, error) {
+	var count int
+	err := ss.db.QueryRow(`SELECT COUNT(*) FROM leaderboard WHERE attempts >= ?`, minAttempts).Scan(&count)
+	return count, err
+}
+
+// entryRank возвращает запись игрока и сколько игроков, прошедших не меньше minAttempts викторин,
+// стоят выше нее. nil - игрока нет в лидерборде
+func (ss *SQLiteStore) entryRank(userID int64, minAttempts int) (*LeaderboardEntry, int, error) {
+	var above int
+	entry, err := scanEntry(ss.db.QueryRow(`SELECT `+sqliteEntryColumns+`,
+			(SELECT COUNT(*) FROM leaderboard o WHERE o.attempts >= ? AND `+sqliteRanksAbove+`)
+		FROM leaderboard WHERE user_id = ?`, minAttempts, userID), &above)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	return &entry, above, nil
+}
+
+// findEntries ищет записи по username или имени без учета регистра и возвращает их по порядку мест
+func (ss *SQLiteStore) findEntries(query string) ([]RankedEntry, error) {
+	key := strings.ToLower(query)
+	rows, err := ss.db.Query(`SELECT `+sqliteEntryColumns+`,
+			(SELECT COUNT(*) FROM leaderboard o WHERE `+sqliteRanksAbove+`) + 1
+		FROM leaderboard WHERE username_key = ? OR first_name_key = ? `+sqliteRankOrder, key, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []RankedEntry
+	for rows.Next() {
+		var ranked RankedEntry
+		if ranked.Entry, err = scanEntry(rows, &ranked.Position); err != nil {
+			return nil, err
+		}
+		found = append(found, ranked)
+	}
+	return found, rows.Err()
+}
+
+// updateEntry читает запись игрока и сохраняет то, что вернет update, в одной транзакции
+func (ss *SQLiteStore) updateEntry(userID int64, update func(entry *LeaderboardEntry) *LeaderboardEntry) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existing *LeaderboardEntry
+	entry, err := scanEntry(tx.QueryRow(`SELECT `+sqliteEntryColumns+` FROM leaderboard WHERE user_id = ?`, userID))
+	switch {
+	case err == nil:
+		existing = &entry
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+
+	updated := update(existing)
+	if updated == nil {
+		return nil
+	}
+	if err := saveEntry(tx, *updated); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
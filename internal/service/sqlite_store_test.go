@@ -0,0 +1,267 @@
+package service
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newSQLiteLeaderboard(t *testing.T) (*StoreLeaderboardService, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "leaderboard.db")
+	ls, err := NewSQLiteLeaderboardService(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ls.Store().(*SQLiteStore).Close() })
+	return ls, path
+}
+
+func TestSQLiteLeaderboard(t *testing.T) {
+	ls, path := newSQLiteLeaderboard(t)
+
+	// Первая запись игрока - новый лучший результат
+	improved, err := ls.AddEntry(1, "alice", "Alice", 6, 10, 0, 0)
+	if err != nil || !improved {
+		t.Fatalf("first AddEntry = %v, %v, want a new best", improved, err)
+	}
+	addResults(t, ls, 2, "bob", 8, 10, 1)
+	addResults(t, ls, 3, "carol", 7, 10, 1)
+
+	// Худший результат не заменяет лучший, но считается попыткой
+	if improved, err := ls.AddEntry(1, "alice", "Alice", 2, 10, 0, 0); err != nil || improved {
+		t.Errorf("worse AddEntry = %v, %v, want no new best", improved, err)
+	}
+	// Лучший результат заменяет запись
+	if improved, err := ls.AddEntry(1, "alice", "Alice", 9, 10, 0, 0); err != nil || !improved {
+		t.Errorf("better AddEntry = %v, %v, want a new best", improved, err)
+	}
+
+	top, err := ls.GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := userIDs(top), []int64{1, 2, 3}; !equalIDs(got, want) {
+		t.Errorf("GetTop = %v, want %v", got, want)
+	}
+	if top[0].Score != 9 || top[0].Attempts != 3 {
+		t.Errorf("alice = score %d, attempts %d, want 9 and 3", top[0].Score, top[0].Attempts)
+	}
+	if ls.Backend() != "sqlite" {
+		t.Errorf("Backend = %q, want sqlite", ls.Backend())
+	}
+
+	// Записи хранятся в таблице leaderboard с ключом user_id и переживают переоткрытие базы
+	store := ls.Store().(*SQLiteStore)
+	var rows int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM leaderboard WHERE user_id IN (1, 2, 3)`).Scan(&rows); err != nil || rows != 3 {
+		t.Errorf("leaderboard table has %d rows (err %v), want 3", rows, err)
+	}
+	store.Close()
+
+	reopened, err := NewSQLiteLeaderboardService(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Store().(*SQLiteStore).Close()
+	if count, err := reopened.Count(); err != nil || count != 3 {
+		t.Errorf("Count after reopening = %d, %v, want 3", count, err)
+	}
+}
+
+func TestNewLeaderboardServiceSelectsSQLite(t *testing.T) {
+	t.Setenv("GITHUB_GIST_ID", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("LEADERBOARD_FILE", filepath.Join(t.TempDir(), "leaderboard.json"))
+	t.Setenv("LEADERBOARD_SQLITE_PATH", filepath.Join(t.TempDir(), "leaderboard.db"))
+
+	ls, err := NewLeaderboardService(LeaderboardOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ls.Store().(*SQLiteStore).Close()
+	if ls.Backend() != "sqlite" {
+		t.Errorf("Backend = %q, want sqlite to take precedence over the file", ls.Backend())
+	}
+
+	t.Setenv("LEADERBOARD_SQLITE_PATH", filepath.Join(t.TempDir(), "missing", "leaderboard.db"))
+	if _, err := NewLeaderboardService(LeaderboardOptions{}); err == nil {
+		t.Error("unopenable database did not fail")
+	}
+}
+
+// TestSQLiteLeaderboardQueries проверяет, что места, страницы и поиск, посчитанные запросами SQL,
+// совпадают с сортировкой всех записей в памяти
+func TestSQLiteLeaderboardQueries(t *testing.T) {
+	sqlite, _ := newSQLiteLeaderboard(t)
+	memory := NewMemoryLeaderboardService()
+	for _, ls := range []LeaderboardService{sqlite, memory} {
+		addResults(t, ls, 1, "alice", 9, 10, 2)
+		addResults(t, ls, 2, "bob", 7, 10, 3)
+		addResults(t, ls, 3, "carol", 10, 10, 1)
+		addResults(t, ls, 4, "Alice", 18, 20, 2) // та же доля, что у alice, но больше очков
+		addResults(t, ls, 5, "dave", 2, 3, 2)
+		addResults(t, ls, 6, "eve", 4, 6, 2) // та же доля и очки, что у dave - выше меньший user_id
+	}
+
+	same := func(name string, call func(ls LeaderboardService) (any, error)) {
+		t.Helper()
+		want, err := call(memory)
+		if err != nil {
+			t.Fatalf("%s in memory: %v", name, err)
+		}
+		got, err := call(sqlite)
+		if err != nil {
+			t.Fatalf("%s in sqlite: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s in sqlite = %+v, want %+v", name, got, want)
+		}
+	}
+	// Время записи в SQLite хранится без монотонных часов, поэтому записи сравниваются без него
+	withoutTime := func(entries []LeaderboardEntry) []LeaderboardEntry {
+		for i := range entries {
+			entries[i].CreatedAt = time.Time{}
+		}
+		return entries
+	}
+
+	for _, minAttempts := range []int{0, 2} {
+		for _, offset := range []int{0, 2, 10} {
+			same("GetTopWithPosition", func(ls LeaderboardService) (any, error) {
+				page, err := ls.GetTopWithPosition(offset, 3, minAttempts, 6)
+				page.Entries = withoutTime(page.Entries)
+				return page, err
+			})
+		}
+		same("GetTopFiltered", func(ls LeaderboardService) (any, error) {
+			top, err := ls.GetTopFiltered(10, minAttempts)
+			return withoutTime(top), err
+		})
+		for _, userID := range []int64{1, 3, 6, 42} {
+			same("GetUserRank", func(ls LeaderboardService) (any, error) {
+				position, players, err := ls.GetUserRank(userID, minAttempts)
+				return [2]int{position, players}, err
+			})
+		}
+	}
+	for _, userID := range []int64{4, 42} {
+		same("GetUserPosition", func(ls LeaderboardService) (any, error) {
+			position, _, err := ls.GetUserPosition(userID)
+			return position, err
+		})
+		same("GetUserStats", func(ls LeaderboardService) (any, error) {
+			stats, found, err := ls.GetUserStats(userID)
+			stats.Best.CreatedAt = time.Time{}
+			return []any{stats, found}, err
+		})
+	}
+	for _, query := range []string{"ALICE", "@bob", " dave ", "nobody", ""} {
+		same("FindByUsername", func(ls LeaderboardService) (any, error) {
+			found, err := ls.FindByUsername(query)
+			for i := range found {
+				found[i].Entry.CreatedAt = time.Time{}
+			}
+			return found, err
+		})
+	}
+	same("Count", func(ls LeaderboardService) (any, error) { return ls.Count() })
+
+	// Места считаются по индексу в порядке мест, а поиск - по индексам имен
+	store := sqlite.Store().(*SQLiteStore)
+	for _, index := range []string{"leaderboard_rank", "leaderboard_username", "leaderboard_first_name"} {
+		var name string
+		if err := store.db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&name); err != nil {
+			t.Errorf("index %s: %v", index, err)
+		}
+	}
+}
+
+func TestSQLiteMigratesJSONLeaderboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leaderboard.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE leaderboard (user_id INTEGER PRIMARY KEY, entry TEXT NOT NULL);
+		INSERT INTO leaderboard VALUES
+			(1, '{"user_id":1,"username":"alice","score":6,"total":10,"percentage":60,"date":"01.10.2026 12:00","attempts":3}'),
+			(2, '{"user_id":2,"first_name":"Боб","score":8,"total":10,"percentage":80,"attempts":1}');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ls := NewStoreLeaderboardService(store, "sqlite")
+
+	top, err := ls.GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := userIDs(top), []int64{2, 1}; !equalIDs(got, want) {
+		t.Fatalf("GetTop after migration = %v, want %v", got, want)
+	}
+	alice := top[1]
+	if alice.Username != "alice" || alice.Attempts != 3 || alice.Date != "01.10.2026 12:00" || alice.CreatedAt.IsZero() {
+		t.Errorf("migrated entry = %+v", alice)
+	}
+	if found, err := ls.FindByUsername("боб"); err != nil || len(found) != 1 || found[0].Position != 1 {
+		t.Errorf("FindByUsername after migration = %+v, %v", found, err)
+	}
+
+	// Повторное открытие уже перенесенной базы ничего не меняет
+	store.Close()
+	reopened, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if count, err := NewStoreLeaderboardService(reopened, "sqlite").Count(); err != nil || count != 2 {
+		t.Errorf("Count after reopening = %d, %v, want 2", count, err)
+	}
+}
+
+func TestSQLiteConcurrentAddEntryKeepsBest(t *testing.T) {
+	first, path := newSQLiteLeaderboard(t)
+	// Второй сервис над той же базой - как второй процесс бота: общего мьютекса у них нет
+	second, err := NewSQLiteLeaderboardService(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Store().(*SQLiteStore).Close()
+
+	const rounds = 20
+	var wg sync.WaitGroup
+	for i := range rounds {
+		for _, ls := range []*StoreLeaderboardService{first, second} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				score := 1
+				if i == rounds/2 && ls == second {
+					score = 9
+				}
+				if _, err := ls.AddEntry(1, "alice", "Alice", score, 10, 0, time.Minute); err != nil {
+					t.Errorf("AddEntry: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	_, entry, err := first.GetUserPosition(1)
+	if err != nil || entry == nil {
+		t.Fatalf("GetUserPosition = %v, %v", entry, err)
+	}
+	if entry.Score != 9 || entry.Attempts != 2*rounds {
+		t.Errorf("entry = score %d, attempts %d, want 9 and %d", entry.Score, entry.Attempts, 2*rounds)
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Настройки и статистика живут в том же Store, что и лидерборд, поэтому переживают рестарт вместе с ним
+func TestStatsSurviveRestart(t *testing.T) {
+	for _, backend := range storeBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			store, reopen := backend.open(t)
+			if reopen == nil {
+				t.Skip("backend keeps data in memory only")
+			}
+
+			first := QuizQuestion{ID: 1, Question: "Свинина"}
+			second := QuizQuestion{ID: 2, Question: "Курица"}
+
+			answers := NewStoreAnswerStats(store, AnswerStatsNamespace, nil)
+			answers.Record(first, 2*time.Second)
+			answers.Record(first, 4*time.Second)
+			answers.Record(second, 10*time.Second)
+			NewStoreAnswerStats(store, PracticeStatsNamespace, nil).Record(first, time.Minute)
+
+			mistakes := NewStoreMistakeStats(store, nil)
+			mistakes.Record(42, first)
+			mistakes.Record(42, second)
+			mistakes.Record(42, second)
+
+			NewStorePreferencesService(store, nil).Set(-100, ChatPreferences{HideLeaderboard: true})
+
+			store = reopen()
+
+			times := NewStoreAnswerStats(store, AnswerStatsNamespace, nil).AverageTimes()
+			if len(times) != 2 || times[0].QuestionID != 2 || times[1].Average() != 3*time.Second {
+				t.Errorf("AverageTimes = %+v, want question 2 first and 3s for question 1", times)
+			}
+			practice := NewStoreAnswerStats(store, PracticeStatsNamespace, nil).AverageTimes()
+			if len(practice) != 1 || practice[0].Answers != 1 {
+				t.Errorf("practice stats = %+v, want one answer kept apart", practice)
+			}
+
+			top := NewStoreMistakeStats(store, nil).Top(42, 10)
+			if len(top) != 2 || top[0].QuestionID != 2 || top[0].Count != 2 || top[1].Count != 1 {
+				t.Errorf("Top = %+v, want question 2 twice, then question 1", top)
+			}
+			if other := NewStoreMistakeStats(store, nil).Top(7, 10); len(other) != 0 {
+				t.Errorf("Top of another user = %+v, want empty", other)
+			}
+
+			if !NewStorePreferencesService(store, nil).Get(-100).HideLeaderboard {
+				t.Error("chat preferences were lost")
+			}
+		})
+	}
+}
+
+func TestMistakesTopLimit(t *testing.T) {
+	mistakes := NewMistakeStats()
+	for id := 1; id <= 5; id++ {
+		for range id {
+			mistakes.Record(1, QuizQuestion{ID: id})
+		}
+	}
+
+	top := mistakes.Top(1, 3)
+	if len(top) != 3 || top[0].QuestionID != 5 || top[2].QuestionID != 3 {
+		t.Errorf("Top(3) = %+v, want questions 5, 4, 3", top)
+	}
+}
+
+// flakyStore - хранилище, запись в которое можно сломать
+type flakyStore struct {
+	Store
+	broken bool
+}
+
+func (s *flakyStore) Set(namespace, key string, value []byte) error {
+	if s.broken {
+		return errors.New("store unavailable")
+	}
+	return s.Store.Set(namespace, key, value)
+}
+
+func TestBatchedAnswerStats(t *testing.T) {
+	for _, backend := range storeBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			store, reopen := backend.open(t)
+			question := QuizQuestion{ID: 1, Question: "Свинина"}
+
+			stats := NewStoreAnswerStats(store, AnswerStatsNamespace, nil)
+			stats.EnableBatching()
+			stats.Record(question, 2*time.Second)
+
+			// До Flush ответ виден только через накопленные приращения
+			if persisted := NewStoreAnswerStats(store, AnswerStatsNamespace, nil).AverageTimes(); len(persisted) != 0 {
+				t.Fatalf("stats written before Flush: %+v", persisted)
+			}
+			if times := stats.AverageTimes(); len(times) != 1 || times[0].Answers != 1 {
+				t.Fatalf("AverageTimes = %+v, want the pending answer", times)
+			}
+
+			if err := stats.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			stats.Record(question, 4*time.Second)
+
+			// Чтение складывает сохраненное и еще не записанное
+			times := stats.AverageTimes()
+			if len(times) != 1 || times[0].Answers != 2 || times[0].Average() != 3*time.Second {
+				t.Fatalf("AverageTimes = %+v, want 2 answers averaging 3s", times)
+			}
+
+			if err := stats.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			// Повторный Flush без новых ответов ничего не удваивает
+			if err := stats.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if reopen != nil {
+				store = reopen()
+			}
+			times = NewStoreAnswerStats(store, AnswerStatsNamespace, nil).AverageTimes()
+			if len(times) != 1 || times[0].Answers != 2 || times[0].TotalTime != 6*time.Second {
+				t.Errorf("persisted stats = %+v, want 2 answers totalling 6s", times)
+			}
+		})
+	}
+}
+
+func TestFlushKeepsDeltasOnError(t *testing.T) {
+	store := &flakyStore{Store: NewMemoryStore()}
+	question := QuizQuestion{ID: 1, Question: "Свинина"}
+
+	stats := NewStoreAnswerStats(store, AnswerStatsNamespace, nil)
+	stats.EnableBatching()
+	stats.Record(question, time.Second)
+
+	store.broken = true
+	if err := stats.Flush(); err == nil {
+		t.Fatal("Flush to a broken store succeeded")
+	}
+	stats.Record(question, 3*time.Second)
+
+	store.broken = false
+	if err := stats.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	times := NewStoreAnswerStats(store, AnswerStatsNamespace, nil).AverageTimes()
+	if len(times) != 1 || times[0].Answers != 2 || times[0].TotalTime != 4*time.Second {
+		t.Errorf("persisted stats = %+v, want both answers after the retry", times)
+	}
+}
+
+func TestDailyStreak(t *testing.T) {
+	day := func(n int) time.Time {
+		// Время дня не важно, дни считаются по UTC
+		return time.Date(2026, time.October, n, 21, 30, 0, 0, time.UTC)
+	}
+	play := func(grace int, days ...int) DailyStreak {
+		var streak DailyStreak
+		for _, n := range days {
+			streak = streak.Played(day(n), grace)
+		}
+		return streak
+	}
+
+	tests := []struct {
+		name  string
+		grace int
+		days  []int
+		want  int
+	}{
+		{"consecutive days", 1, []int{1, 2, 3, 4}, 4},
+		{"twice a day", 1, []int{1, 1, 2}, 2},
+		{"single miss within grace", 1, []int{1, 2, 4, 5}, 4},
+		{"no grace", 0, []int{1, 2, 4}, 1},
+		{"two misses beyond grace", 1, []int{1, 2, 5}, 1},
+		{"two misses in a week", 1, []int{1, 3, 5}, 1},
+		{"grace renews after a week", 1, []int{1, 3, 4, 5, 6, 7, 8, 9, 11}, 9},
+		{"two skipped days allowed", 2, []int{1, 4, 5}, 3},
+	}
+	for _, tt := range tests {
+		if got := play(tt.grace, tt.days...).Days; got != tt.want {
+			t.Errorf("%s: Days = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	streak := play(1, 1, 2, 3)
+	if days, graceLeft := streak.Status(day(3), 1); days != 3 || graceLeft != 1 {
+		t.Errorf("same day: Status = %d, %d, want 3, 1", days, graceLeft)
+	}
+	// Вчерашний пропуск еще простится, если сыграть сегодня
+	if days, _ := streak.Status(day(5), 1); days != 3 {
+		t.Errorf("one missed day: Status = %d, want 3", days)
+	}
+	if days, _ := streak.Status(day(6), 1); days != 0 {
+		t.Errorf("two missed days: Status = %d, want the streak broken", days)
+	}
+
+	streak = play(1, 1, 3)
+	if _, graceLeft := streak.Status(day(3), 1); graceLeft != 0 {
+		t.Errorf("grace left after a miss = %d, want 0", graceLeft)
+	}
+	if _, graceLeft := streak.Status(day(9), 1); graceLeft != 1 {
+		t.Errorf("grace left a week later = %d, want 1", graceLeft)
+	}
+}
+
+func TestStreakStatsRecord(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	NewStoreStreakStats(store, nil).Record(7, now, 1)
+	NewStoreStreakStats(store, nil).Record(7, now.AddDate(0, 0, 2), 1)
+
+	streak := NewStoreStreakStats(store, nil).Get(7)
+	if streak.Days != 2 || len(streak.Skipped) != 1 {
+		t.Errorf("stored streak = %+v, want 2 days with one forgiven miss", streak)
+	}
+	if other := NewStoreStreakStats(store, nil).Get(8); other.Days != 0 {
+		t.Errorf("streak of another user = %+v, want empty", other)
+	}
+}
+
+func TestRecordAllWritesOncePerUser(t *testing.T) {
+	store := &countingStore{Store: NewMemoryStore()}
+	mistakes := NewStoreMistakeStats(store, nil)
+	mistakes.Record(42, QuizQuestion{ID: 1, Question: "Свинина"})
+	store.sets = 0
+
+	mistakes.RecordAll([]PendingMistake{
+		{UserID: 42, QuestionID: 1, Question: "Свинина"},
+		{UserID: 7, QuestionID: 2, Question: "Курица"},
+		{UserID: 42, QuestionID: 2, Question: "Курица"},
+		{UserID: 42, QuestionID: 1, Question: "Свинина"},
+	})
+	if store.sets != 2 {
+		t.Errorf("RecordAll wrote %d times, want once per user", store.sets)
+	}
+
+	top := mistakes.Top(42, 10)
+	if len(top) != 2 || top[0].QuestionID != 1 || top[0].Count != 3 || top[1].Count != 1 {
+		t.Errorf("Top(42) = %+v, want question 1 three times, then question 2", top)
+	}
+	if top := mistakes.Top(7, 10); len(top) != 1 || top[0].QuestionID != 2 {
+		t.Errorf("Top(7) = %+v, want question 2", top)
+	}
+
+	mistakes.RecordAll(nil)
+	if store.sets != 2 {
+		t.Errorf("empty RecordAll wrote to the store")
+	}
+}
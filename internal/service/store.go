@@ -0,0 +1,356 @@
+package service
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// ErrNotFound - ключа нет в хранилище
+var ErrNotFound = errors.New("not found")
+
+// Store - простое key-value хранилище с пространствами имен, на котором построены
+// лидерборд, настройки чатов и статистика ответов. Значения - JSON, чтобы любое хранилище могло держать
+// пространство имен одним JSON-документом. Новый бэкенд (например, Redis) - это одна реализация Store
+type Store interface {
+	Get(namespace, key string) ([]byte, error)
+	Set(namespace, key string, value []byte) error
+	Delete(namespace, key string) error
+	List(namespace string) (map[string][]byte, error)
+}
+
+// BatchStore - Store, который умеет записать несколько ключей пространства имен одной операцией.
+// Файл и Gist перезаписывают документ целиком, поэтому для них это одна запись вместо многих
+type BatchStore interface {
+	Store
+	SetMany(namespace string, values map[string][]byte) error
+}
+
+// setMany записывает values одной операцией, если store это умеет, иначе по одному ключу
+func setMany(store Store, namespace string, values map[string][]byte) error {
+	if batch, ok := store.(BatchStore); ok {
+		return batch.SetMany(namespace, values)
+	}
+	for key, value := range values {
+		if err := store.Set(namespace, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryStore хранит данные в памяти, они теряются при рестарте
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: make(map[string]map[string][]byte),
+	}
+}
+
+func (ms *MemoryStore) Get(namespace, key string) ([]byte, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	value, exists := ms.data[namespace][key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (ms *MemoryStore) Set(namespace, key string, value []byte) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.data[namespace] == nil {
+		ms.data[namespace] = make(map[string][]byte)
+	}
+	ms.data[namespace][key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (ms *MemoryStore) Delete(namespace, key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.data[namespace], key)
+	return nil
+}
+
+func (ms *MemoryStore) List(namespace string) (map[string][]byte, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	values := make(map[string][]byte, len(ms.data[namespace]))
+	for key, value := range ms.data[namespace] {
+		values[key] = append([]byte(nil), value...)
+	}
+	return values, nil
+}
+
+// arrayNamespaces - пространства имен, которые файл и Gist хранят JSON-массивом значений, а не
+// объектом {ключ: значение}: так leaderboard.json остается массивом записей, как до появления Store,
+// и его читают прежние версии бота. Значение - поле записи, в котором лежит ее ключ
+var arrayNamespaces = map[string]string{leaderboardNamespace: "user_id"}
+
+// documentStore реализует Store поверх хранилища, которое умеет только читать и записывать
+// пространство имен целиком как один JSON-документ (файл, Gist), см. encodeDocument
+type documentStore struct {
+	mu     sync.Mutex
+	load   func(namespace string) (map[string]json.RawMessage, error)
+	save   func(namespace string, doc map[string]json.RawMessage) error
+	logger *slog.Logger
+}
+
+// SetLogger задает логгер хранилища, nil - slog.Default()
+func (ds *documentStore) SetLogger(logger *slog.Logger) {
+	ds.logger = logger
+}
+
+// log возвращает логгер хранилища
+func (ds *documentStore) log() *slog.Logger {
+	if ds.logger == nil {
+		return slog.Default()
+	}
+	return ds.logger
+}
+
+func (ds *documentStore) Get(namespace, key string) ([]byte, error) {
+	doc, err := ds.load(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	value, exists := doc[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	// Документ может быть закэширован (Gist): вызывающий код не должен менять его значения
+	return append([]byte(nil), value...), nil
+}
+
+func (ds *documentStore) Set(namespace, key string, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("value for %s/%s is not valid JSON", namespace, key)
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	doc, err := ds.load(namespace)
+	if err != nil {
+		return err
+	}
+	doc[key] = append(json.RawMessage(nil), value...)
+	return ds.save(namespace, doc)
+}
+
+func (ds *documentStore) SetMany(namespace string, values map[string][]byte) error {
+	for key, value := range values {
+		if !json.Valid(value) {
+			return fmt.Errorf("value for %s/%s is not valid JSON", namespace, key)
+		}
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	doc, err := ds.load(namespace)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		doc[key] = append(json.RawMessage(nil), value...)
+	}
+	return ds.save(namespace, doc)
+}
+
+func (ds *documentStore) Delete(namespace, key string) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	doc, err := ds.load(namespace)
+	if err != nil {
+		return err
+	}
+	if _, exists := doc[key]; !exists {
+		return nil
+	}
+	delete(doc, key)
+	return ds.save(namespace, doc)
+}
+
+func (ds *documentStore) List(namespace string) (map[string][]byte, error) {
+	doc, err := ds.load(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]byte, len(doc))
+	for key, value := range doc {
+		values[key] = append([]byte(nil), value...)
+	}
+	return values, nil
+}
+
+// FileStore хранит каждое пространство имен в файле <dir>/<namespace>.json
+type FileStore struct {
+	documentStore
+	dir   string
+	files map[string]string // пути к файлам пространств имен, хранящихся не в <dir>/<namespace>.json
+}
+
+func NewFileStore(dir string) *FileStore {
+	fs := &FileStore{dir: dir, files: make(map[string]string)}
+	fs.load = fs.loadFile
+	fs.save = fs.saveFile
+	return fs
+}
+
+func (fs *FileStore) path(namespace string) string {
+	if path, exists := fs.files[namespace]; exists {
+		return path
+	}
+	return filepath.Join(fs.dir, namespace+".json")
+}
+
+// loadFile читает файл пространства имен. Поврежденный файл откладывается в <файл>.corrupt,
+// и работа продолжается с пустыми данными, чтобы бот не перестал сохранять результаты
+func (fs *FileStore) loadFile(namespace string) (map[string]json.RawMessage, error) {
+	path := fs.path(namespace)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]json.RawMessage), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := decodeDocument(namespace, data)
+	if err != nil {
+		fs.log().Warn("store file is corrupt, moving it aside", "file", path, "moved_to", path+".corrupt", "err", err)
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return nil, err
+		}
+		return make(map[string]json.RawMessage), nil
+	}
+	return doc, nil
+}
+
+// saveFile записывает файл атомарно: во временный файл, затем переименование
+func (fs *FileStore) saveFile(namespace string, doc map[string]json.RawMessage) error {
+	data, err := encodeDocument(namespace, doc)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fs.path(namespace)), 0o755); err != nil {
+		return err
+	}
+
+	// Временный файл в той же папке, чтобы переименование было атомарным
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path(namespace)), namespace+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fs.path(namespace))
+}
+
+// encodeDocument записывает пространство имен: из arrayNamespaces - массивом значений по порядку
+// ключей (числовые ключи - по возрастанию числа), остальные - JSON-объектом {ключ: значение}
+func encodeDocument(namespace string, doc map[string]json.RawMessage) ([]byte, error) {
+	if _, isArray := arrayNamespaces[namespace]; !isArray {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compareKeys)
+
+	values := make([]json.RawMessage, len(keys))
+	for i, key := range keys {
+		values[i] = doc[key]
+	}
+	return json.MarshalIndent(values, "", "  ")
+}
+
+// compareKeys сравнивает ключи как числа, если оба - числа, иначе как строки
+func compareKeys(a, b string) int {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return cmp.Compare(a, b)
+}
+
+// decodeDocument разбирает документ пространства имен, записанный encodeDocument. Пустой документ -
+// пустое пространство. Пространства из arrayNamespaces принимаются и объектом: так их записывали
+// версии бота, хранившие все пространства объектами
+func decodeDocument(namespace string, data []byte) (map[string]json.RawMessage, error) {
+	doc := make(map[string]json.RawMessage)
+	if len(data) == 0 {
+		return doc, nil
+	}
+
+	keyField, isArray := arrayNamespaces[namespace]
+	var values []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err == nil {
+		if !isArray {
+			return nil, fmt.Errorf("namespace %s must be a JSON object, got an array", namespace)
+		}
+		for _, entry := range values {
+			key, err := documentKey(entry[keyField])
+			if err != nil {
+				return nil, fmt.Errorf("entry of %s without %s: %w", namespace, keyField, err)
+			}
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return nil, err
+			}
+			doc[key] = value
+		}
+		return doc, nil
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// documentKey превращает значение поля-ключа записи (число или строку) в ключ Store
+func documentKey(raw json.RawMessage) (string, error) {
+	var key string
+	if err := json.Unmarshal(raw, &key); err == nil {
+		return key, nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return "", err
+	}
+	return number.String(), nil
+}
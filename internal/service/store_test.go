@@ -0,0 +1,556 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeGist - HTTP-сервер, отвечающий на запросы GitHub Gist API вместо GitHub
+type fakeGist struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	files    map[string]string
+	requests int
+
+	// failures следующих запросов отвечают кодом failStatus
+	failures   int
+	failStatus int
+}
+
+func newFakeGist(t *testing.T) *fakeGist {
+	t.Helper()
+	fg := &fakeGist{files: make(map[string]string)}
+	fg.server = httptest.NewServer(http.HandlerFunc(fg.serve))
+	t.Cleanup(fg.server.Close)
+	return fg
+}
+
+func (fg *fakeGist) serve(w http.ResponseWriter, r *http.Request) {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	fg.requests++
+
+	if fg.failures > 0 {
+		fg.failures--
+		http.Error(w, http.StatusText(fg.failStatus), fg.failStatus)
+		return
+	}
+
+	type file struct {
+		Content string `json:"content"`
+	}
+	var gist struct {
+		Files map[string]file `json:"files"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		gist.Files = make(map[string]file, len(fg.files))
+		for name, content := range fg.files {
+			gist.Files[name] = file{Content: content}
+		}
+		_ = json.NewEncoder(w).Encode(gist)
+	case http.MethodPatch:
+		if err := json.NewDecoder(r.Body).Decode(&gist); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for name, f := range gist.Files {
+			fg.files[name] = f.Content
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+	}
+}
+
+// fail заставляет следующие n запросов отвечать кодом status
+func (fg *fakeGist) fail(n, status int) {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	fg.failures, fg.failStatus = n, status
+}
+
+// requestCount возвращает, сколько запросов получил Gist
+func (fg *fakeGist) requestCount() int {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	return fg.requests
+}
+
+// file возвращает содержимое файла Gist
+func (fg *fakeGist) file(name string) string {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	return fg.files[name]
+}
+
+// client - HTTP-клиент, который отправляет запросы к api.github.com в фейковый Gist
+func (fg *fakeGist) client() *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme = "http"
+		r.URL.Host = strings.TrimPrefix(fg.server.URL, "http://")
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// storeBackend создает хранилища одного бэкенда. reopen возвращает новое хранилище поверх тех же
+// данных, чтобы проверить, что они переживают рестарт; nil - бэкенд ничего не сохраняет
+type storeBackend struct {
+	name string
+	open func(t *testing.T) (store Store, reopen func() Store)
+}
+
+func storeBackends() []storeBackend {
+	return []storeBackend{
+		{
+			name: "memory",
+			open: func(t *testing.T) (Store, func() Store) {
+				return NewMemoryStore(), nil
+			},
+		},
+		{
+			name: "file",
+			open: func(t *testing.T) (Store, func() Store) {
+				dir := t.TempDir()
+				return NewFileStore(dir), func() Store { return NewFileStore(dir) }
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T) (Store, func() Store) {
+				path := filepath.Join(t.TempDir(), "bot.db")
+				open := func() Store {
+					store, err := NewSQLiteStore(path)
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Cleanup(func() { store.Close() })
+					return store
+				}
+				return open(), open
+			},
+		},
+		{
+			name: "gist",
+			open: func(t *testing.T) (Store, func() Store) {
+				fg := newFakeGist(t)
+				return NewGistStoreWithClient("gist-id", "token", time.Minute, fg.client()),
+					func() Store { return NewGistStoreWithClient("gist-id", "token", time.Minute, fg.client()) }
+			},
+		},
+	}
+}
+
+// TestStoreConformance проверяет одинаковое поведение всех реализаций Store
+func TestStoreConformance(t *testing.T) {
+	for _, backend := range storeBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			t.Run("missing key", func(t *testing.T) {
+				store, _ := backend.open(t)
+				if _, err := store.Get("ns", "missing"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get = %v, want ErrNotFound", err)
+				}
+				values, err := store.List("empty")
+				if err != nil || values == nil || len(values) != 0 {
+					t.Errorf("List(empty) = %v, %v, want an empty map", values, err)
+				}
+			})
+
+			t.Run("set, get and overwrite", func(t *testing.T) {
+				store, _ := backend.open(t)
+				mustSet(t, store, "ns", "a", `{"n":1}`)
+				mustGet(t, store, "ns", "a", `{"n":1}`)
+
+				mustSet(t, store, "ns", "a", `{"n":2}`)
+				mustGet(t, store, "ns", "a", `{"n":2}`)
+			})
+
+			t.Run("namespaces are separate", func(t *testing.T) {
+				store, _ := backend.open(t)
+				mustSet(t, store, "first", "key", `1`)
+				mustSet(t, store, "second", "key", `2`)
+				mustSet(t, store, "second", "other", `3`)
+
+				mustGet(t, store, "first", "key", `1`)
+				mustList(t, store, "first", map[string]string{"key": `1`})
+				mustList(t, store, "second", map[string]string{"key": `2`, "other": `3`})
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				store, _ := backend.open(t)
+				mustSet(t, store, "ns", "a", `1`)
+				mustSet(t, store, "ns", "b", `2`)
+
+				if err := store.Delete("ns", "a"); err != nil {
+					t.Fatal(err)
+				}
+				if err := store.Delete("ns", "missing"); err != nil {
+					t.Errorf("Delete(missing) = %v, want nil", err)
+				}
+				if _, err := store.Get("ns", "a"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+				}
+				mustList(t, store, "ns", map[string]string{"b": `2`})
+			})
+
+			t.Run("values are copies", func(t *testing.T) {
+				store, _ := backend.open(t)
+				value := []byte(`"abc"`)
+				if err := store.Set("ns", "a", value); err != nil {
+					t.Fatal(err)
+				}
+				value[1] = 'x'
+
+				got, err := store.Get("ns", "a")
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[1] = 'y'
+				mustGet(t, store, "ns", "a", `"abc"`)
+			})
+
+			// SQLite хранит записи лидерборда по столбцам, поэтому они сравниваются целиком
+			t.Run("leaderboard entries", func(t *testing.T) {
+				store, _ := backend.open(t)
+				first := entryJSON(t, LeaderboardEntry{UserID: 10, Username: "alice", Score: 3, Total: 10, Percentage: 30, Attempts: 2})
+				second := entryJSON(t, LeaderboardEntry{UserID: 2, FirstName: "Боб", Score: 5, Total: 10, Percentage: 50, Attempts: 1,
+					Date: "01.10.2026 12:00", CreatedAt: time.Date(2026, time.October, 1, 9, 0, 0, 0, time.UTC)})
+				mustSet(t, store, leaderboardNamespace, "10", first)
+				mustSet(t, store, leaderboardNamespace, "2", second)
+				mustList(t, store, leaderboardNamespace, map[string]string{"10": first, "2": second})
+			})
+
+			t.Run("survives a restart", func(t *testing.T) {
+				store, reopen := backend.open(t)
+				if reopen == nil {
+					t.Skip("backend keeps data in memory only")
+				}
+				mustSet(t, store, "ns", "a", `{"n":1}`)
+				entry := entryJSON(t, LeaderboardEntry{UserID: 7, Score: 1, Total: 2, Percentage: 50})
+				mustSet(t, store, leaderboardNamespace, "7", entry)
+
+				reopened := reopen()
+				mustGet(t, reopened, "ns", "a", `{"n":1}`)
+				mustGet(t, reopened, leaderboardNamespace, "7", entry)
+			})
+		})
+	}
+}
+
+// entryJSON - запись лидерборда в том виде, в каком ее хранит Store
+func entryJSON(t *testing.T, entry LeaderboardEntry) string {
+	t.Helper()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func mustSet(t *testing.T, store Store, namespace, key, value string) {
+	t.Helper()
+	if err := store.Set(namespace, key, []byte(value)); err != nil {
+		t.Fatalf("Set(%s, %s): %v", namespace, key, err)
+	}
+}
+
+func mustGet(t *testing.T, store Store, namespace, key, want string) {
+	t.Helper()
+	got, err := store.Get(namespace, key)
+	if err != nil {
+		t.Fatalf("Get(%s, %s): %v", namespace, key, err)
+	}
+	if !jsonEqual(got, []byte(want)) {
+		t.Errorf("Get(%s, %s) = %s, want %s", namespace, key, got, want)
+	}
+}
+
+func mustList(t *testing.T, store Store, namespace string, want map[string]string) {
+	t.Helper()
+	got, err := store.List(namespace)
+	if err != nil {
+		t.Fatalf("List(%s): %v", namespace, err)
+	}
+	if !maps.EqualFunc(got, want, func(a []byte, b string) bool { return jsonEqual(a, []byte(b)) }) {
+		t.Errorf("List(%s) = %s, want %v", namespace, got, want)
+	}
+}
+
+// jsonEqual сравнивает JSON без учета форматирования: файл и Gist хранят значения с отступами
+func jsonEqual(a, b []byte) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	left, _ := json.Marshal(x)
+	right, _ := json.Marshal(y)
+	return string(left) == string(right)
+}
+
+// leaderboardArray проверяет, что лидерборд записан массивом записей по возрастанию user_id
+func leaderboardArray(t *testing.T, data []byte) {
+	t.Helper()
+	var entries []LeaderboardEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("leaderboard is not a JSON array: %v\n%s", err, data)
+	}
+	if len(entries) != 2 || entries[0].UserID != 2 || entries[1].UserID != 10 {
+		t.Errorf("entries = %+v, want users 2 and 10", entries)
+	}
+}
+
+func TestFileStoreKeepsLeaderboardArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leaderboard.json")
+	ls := NewFileLeaderboardService(path)
+	addResults(t, ls, 10, "ten", 3, 5, 1)
+	addResults(t, ls, 2, "two", 4, 5, 1)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderboardArray(t, data)
+}
+
+func TestGistStoreKeepsLeaderboardArray(t *testing.T) {
+	fg := newFakeGist(t)
+	ls := NewGistLeaderboardServiceWithClient("gist-id", "token", 0, fg.client())
+	addResults(t, ls, 10, "ten", 3, 5, 1)
+	addResults(t, ls, 2, "two", 4, 5, 1)
+
+	leaderboardArray(t, []byte(fg.file("leaderboard.json")))
+}
+
+// TestDecodeDocumentFormats читает документы в обоих форматах, которые мог записать бот
+func TestDecodeDocumentFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		data      string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{"empty", leaderboardNamespace, "", map[string]string{}, false},
+		{"leaderboard array", leaderboardNamespace, `[{"user_id":1,"score":2}]`, map[string]string{"1": `{"user_id":1,"score":2}`}, false},
+		{"leaderboard object", leaderboardNamespace, `{"1":{"user_id":1}}`, map[string]string{"1": `{"user_id":1}`}, false},
+		{"object namespace", preferencesNamespace, `{"5":{"hide_leaderboard":true}}`, map[string]string{"5": `{"hide_leaderboard":true}`}, false},
+		{"array in object namespace", preferencesNamespace, `[{"user_id":1}]`, nil, true},
+		{"entry without key", leaderboardNamespace, `[{"score":1}]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := decodeDocument(tt.namespace, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make(map[string][]byte, len(doc))
+			for key, value := range doc {
+				got[key] = value
+			}
+			if !maps.EqualFunc(got, tt.want, func(a []byte, b string) bool { return jsonEqual(a, []byte(b)) }) {
+				t.Errorf("doc = %s, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGistCache(t *testing.T) {
+	fg := newFakeGist(t)
+	ls := NewGistLeaderboardServiceWithClient("gist-id", "token", time.Minute, fg.client())
+
+	// Один просмотр лидерборда - одно чтение Gist
+	for range 3 {
+		if _, err := ls.GetTop(10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := ls.GetUserPosition(1); err != nil {
+		t.Fatal(err)
+	}
+	if got := fg.requestCount(); got != 1 {
+		t.Errorf("%d requests for repeated reads within the TTL, want 1", got)
+	}
+
+	// Запись идет в Gist, а кэш обновляется сохраненным документом
+	if _, err := ls.AddEntry(1, "alice", "Alice", 5, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	top, err := ls.GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 {
+		t.Errorf("GetTop after AddEntry = %v, want the new entry", top)
+	}
+	if got := fg.requestCount(); got != 2 {
+		t.Errorf("%d requests after a write, want 1 read and 1 write", got)
+	}
+
+	// Без кэша каждое чтение идет в Gist
+	uncached := NewGistLeaderboardServiceWithClient("gist-id", "token", 0, fg.client())
+	before := fg.requestCount()
+	for range 2 {
+		if _, err := uncached.GetTop(10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fg.requestCount() - before; got != 2 {
+		t.Errorf("%d requests without a cache, want 2", got)
+	}
+
+	// Устаревший кэш перечитывается
+	expiring := NewGistLeaderboardServiceWithClient("gist-id", "token", 10*time.Millisecond, fg.client())
+	before = fg.requestCount()
+	if _, err := expiring.GetTop(10); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := expiring.GetTop(10); err != nil {
+		t.Fatal(err)
+	}
+	if got := fg.requestCount() - before; got != 2 {
+		t.Errorf("%d requests across an expired TTL, want 2", got)
+	}
+}
+
+func TestGistClient(t *testing.T) {
+	if got := NewGistStore("gist-id", "token", 0).httpClient.Timeout; got != gistRequestTimeout {
+		t.Errorf("default client timeout = %v, want %v", got, gistRequestTimeout)
+	}
+
+	// Запросы идут через переданный клиент с токеном в заголовке
+	fg := newFakeGist(t)
+	var auth []string
+	var mu sync.Mutex
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		return fg.client().Transport.RoundTrip(r)
+	})}
+
+	ls := NewGistLeaderboardServiceWithClient("gist-id", "secret", 0, client)
+	if _, err := ls.AddEntry(1, "alice", "Alice", 7, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fg.file("leaderboard.json"), `"alice"`) {
+		t.Errorf("leaderboard.json = %s, want the saved entry", fg.file("leaderboard.json"))
+	}
+	if len(auth) < 2 || slices.ContainsFunc(auth, func(header string) bool { return header != "token secret" }) {
+		t.Errorf("Authorization headers = %q, want the token on every read and write", auth)
+	}
+
+	top, err := NewGistLeaderboardServiceWithClient("gist-id", "secret", 0, client).GetTop(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].Score != 7 {
+		t.Errorf("GetTop from a new service = %v, want the saved entry", top)
+	}
+}
+
+func TestGistRetry(t *testing.T) {
+	fast := RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond}
+	newStore := func(client *http.Client, logs io.Writer) *GistStore {
+		store := NewGistStoreWithClient("gist-id", "token", 0, client)
+		store.SetLogger(slog.New(slog.NewTextHandler(logs, nil)))
+		store.SetRetryPolicy(fast)
+		return store
+	}
+
+	t.Run("503 then 200", func(t *testing.T) {
+		fg := newFakeGist(t)
+		var logs bytes.Buffer
+		store := newStore(fg.client(), &logs)
+
+		fg.fail(2, http.StatusServiceUnavailable)
+		if err := store.Set("leaderboard", "1", json.RawMessage(`{"user_id":1}`)); err != nil {
+			t.Fatalf("Set after two 503: %v", err)
+		}
+		if !strings.Contains(fg.file("leaderboard.json"), `"user_id": 1`) {
+			t.Errorf("leaderboard.json = %s, want the saved entry", fg.file("leaderboard.json"))
+		}
+
+		before := fg.requestCount()
+		fg.fail(1, http.StatusBadGateway)
+		if _, err := store.Get("leaderboard", "1"); err != nil {
+			t.Fatalf("Get after a 502: %v", err)
+		}
+		if got := fg.requestCount() - before; got != 2 {
+			t.Errorf("%d requests, want a failed one and a retry", got)
+		}
+		if got := strings.Count(logs.String(), "level=WARN msg=\"gist request failed, retrying\""); got != 3 {
+			t.Errorf("%d retry warnings, want 3:\n%s", got, logs.String())
+		}
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		fg := newFakeGist(t)
+		store := newStore(fg.client(), io.Discard)
+
+		fg.fail(10, http.StatusServiceUnavailable)
+		_, err := store.Get("leaderboard", "1")
+		if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+			t.Fatalf("err = %v, want failure after 3 attempts", err)
+		}
+		if got := fg.requestCount(); got != 3 {
+			t.Errorf("%d requests, want 3", got)
+		}
+	})
+
+	t.Run("4xx is not retried", func(t *testing.T) {
+		fg := newFakeGist(t)
+		store := newStore(fg.client(), io.Discard)
+
+		fg.fail(1, http.StatusNotFound)
+		if _, err := store.Get("leaderboard", "1"); err == nil {
+			t.Fatal("404 was not reported")
+		}
+		if got := fg.requestCount(); got != 1 {
+			t.Errorf("%d requests after a 404, want 1", got)
+		}
+	})
+
+	t.Run("network error", func(t *testing.T) {
+		fg := newFakeGist(t)
+		var calls atomic.Int32
+		client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if calls.Add(1) == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return fg.client().Transport.RoundTrip(r)
+		})}
+		store := newStore(client, io.Discard)
+
+		if err := store.Set("leaderboard", "1", json.RawMessage(`{"user_id":1}`)); err != nil {
+			t.Fatalf("Set after a network error: %v", err)
+		}
+		if calls.Load() < 2 {
+			t.Errorf("%d calls, want a retry after the network error", calls.Load())
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// streaksNamespace - пространство имен серий дней в Store, ключ - ID пользователя
+const streaksNamespace = "streaks"
+
+// streakDateLayout - формат дней серии, дни считаются по UTC, как и викторина дня
+const streakDateLayout = "2006-01-02"
+
+// StreakGraceWindow - за сколько последних дней считаются пропуски, прощенные серии
+const StreakGraceWindow = 7
+
+// DailyStreak - серия дней подряд, в которые пользователь проходил викторину.
+// Пропущенные дни не обрывают серию, пока их не больше grace за StreakGraceWindow дней
+type DailyStreak struct {
+	Days       int      `json:"days"`        // дней с викториной в серии
+	LastPlayed string   `json:"last_played"` // последний день с викториной
+	Skipped    []string `json:"skipped"`     // пропущенные дни, которые простили серии
+}
+
+// streakDay возвращает начало дня t по UTC
+func streakDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween возвращает, на сколько дней день to позже дня from
+func daysBetween(from, to time.Time) int {
+	return int(streakDay(to).Sub(streakDay(from)).Hours() / 24)
+}
+
+// graceUsed возвращает пропуски, прощенные за StreakGraceWindow дней до today
+func (s DailyStreak) graceUsed(today time.Time) []string {
+	var used []string
+	for _, skipped := range s.Skipped {
+		day, err := time.Parse(streakDateLayout, skipped)
+		if err == nil && daysBetween(day, today) < StreakGraceWindow {
+			used = append(used, skipped)
+		}
+	}
+	return used
+}
+
+// Played возвращает серию после викторины в день today при grace прощаемых пропусков в неделю
+func (s DailyStreak) Played(today time.Time, grace int) DailyStreak {
+	today = streakDay(today)
+	last, err := time.Parse(streakDateLayout, s.LastPlayed)
+	if err != nil || s.Days == 0 {
+		return DailyStreak{Days: 1, LastPlayed: today.Format(streakDateLayout)}
+	}
+
+	gap := daysBetween(last, today)
+	switch {
+	case gap <= 0:
+		return s
+	case gap == 1:
+		s.Days++
+	default:
+		used := s.graceUsed(today)
+		if len(used)+gap-1 > grace {
+			return DailyStreak{Days: 1, LastPlayed: today.Format(streakDateLayout)}
+		}
+		for day := last.AddDate(0, 0, 1); day.Before(today); day = day.AddDate(0, 0, 1) {
+			used = append(used, day.Format(streakDateLayout))
+		}
+		s.Days++
+		s.Skipped = used
+	}
+	s.LastPlayed = today.Format(streakDateLayout)
+	s.Skipped = s.graceUsed(today)
+	return s
+}
+
+// Status возвращает длину серии на день today (0 - серия оборвалась) и сколько пропусков
+// ей еще простится на этой неделе
+func (s DailyStreak) Status(today time.Time, grace int) (days, graceLeft int) {
+	graceLeft = max(grace-len(s.graceUsed(today)), 0)
+
+	last, err := time.Parse(streakDateLayout, s.LastPlayed)
+	if err != nil || s.Days == 0 {
+		return 0, graceLeft
+	}
+	// Сегодняшний день еще можно сыграть, поэтому пропущенными считаются только прошедшие дни
+	if missed := daysBetween(last, today) - 1; missed > graceLeft {
+		return 0, graceLeft
+	}
+	return s.Days, graceLeft
+}
+
+// StreakStats хранит серии дней пользователей в Store
+type StreakStats struct {
+	mu     sync.Mutex // Record читает и перезаписывает серию пользователя, это должно быть атомарно
+	store  Store
+	logger *slog.Logger
+}
+
+// NewStoreStreakStats хранит серии дней пользователей в store. logger == nil - slog.Default()
+func NewStoreStreakStats(store Store, logger *slog.Logger) *StreakStats {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &StreakStats{store: store, logger: logger}
+}
+
+// Get возвращает сохраненную серию пользователя, пустую - если он еще не играл
+func (s *StreakStats) Get(userID int64) DailyStreak {
+	var streak DailyStreak
+	value, err := s.store.Get(streaksNamespace, strconv.FormatInt(userID, 10))
+	if errors.Is(err, ErrNotFound) {
+		return streak
+	}
+	if err == nil {
+		err = json.Unmarshal(value, &streak)
+	}
+	if err != nil {
+		s.logger.Error("loading daily streak failed", "user_id", userID, "err", err)
+		return DailyStreak{}
+	}
+	return streak
+}
+
+// Record продлевает серию пользователя викториной в момент now и возвращает ее
+func (s *StreakStats) Record(userID int64, now time.Time, grace int) DailyStreak {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streak := s.Get(userID).Played(now, grace)
+
+	data, err := json.Marshal(streak)
+	if err == nil {
+		err = s.store.Set(streaksNamespace, strconv.FormatInt(userID, 10), data)
+	}
+	if err != nil {
+		s.logger.Error("saving daily streak failed", "user_id", userID, "err", err)
+	}
+	return streak
+}
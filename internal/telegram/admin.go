@@ -0,0 +1,329 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleShowQuestion выводит вопрос по ID в том виде, в котором он был загружен (только для админов)
+func (b *Bot) handleShowQuestion(chatID, userID int64, args string) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil {
+		b.sendMessage(chatID, b.text(chatID, i18n.ShowQuestionUsage))
+		return
+	}
+
+	for _, question := range b.questions() {
+		if question.ID != id {
+			continue
+		}
+
+		text := b.text(chatID, i18n.ShowQuestionHeader, question.ID, question.Question)
+		for i, option := range question.Options {
+			marker := "  "
+			if question.IsCorrectOption(i) {
+				marker = "✅"
+			}
+			text += fmt.Sprintf("%s %d. %q\n", marker, i, option)
+		}
+		if question.Ordered() {
+			text += b.text(chatID, i18n.ShowQuestionOrder, question.CorrectText())
+		} else if question.Multi() {
+			text += b.text(chatID, i18n.ShowQuestionCorrectSet, question.CorrectText())
+		} else {
+			text += b.text(chatID, i18n.ShowQuestionCorrect, question.Correct)
+		}
+		if question.Category != "" {
+			text += b.text(chatID, i18n.ShowQuestionCategory, question.Category)
+		}
+		if len(question.Tags) > 0 {
+			text += b.text(chatID, i18n.ShowQuestionTags, strings.Join(question.Tags, ", "))
+		}
+		if question.Difficulty > 0 {
+			text += b.text(chatID, i18n.ShowQuestionDifficulty, question.Difficulty)
+		}
+		if question.Explanation != "" {
+			text += b.text(chatID, i18n.ShowQuestionExplanation, question.Explanation)
+		}
+		if question.Retired {
+			text += b.text(chatID, i18n.ShowQuestionRetired)
+		}
+
+		b.sendMessage(chatID, text)
+		return
+	}
+
+	b.sendMessage(chatID, b.text(chatID, i18n.QuestionNotFound, id))
+}
+
+// handleAnswerTimes показывает среднее время ответа на каждый вопрос (только для админов)
+func (b *Bot) handleAnswerTimes(chatID, userID int64) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	stats := b.answerStats.AverageTimes()
+	if len(stats) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoAnswerTimes))
+		return
+	}
+
+	text := b.text(chatID, i18n.AnswerTimesHeader)
+	for _, s := range stats {
+		text += b.text(chatID, i18n.AnswerTimesLine,
+			s.QuestionID, s.Question, s.Average().Seconds(), s.Answers)
+	}
+
+	if err := b.sendLongMessage(tgbotapi.NewMessage(chatID, text)); err != nil {
+		b.logger.Error("sending answer times failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// handleReloadConfig перечитывает конфигурацию и применяет настройки, которые можно менять на лету.
+// Токен, файл вопросов и порог лидерборда остаются прежними до перезапуска (только для админов)
+func (b *Bot) handleReloadConfig(chatID, userID int64) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	newCfg, err := config.Load()
+	if err != nil {
+		b.sendMessage(chatID, b.text(chatID, i18n.ConfigReloadFailed, err))
+		return
+	}
+
+	b.configMu.Lock()
+	oldCfg := b.config
+	restartRequired := oldCfg.RestartRequired(newCfg)
+	newCfg.KeepRestartFields(oldCfg)
+	b.config = newCfg
+	b.configMu.Unlock()
+
+	b.api.Debug = newCfg.Debug
+	if b.logLevel != nil {
+		b.logLevel.Set(newCfg.Level())
+	}
+
+	text := b.text(chatID, i18n.ConfigReloaded,
+		newCfg.PercentPrecision, newCfg.MaxMessageLength, newCfg.HideCorrectAnswer,
+		newCfg.PassPercentage, newCfg.RandomQuizMin, newCfg.RandomQuizMax, newCfg.Debug, newCfg.Level())
+
+	if len(restartRequired) > 0 {
+		text += b.text(chatID, i18n.ConfigRestartRequired, strings.Join(restartRequired, ", "))
+	}
+	text += b.text(chatID, i18n.ConfigBackendNote)
+
+	b.sendMessage(chatID, text)
+}
+
+// handleReloadQuestions перечитывает файл вопросов без перезапуска бота. Уже идущие викторины
+// доигрываются со своими вопросами, при ошибке разбора остается прежний набор (только для админов)
+func (b *Bot) handleReloadQuestions(chatID, userID int64) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	filename := b.cfg().QuestionsFile
+	questions, err := service.ReloadQuizQuestions(filename)
+	if err != nil {
+		b.logger.Error("reloading questions failed", "chat_id", chatID, "file", filename, "err", err)
+		b.sendMessage(chatID, b.text(chatID, i18n.QuestionsReloadFailed, err))
+		return
+	}
+
+	b.questionsMu.Lock()
+	b.quizQuestions = questions
+	b.questionsMu.Unlock()
+
+	b.sendMessage(chatID, b.text(chatID, i18n.QuestionsReloaded, len(questions)))
+}
+
+// handleStatus показывает время работы бота, число активных викторин и тип хранилища (только для админов)
+func (b *Bot) handleStatus(chatID, userID int64) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	uptime := time.Since(b.startedAt).Round(time.Second)
+	questions := b.questions()
+	retired := len(questions) - len(service.ActiveQuestions(questions))
+
+	sessions := strconv.Itoa(b.sessionCount())
+	if maxSessions := b.cfg().MaxSessions; maxSessions > 0 {
+		sessions += "/" + strconv.Itoa(maxSessions)
+	}
+
+	b.sendMessage(chatID, b.text(chatID, i18n.StatusText,
+		uptime, sessions, len(questions), retired, b.leaderboardService.Backend()))
+}
+
+// handleCheckOptions проверяет, что у всех вопросов одинаковое количество вариантов ответа (только для админов)
+func (b *Bot) handleCheckOptions(chatID, userID int64) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	expected, mismatched := service.CheckOptionCounts(b.questions(), b.cfg().ExpectedOptionCount)
+	if len(mismatched) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.OptionsConsistent, expected))
+		return
+	}
+
+	text := b.text(chatID, i18n.OptionsMismatch, expected)
+	for _, question := range mismatched {
+		text += fmt.Sprintf("#%d %s - %d\n", question.ID, question.Question, len(question.Options))
+	}
+
+	if err := b.sendLongMessage(tgbotapi.NewMessage(chatID, text)); err != nil {
+		b.logger.Error("sending option check failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// defaultPreviewSize - сколько вопросов показывает /preview без аргумента
+const defaultPreviewSize = 10
+
+// handlePreview показывает пример викторины с правильными ответами, не создавая сессию (только для админов)
+func (b *Bot) handlePreview(chatID, userID int64, args string) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	count := defaultPreviewSize
+	if args = strings.TrimSpace(args); args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n <= 0 {
+			b.sendMessage(chatID, b.text(chatID, i18n.PreviewUsage))
+			return
+		}
+		count = n
+	}
+
+	pool := b.quizPool()
+	if len(pool) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoQuestions))
+		return
+	}
+
+	questions := b.selectQuestions(pool, count)
+	text := b.text(chatID, i18n.PreviewHeader, len(questions))
+	if count > len(pool) {
+		text += b.text(chatID, i18n.PreviewShortage, count, len(pool))
+	}
+	text += "\n"
+
+	for i, question := range questions {
+		text += fmt.Sprintf("%d. %s → %s\n", i+1, question.Question, question.CorrectText())
+	}
+
+	if err := b.sendLongMessage(tgbotapi.NewMessage(chatID, text)); err != nil {
+		b.logger.Error("sending preview failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// listQuestionWidth - сколько символов текста вопроса показывает /listq
+const listQuestionWidth = 40
+
+// handleListQuestions показывает страницу списка всех загруженных вопросов: ID, начало текста
+// и индекс правильного ответа. Номер страницы считается с 1. При messageID != 0
+// редактируется сообщение с предыдущей страницей (только для админов)
+func (b *Bot) handleListQuestions(chatID int64, messageID int, userID int64, args string) {
+	if !b.cfg().IsAdmin(userID) {
+		b.sendMessage(chatID, b.text(chatID, i18n.AdminOnly))
+		return
+	}
+
+	page := 1
+	if args = strings.TrimSpace(args); args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n <= 0 {
+			b.sendMessage(chatID, b.text(chatID, i18n.ListQuestionsUsage))
+			return
+		}
+		page = n
+	}
+
+	questions := b.questions()
+	if len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoQuestions))
+		return
+	}
+
+	perPage := b.cfg().QuestionsPerPage
+	pages := (len(questions) + perPage - 1) / perPage
+	if page > pages {
+		page = pages
+	}
+
+	start := (page - 1) * perPage
+	end := start + perPage
+	if end > len(questions) {
+		end = len(questions)
+	}
+
+	text := b.text(chatID, i18n.ListQuestionsHeader, page, pages, len(questions))
+	for _, question := range questions[start:end] {
+		answer := strconv.Itoa(question.Correct)
+		if question.Ordered() {
+			answer = strings.Trim(strings.Join(strings.Fields(fmt.Sprint(question.OrderedAnswer)), ","), "[]")
+		}
+		if question.Multi() {
+			answer = strings.Trim(strings.Join(strings.Fields(fmt.Sprint(question.CorrectSet)), "+"), "[]")
+		}
+		text += fmt.Sprintf("#%d %s → %s\n", question.ID, truncateText(question.Question, listQuestionWidth), answer)
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️", fmt.Sprintf("listq_page_%d", page-1)))
+	}
+	if page < pages {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("▶️", fmt.Sprintf("listq_page_%d", page+1)))
+	}
+
+	if messageID != 0 {
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		if len(nav) > 0 {
+			keyboard := tgbotapi.NewInlineKeyboardMarkup(nav)
+			edit.ReplyMarkup = &keyboard
+		}
+		if _, err := b.send(edit); err != nil {
+			b.logger.Error("editing question list failed", "chat_id", chatID, "err", err)
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if len(nav) > 0 {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(nav)
+	}
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending question list failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// truncateText обрезает текст до width символов, добавляя многоточие
+func truncateText(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
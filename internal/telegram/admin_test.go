@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+func TestReloadConfigSwapsLogLevel(t *testing.T) {
+	const admin = 7
+	t.Setenv("BOT_ADMINS", "7")
+	bot, ft, _ := newTestBot(t, testConfig(t))
+
+	level := new(slog.LevelVar)
+	level.Set(bot.cfg().Level())
+	bot.SetLogLevel(level)
+
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("QUIZ_ANSWER_DELAY_MS", "250")
+	t.Setenv("GIST_CACHE_TTL", "5")
+	t.Setenv("GIST_RETRY_ATTEMPTS", "9")
+	bot.handleReloadConfig(admin, admin)
+
+	if level.Level() != slog.LevelWarn {
+		t.Errorf("log level = %v, want WARN", level.Level())
+	}
+	if bot.cfg().AnswerDelayMs != 250 {
+		t.Errorf("AnswerDelayMs = %d, want 250", bot.cfg().AnswerDelayMs)
+	}
+	// Настройки Gist применяются только при запуске - бот продолжает работать со старыми
+	if bot.cfg().GistCacheTTL != 30 || bot.cfg().GistRetryAttempts != 3 {
+		t.Errorf("gist settings changed without restart: ttl=%d attempts=%d", bot.cfg().GistCacheTTL, bot.cfg().GistRetryAttempts)
+	}
+
+	texts := ft.texts(admin)
+	if len(texts) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(texts))
+	}
+	if !strings.Contains(texts[0], "gist_cache_ttl, gist_retry_attempts") {
+		t.Errorf("reply does not list settings requiring restart:\n%s", texts[0])
+	}
+}
+
+func TestReloadConfigRequiresAdmin(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	level := new(slog.LevelVar)
+	bot.SetLogLevel(level)
+
+	t.Setenv("LOG_LEVEL", "error")
+	bot.handleReloadConfig(5, 5)
+
+	if level.Level() != slog.LevelInfo {
+		t.Errorf("non-admin changed log level to %v", level.Level())
+	}
+	if texts := ft.texts(5); len(texts) != 1 || !strings.Contains(texts[0], "только администраторам") {
+		t.Errorf("reply = %q, want admin-only notice", texts)
+	}
+}
+
+func TestReloadQuestions(t *testing.T) {
+	const admin, player = 7, 8
+	t.Setenv("BOT_ADMINS", "7")
+	cfg := testConfig(t)
+	cfg.QuestionsFile = filepath.Join(t.TempDir(), "questions.json")
+	bot, ft, _ := newTestBot(t, cfg)
+
+	embedded, err := service.ParseEmbeddedQuestions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(cfg.QuestionsFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hasQuestion := func(text string) bool {
+		bot.questionsMu.RLock()
+		defer bot.questionsMu.RUnlock()
+		return slices.ContainsFunc(bot.quizQuestions, func(q service.QuizQuestion) bool { return q.Question == text })
+	}
+	lastReply := func() string {
+		texts := ft.texts(admin)
+		if len(texts) == 0 {
+			t.Fatal("no reply to /reload")
+		}
+		return texts[len(texts)-1]
+	}
+
+	write(`[{"id": 10001, "question": "Первая версия?", "options": ["Да", "Нет"], "correct": 0}]`)
+	bot.handleUpdate(textUpdate(1, admin, "/reload"))
+	if !hasQuestion("Первая версия?") {
+		t.Fatal("first version of the file was not loaded")
+	}
+	if want := fmt.Sprint(len(embedded) + 1); !strings.Contains(lastReply(), want) {
+		t.Errorf("reply %q does not report %s questions", lastReply(), want)
+	}
+
+	// Файл изменился - /reload подхватывает новую версию без перезапуска
+	write(`[
+		{"id": 10001, "question": "Вторая версия?", "options": ["Да", "Нет"], "correct": 1},
+		{"id": 10002, "question": "Новый вопрос?", "options": ["Да", "Нет"], "correct": 0}
+	]`)
+	bot.handleUpdate(textUpdate(2, admin, "/reload"))
+	if hasQuestion("Первая версия?") || !hasQuestion("Вторая версия?") || !hasQuestion("Новый вопрос?") {
+		t.Fatal("second version of the file was not loaded")
+	}
+	if want := fmt.Sprint(len(embedded) + 2); !strings.Contains(lastReply(), want) {
+		t.Errorf("reply %q does not report %s questions", lastReply(), want)
+	}
+
+	// Сломанный файл не заменяет загруженные вопросы
+	write(`[{"id": 10003, "question": "Без закрывающей скобки?"`)
+	bot.handleUpdate(textUpdate(3, admin, "/reload"))
+	if !hasQuestion("Вторая версия?") {
+		t.Error("broken file replaced the loaded questions")
+	}
+	if !strings.Contains(lastReply(), "Ошибка загрузки вопросов") {
+		t.Errorf("reply %q does not report the parse error", lastReply())
+	}
+
+	// Не админ не может перезагрузить вопросы
+	write(`[{"id": 10001, "question": "Третья версия?", "options": ["Да", "Нет"], "correct": 0}]`)
+	bot.handleUpdate(textUpdate(4, player, "/reload"))
+	if hasQuestion("Третья версия?") {
+		t.Error("non-admin reloaded the questions")
+	}
+	if texts := ft.texts(player); len(texts) != 1 || !strings.Contains(texts[0], "только администраторам") {
+		t.Errorf("reply = %q, want admin-only notice", texts)
+	}
+}
+
+func TestStatusCountsRetiredQuestions(t *testing.T) {
+	const admin = 7
+	t.Setenv("BOT_ADMINS", "7")
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	bot.quizQuestions = []service.QuizQuestion{
+		{ID: 1, Question: "?", Options: []string{"a", "b"}},
+		{ID: 2, Question: "?", Options: []string{"a", "b"}, Retired: true},
+		{ID: 3, Question: "?", Options: []string{"a", "b"}, Retired: true},
+	}
+
+	bot.handleStatus(admin, admin)
+
+	texts := ft.texts(admin)
+	if len(texts) != 1 || !strings.Contains(texts[0], "3 (устаревших: 2)") {
+		t.Errorf("status does not report retired questions: %q", texts)
+	}
+}
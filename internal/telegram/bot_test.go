@@ -0,0 +1,261 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// apiRequest - запрос бота к фейковому Telegram API
+type apiRequest struct {
+	Method string
+	Params url.Values
+}
+
+// fakeTelegram - HTTP-сервер, отвечающий на запросы Bot API вместо Telegram.
+// Запросы запоминаются, методы из fail отвечают ошибкой, а чаты из blocked - отказом 403,
+// как для пользователя, заблокировавшего бота
+type fakeTelegram struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	requests  []apiRequest
+	fail      map[string]bool
+	blocked   map[string]bool
+	messageID int
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	t.Helper()
+	ft := &fakeTelegram{fail: make(map[string]bool), blocked: make(map[string]bool)}
+	ft.server = httptest.NewServer(http.HandlerFunc(ft.serve))
+	t.Cleanup(ft.server.Close)
+	return ft
+}
+
+func (ft *fakeTelegram) serve(w http.ResponseWriter, r *http.Request) {
+	method := path.Base(r.URL.Path)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		_ = r.ParseMultipartForm(1 << 20)
+	} else {
+		_ = r.ParseForm()
+	}
+
+	ft.mu.Lock()
+	ft.requests = append(ft.requests, apiRequest{Method: method, Params: r.Form})
+	failed := ft.fail[method]
+	blocked := ft.blocked[r.Form.Get("chat_id")]
+	ft.messageID++
+	messageID := ft.messageID
+	ft.mu.Unlock()
+
+	if failed {
+		writeJSON(w, map[string]any{"ok": false, "error_code": 400, "description": "Bad Request: test failure"})
+		return
+	}
+	if blocked {
+		writeJSON(w, map[string]any{"ok": false, "error_code": 403, "description": "Forbidden: bot was blocked by the user"})
+		return
+	}
+
+	var result any = true
+	switch {
+	case method == "getMe":
+		result = map[string]any{"id": 1, "is_bot": true, "first_name": "Quiz", "username": "quiz_test_bot"}
+	case strings.HasPrefix(method, "send"), strings.HasPrefix(method, "edit"):
+		chatID := r.Form.Get("chat_id")
+		poll := ""
+		if method == "sendPoll" {
+			// ID опроса выводится из ID сообщения, чтобы тесты могли ответить на него
+			poll = `,"poll":{"id":"poll-` + strconv.Itoa(messageID) + `","question":"","options":[],"type":"quiz"}`
+		}
+		result = json.RawMessage(`{"message_id":` + strconv.Itoa(messageID) + `,"date":0,"chat":{"id":` + orZero(chatID) + `,"type":"private"}` + poll + `}`)
+	}
+	writeJSON(w, map[string]any{"ok": true, "result": result})
+}
+
+// roundTripFunc - HTTP-транспорт из функции, чтобы подменять ответы внешних API в тестах
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// failMethod заставляет метод API отвечать ошибкой
+func (ft *fakeTelegram) failMethod(method string) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.fail[method] = true
+}
+
+// blockChat заставляет запросы в чат chatID отвечать так, будто пользователь заблокировал бота
+func (ft *fakeTelegram) blockChat(chatID int64) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.blocked[strconv.FormatInt(chatID, 10)] = true
+}
+
+// sent возвращает запросы метода method в порядке отправки
+func (ft *fakeTelegram) sent(method string) []apiRequest {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	var requests []apiRequest
+	for _, request := range ft.requests {
+		if request.Method == method {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// texts возвращает тексты отправленных в чат chatID сообщений
+func (ft *fakeTelegram) texts(chatID int64) []string {
+	var texts []string
+	for _, request := range ft.sent("sendMessage") {
+		if request.Params.Get("chat_id") == strconv.FormatInt(chatID, 10) {
+			texts = append(texts, request.Params.Get("text"))
+		}
+	}
+	return texts
+}
+
+// testConfig загружает конфигурацию по умолчанию, как из пустого окружения
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "test-token")
+	t.Setenv("BOT_DEBUG", "false")
+	t.Setenv("QUIZ_ANSWER_DELAY_MS", "0")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	return cfg
+}
+
+// testQuestions - небольшой набор вопросов для тестов бота
+func testQuestions() []service.QuizQuestion {
+	return []service.QuizQuestion{
+		{ID: 1, Question: "2 + 2?", Options: []string{"3", "4", "5", "6"}, Correct: 1, Category: "Математика"},
+		{ID: 2, Question: "Столица Франции?", Options: []string{"Париж", "Рим", "Берлин", "Мадрид"}, Correct: 0, Category: "География"},
+		{ID: 3, Question: "3 * 3?", Options: []string{"6", "9", "12", "8"}, Correct: 1, Category: "Математика"},
+	}
+}
+
+// newTestBot создает бота, который ходит в фейковый Telegram API, без пауз между шагами викторины.
+// Записи логов собирает logs
+func newTestBot(t *testing.T, cfg *config.Config) (*Bot, *fakeTelegram, *logRecorder) {
+	t.Helper()
+	ft := newFakeTelegram(t)
+
+	api, err := tgbotapi.NewBotAPIWithClient(cfg.Token, ft.server.URL+"/bot%s/%s", ft.server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+
+	logs := &logRecorder{}
+	bot := newBot(cfg, api, service.NewMemoryLeaderboardService(), testQuestions(), slog.New(logs))
+	bot.sleep = func(time.Duration) {}
+	return bot, ft, logs
+}
+
+// logRecorder - slog.Handler, который запоминает все записи логов
+type logRecorder struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (lr *logRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (lr *logRecorder) Handle(_ context.Context, record slog.Record) error {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.records = append(lr.records, record.Clone())
+	return nil
+}
+
+func (lr *logRecorder) WithAttrs([]slog.Attr) slog.Handler { return lr }
+func (lr *logRecorder) WithGroup(string) slog.Handler      { return lr }
+
+// find возвращает первую запись уровня level с сообщением message
+func (lr *logRecorder) find(level slog.Level, message string) (slog.Record, bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	for _, record := range lr.records {
+		if record.Level == level && record.Message == message {
+			return record, true
+		}
+	}
+	return slog.Record{}, false
+}
+
+// recordAttr возвращает значение атрибута key записи
+func recordAttr(record slog.Record, key string) (slog.Value, bool) {
+	var value slog.Value
+	found := false
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == key {
+			value, found = attr.Value, true
+			return false
+		}
+		return true
+	})
+	return value, found
+}
+
+// textUpdate - обновление с сообщением text от пользователя userID в его личном чате
+func textUpdate(updateID int, userID int64, text string) tgbotapi.Update {
+	message := &tgbotapi.Message{
+		MessageID: updateID,
+		From:      &tgbotapi.User{ID: userID, FirstName: "Player", LanguageCode: "ru"},
+		Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command := strings.SplitN(text, " ", 2)[0]
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	return tgbotapi.Update{UpdateID: updateID, Message: message}
+}
+
+// callbackUpdate - нажатие пользователем userID кнопки data в его личном чате
+func callbackUpdate(updateID int, userID int64, data string) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: updateID,
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:   strconv.Itoa(updateID),
+			From: &tgbotapi.User{ID: userID, FirstName: "Player", LanguageCode: "ru"},
+			Message: &tgbotapi.Message{
+				MessageID: updateID,
+				Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+			},
+			Data: data,
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chooseCategory предлагает выбрать категорию вопросов перед викториной.
+// Если категорий в файле нет, викторина начинается сразу
+func (b *Bot) chooseCategory(chatID int64) {
+	categories := service.Categories(b.quizPool())
+	if len(categories) == 0 {
+		b.startQuiz(chatID, 0)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, b.text(chatID, i18n.ChooseCategory))
+	msg.ReplyMarkup = categoryKeyboard(categories, "category_", b.text(chatID, i18n.AllCategories))
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending categories failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// choosePracticeCategory предлагает выбрать категорию для тренировки.
+// Если категорий среди вопросов тренировки нет, тренировка начинается сразу по всем вопросам
+func (b *Bot) choosePracticeCategory(chatID int64) {
+	categories := service.Categories(b.practicePool())
+	if len(categories) == 0 {
+		b.startPractice(chatID, "")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, b.text(chatID, i18n.PracticeChooseCategory))
+	msg.ReplyMarkup = categoryKeyboard(categories, "practice_category_", b.text(chatID, i18n.PracticeAllCategories))
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending practice categories failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// categoryKeyboard - кнопки категорий с callback "<prefix><n>" и кнопка всех вопросов "<prefix>all".
+// В callback передается номер категории: названия могут не поместиться в 64 байта
+func categoryKeyboard(categories []string, prefix, allLabel string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, category := range categories {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(category, fmt.Sprintf("%s%d", prefix, i)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(allLabel, prefix+"all"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleCategories показывает категории с количеством вопросов в каждой в порядке из CategoriesSort
+func (b *Bot) handleCategories(chatID int64) {
+	questions := b.quizPool()
+	if len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoQuestions))
+		return
+	}
+
+	counts, uncategorized := service.CategoryCounts(questions, b.cfg().CategoriesSort == config.CategoriesSortCount)
+	if len(counts) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.CategoriesNone, uncategorized))
+		return
+	}
+
+	text := b.text(chatID, i18n.CategoriesTitle)
+	for _, count := range counts {
+		text += b.text(chatID, i18n.CategoriesItem, count.Category, count.Count)
+	}
+	if uncategorized > 0 {
+		text += b.text(chatID, i18n.CategoriesOther, uncategorized)
+	}
+	b.sendMessage(chatID, text)
+}
+
+// handleCategory запускает викторину по выбранной категории (callback "category_<n>" или "category_all")
+func (b *Bot) handleCategory(chatID int64, data string) {
+	arg := strings.TrimPrefix(data, "category_")
+	if arg == "all" {
+		b.startQuiz(chatID, 0)
+		return
+	}
+
+	categories := service.Categories(b.quizPool())
+	index, err := strconv.Atoi(arg)
+	if err != nil || index < 0 || index >= len(categories) {
+		// Список категорий мог измениться после перезагрузки вопросов
+		b.sendMessage(chatID, b.text(chatID, i18n.CategoryNotFound))
+		b.chooseCategory(chatID)
+		return
+	}
+
+	b.startCategoryQuiz(chatID, categories[index])
+}
+
+// startCategoryQuiz запускает викторину только из вопросов категории category.
+// Порядок вопросов может быть задан для категории отдельно
+func (b *Bot) startCategoryQuiz(chatID int64, category string) {
+	cfg := b.cfg()
+	questions := service.QuestionsInCategory(b.quizPool(), category)
+	b.beginQuiz(chatID, pickQuestions(questions, cfg.QuizQuestionCount, cfg.ShuffleCategory(category)))
+}
+
+// handlePracticeCategory запускает тренировку по выбранной категории
+// (callback "practice_category_<n>" или "practice_category_all")
+func (b *Bot) handlePracticeCategory(chatID int64, data string) {
+	arg := strings.TrimPrefix(data, "practice_category_")
+	if arg == "all" {
+		b.startPractice(chatID, "")
+		return
+	}
+
+	categories := service.Categories(b.practicePool())
+	index, err := strconv.Atoi(arg)
+	if err != nil || index < 0 || index >= len(categories) {
+		// Список категорий мог измениться после перезагрузки вопросов
+		b.sendMessage(chatID, b.text(chatID, i18n.CategoryNotFound))
+		b.choosePracticeCategory(chatID)
+		return
+	}
+
+	b.startPractice(chatID, categories[index])
+}
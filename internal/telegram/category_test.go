@@ -0,0 +1,240 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+func TestHandleCategories(t *testing.T) {
+	tests := []struct {
+		name      string
+		sort      string
+		questions []service.QuizQuestion
+		want      string
+	}{
+		{
+			name:      "by count",
+			sort:      config.CategoriesSortCount,
+			questions: append(testQuestions(), service.QuizQuestion{ID: 4, Question: "?", Options: []string{"a", "b"}}),
+			want:      "📂 Категории и число вопросов:\n\n• Математика: 2\n• География: 1\n• Без категории: 1",
+		},
+		{
+			name:      "by name",
+			sort:      config.CategoriesSortName,
+			questions: testQuestions(),
+			want:      "📂 Категории и число вопросов:\n\n• География: 1\n• Математика: 2",
+		},
+		{
+			name:      "no categories",
+			sort:      config.CategoriesSortCount,
+			questions: []service.QuizQuestion{{ID: 1, Question: "?", Options: []string{"a", "b"}}},
+			want:      "📂 Категорий пока нет: все вопросы (1) без категории. Начните викторину через /quiz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.CategoriesSort = tt.sort
+			bot, ft, _ := newTestBot(t, cfg)
+			bot.quizQuestions = tt.questions
+
+			bot.handleUpdate(textUpdate(1, 10, "/categories"))
+
+			texts := ft.texts(10)
+			if len(texts) != 1 || texts[0] != tt.want {
+				t.Errorf("reply = %q, want %q", texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestPracticeByCategory(t *testing.T) {
+	cfg := testConfig(t)
+	bot, ft, _ := newTestBot(t, cfg)
+	const chatID = 10
+
+	bot.handleUpdate(textUpdate(1, chatID, "/practice"))
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("practice started before a category was chosen")
+	}
+	requests := ft.sent("sendMessage")
+	if len(requests) == 0 || !strings.Contains(requests[len(requests)-1].Params.Get("reply_markup"), "practice_category_all") {
+		t.Fatal("category picker was not shown")
+	}
+
+	index := slices.Index(service.Categories(bot.practicePool()), "Математика")
+	bot.handleUpdate(callbackUpdate(2, chatID, fmt.Sprintf("practice_category_%d", index)))
+	session, exists := bot.getSession(chatID)
+	if !exists || !session.Practice || session.Category != "Математика" {
+		t.Fatalf("practice session = %+v, want practice in Математика", session)
+	}
+
+	// Два вопроса категории отвечаются по кругу несколько раз: новый круг берется из той же категории
+	for i := range 5 {
+		answerCurrent(bot, chatID, 10+i)
+		for _, question := range session.Questions {
+			if question.Category != "Математика" {
+				t.Fatalf("round %d has a question from %q", i, question.Category)
+			}
+		}
+	}
+	if session.Answered != 5 {
+		t.Errorf("Answered = %d, want 5", session.Answered)
+	}
+}
+
+func TestPracticeWithoutCategories(t *testing.T) {
+	cfg := testConfig(t)
+	bot, _, _ := newTestBot(t, cfg)
+	bot.quizQuestions = []service.QuizQuestion{{ID: 1, Question: "?", Options: []string{"a", "b"}}}
+
+	bot.handleUpdate(textUpdate(1, 10, "/practice"))
+	if session, exists := bot.getSession(10); !exists || session.Category != "" {
+		t.Error("practice without categories did not start right away")
+	}
+}
+
+// questionIDs возвращает ID вопросов сессии по порядку
+func questionIDs(session *service.QuizSession) []int {
+	ids := make([]int, len(session.Questions))
+	for i, question := range session.Questions {
+		ids[i] = question.ID
+	}
+	return ids
+}
+
+func TestQuestionOrder(t *testing.T) {
+	var questions []service.QuizQuestion
+	for id := 1; id <= 20; id++ {
+		category := "История"
+		if id > 10 {
+			category = "Наука"
+		}
+		questions = append(questions, service.QuizQuestion{ID: id, Question: fmt.Sprintf("Вопрос %d", id), Options: []string{"a", "b"}, Category: category})
+	}
+	fileOrder := func(from, to int) []int {
+		var ids []int
+		for id := from; id <= to; id++ {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		shuffle  bool
+		order    map[string]string
+		category string
+		want     []int // nil - вопросы должны быть перемешаны
+	}{
+		{"shuffling off", false, nil, "", fileOrder(1, 20)},
+		{"shuffling on", true, nil, "", nil},
+		{"category in file order", true, map[string]string{"История": config.OrderFile}, "История", fileOrder(1, 10)},
+		{"other category keeps the default", true, map[string]string{"История": config.OrderFile}, "Наука", nil},
+		{"category shuffled", false, map[string]string{"Наука": config.OrderShuffled}, "Наука", nil},
+		{"category without override", false, map[string]string{"Наука": config.OrderShuffled}, "История", fileOrder(1, 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.ShuffleQuestions = tt.shuffle
+			cfg.CategoryOrder = tt.order
+			cfg.QuizQuestionCount = 0
+			bot, _, _ := newTestBot(t, cfg)
+			bot.quizQuestions = questions
+			const chatID = 7
+
+			if tt.category == "" {
+				bot.startQuiz(chatID, 0)
+			} else {
+				bot.startCategoryQuiz(chatID, tt.category)
+			}
+			session, exists := bot.getSession(chatID)
+			if !exists {
+				t.Fatal("quiz did not start")
+			}
+
+			got := questionIDs(session)
+			if tt.want != nil && !slices.Equal(got, tt.want) {
+				t.Errorf("questions %v, want file order %v", got, tt.want)
+			}
+			// Вероятность случайно получить порядок файла при перемешивании 20 или 10 вопросов ничтожна
+			if tt.want == nil && slices.IsSorted(got) {
+				t.Errorf("questions were not shuffled: %v", got)
+			}
+		})
+	}
+}
+
+func TestTaggedQuiz(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	cfg.QuizQuestionCount = 0
+	bot, ft, _ := newTestBot(t, cfg)
+	bot.quizQuestions = []service.QuizQuestion{
+		{ID: 1, Question: "Можно ли мясо в пост?", Options: []string{"Да", "Нет"}, Tags: []string{"мясо", "пост"}},
+		{ID: 2, Question: "Сколько дней длится пост?", Options: []string{"40", "7"}, Tags: []string{"пост"}},
+		{ID: 3, Question: "Когда Пасха?", Options: []string{"Весной", "Осенью"}, Tags: []string{"праздники"}},
+	}
+	const chatID = 7
+
+	bot.handleUpdate(textUpdate(1, chatID, "/quiz tag:рыба"))
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("quiz started without tagged questions")
+	}
+	if got, want := ft.texts(chatID), bot.text(chatID, i18n.NoTaggedQuestions, "рыба"); len(got) != 1 || got[0] != want {
+		t.Fatalf("reply = %q, want %q", got, want)
+	}
+
+	bot.handleUpdate(textUpdate(2, chatID, "/quiz tag:пост"))
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("tagged quiz did not start")
+	}
+	if got := questionIDs(session); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("questions %v, want only the ones tagged пост", got)
+	}
+}
+
+func TestQuizByCategory(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.QuizQuestionCount = 0
+	bot, ft, _ := newTestBot(t, cfg)
+	const chatID = 10
+
+	bot.handleUpdate(textUpdate(1, chatID, "/quiz"))
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("quiz started before a category was chosen")
+	}
+	requests := ft.sent("sendMessage")
+	if len(requests) == 0 || !strings.Contains(requests[len(requests)-1].Params.Get("reply_markup"), "category_all") {
+		t.Fatal("category picker was not shown")
+	}
+
+	index := slices.Index(service.Categories(bot.quizPool()), "Математика")
+	bot.handleUpdate(callbackUpdate(2, chatID, fmt.Sprintf("category_%d", index)))
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("category quiz did not start")
+	}
+	for _, question := range session.Questions {
+		if question.Category != "Математика" {
+			t.Errorf("question %d is from %q", question.ID, question.Category)
+		}
+	}
+	if session.Total() != 2 {
+		t.Errorf("%d questions, want both from Математика", session.Total())
+	}
+
+	bot.deleteSession(chatID)
+	bot.handleUpdate(callbackUpdate(3, chatID, "category_all"))
+	if session, exists := bot.getSession(chatID); !exists || session.Total() != len(testQuestions()) {
+		t.Errorf("\"all\" did not start a quiz over every question")
+	}
+}
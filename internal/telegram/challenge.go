@@ -0,0 +1,127 @@
+package telegram
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// challengePrefix - префикс аргумента диплинка ?start=challenge_<id>
+const challengePrefix = "challenge_"
+
+// challenge - вызов другу пройти те же вопросы и сравнить результат
+type challenge struct {
+	challengerID   int64
+	challengerName string
+	questions      []service.QuizQuestion
+	score          int
+	total          int
+}
+
+// saveChallenge сохраняет результат викторины как вызов и возвращает ссылку на него.
+// У пользователя хранится только последний вызов, он удаляется через ChallengeTTLMinutes
+func (b *Bot) saveChallenge(user *tgbotapi.User, session *service.QuizSession) string {
+	questions := make([]service.QuizQuestion, session.Total())
+	copy(questions, session.Questions)
+
+	c := &challenge{
+		challengerID:   user.ID,
+		challengerName: displayName(service.LeaderboardEntry{Username: user.UserName, FirstName: user.FirstName}),
+		questions:      questions,
+		score:          session.Score,
+		total:          session.MaxScore(),
+	}
+
+	b.challengesMu.Lock()
+	b.challenges[user.ID] = c
+	b.challengesMu.Unlock()
+
+	time.AfterFunc(time.Duration(b.cfg().ChallengeTTLMinutes)*time.Minute, func() {
+		b.challengesMu.Lock()
+		defer b.challengesMu.Unlock()
+
+		// Не удаляем более новый вызов этого же пользователя
+		if b.challenges[user.ID] == c {
+			delete(b.challenges, user.ID)
+		}
+	})
+
+	return fmt.Sprintf("https://t.me/%s?start=%s%d", b.api.Self.UserName, challengePrefix, user.ID)
+}
+
+// challengeShareURL возвращает ссылку, открывающую выбор чата для отправки вызова
+func challengeShareURL(link, text string) string {
+	return "https://t.me/share/url?url=" + url.QueryEscape(link) +
+		"&text=" + url.QueryEscape(text)
+}
+
+// getChallenge возвращает действующий вызов пользователя
+func (b *Bot) getChallenge(challengerID int64) (*challenge, bool) {
+	b.challengesMu.Lock()
+	defer b.challengesMu.Unlock()
+
+	c, exists := b.challenges[challengerID]
+	return c, exists
+}
+
+// acceptChallenge запускает викторину с вопросами вызова из аргумента "challenge_<id>"
+func (b *Bot) acceptChallenge(chatID int64, user *tgbotapi.User, args string) {
+	challengerID, err := strconv.ParseInt(strings.TrimPrefix(args, challengePrefix), 10, 64)
+	if err != nil {
+		b.sendMainMenu(chatID)
+		return
+	}
+
+	c, exists := b.getChallenge(challengerID)
+	if !exists {
+		b.sendMessage(chatID, b.text(chatID, i18n.ChallengeNotFound))
+		return
+	}
+
+	if challengerID == user.ID {
+		b.sendMessage(chatID, b.text(chatID, i18n.ChallengeOwn))
+		return
+	}
+
+	b.sendMessage(chatID, b.text(chatID, i18n.ChallengeIntro,
+		c.challengerName, c.score, c.total))
+
+	b.beginQuiz(chatID, c.questions)
+	if session, exists := b.getSession(chatID); exists {
+		session.ChallengerID = challengerID
+	}
+}
+
+// finishChallenge сравнивает результат соперника с результатом вызова и сообщает итог обоим
+func (b *Bot) finishChallenge(session *service.QuizSession, user *tgbotapi.User) {
+	c, exists := b.getChallenge(session.ChallengerID)
+	if !exists {
+		b.sendMessage(user.ID, b.text(user.ID, i18n.ChallengeExpired))
+		return
+	}
+
+	opponentName := displayName(service.LeaderboardEntry{Username: user.UserName, FirstName: user.FirstName})
+	summaryArgs := []any{c.challengerName, c.score, c.total, opponentName, session.Score, session.MaxScore()}
+	challengerText := b.text(c.challengerID, i18n.ChallengeSummary, summaryArgs...)
+	opponentText := b.text(user.ID, i18n.ChallengeSummary, summaryArgs...)
+	switch {
+	case session.Score > c.score:
+		challengerText += b.text(c.challengerID, i18n.ChallengeLostTo, opponentName)
+		opponentText += b.text(user.ID, i18n.ChallengeWon)
+	case session.Score < c.score:
+		challengerText += b.text(c.challengerID, i18n.ChallengeBeat, opponentName)
+		opponentText += b.text(user.ID, i18n.ChallengeLost)
+	default:
+		challengerText += b.text(c.challengerID, i18n.ChallengeDraw)
+		opponentText += b.text(user.ID, i18n.ChallengeDraw)
+	}
+
+	b.sendMessage(c.challengerID, challengerText)
+	b.sendMessage(user.ID, opponentText)
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// maxCachedChats - сколько чатов хранят кэши бота (язык, последние вопросы, разбор ответов).
+// При переполнении забывается чат, к которому дольше всего не обращались
+const maxCachedChats = 10000
+
+// chatCache - данные по ID чата с ограничением на число чатов и необязательным сроком хранения
+type chatCache[V any] struct {
+	mu    sync.Mutex
+	limit int
+	ttl   time.Duration // 0 - без срока хранения
+	now   func() time.Time
+	items map[int64]*list.Element
+	order *list.List // от давно использованных к недавним
+}
+
+type cacheItem[V any] struct {
+	chatID  int64
+	value   V
+	savedAt time.Time
+}
+
+func newChatCache[V any](limit int, ttl time.Duration) *chatCache[V] {
+	return &chatCache[V]{
+		limit: limit,
+		ttl:   ttl,
+		now:   time.Now,
+		items: make(map[int64]*list.Element),
+		order: list.New(),
+	}
+}
+
+// get возвращает значение чата, если оно есть и не устарело
+func (c *chatCache[V]) get(chatID int64) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, exists := c.items[chatID]
+	if !exists {
+		return zero, false
+	}
+	item := element.Value.(*cacheItem[V])
+	if c.ttl > 0 && c.now().Sub(item.savedAt) >= c.ttl {
+		c.remove(element)
+		return zero, false
+	}
+
+	c.order.MoveToBack(element)
+	return item.value, true
+}
+
+// set сохраняет значение чата, вытесняя самый давно использованный чат при переполнении
+func (c *chatCache[V]) set(chatID int64, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.items[chatID]; exists {
+		item := element.Value.(*cacheItem[V])
+		item.value = value
+		item.savedAt = c.now()
+		c.order.MoveToBack(element)
+		return
+	}
+
+	for c.limit > 0 && c.order.Len() >= c.limit {
+		c.remove(c.order.Front())
+	}
+	c.items[chatID] = c.order.PushBack(&cacheItem[V]{chatID: chatID, value: value, savedAt: c.now()})
+}
+
+// len возвращает число сохраненных чатов, включая еще не удаленные устаревшие
+func (c *chatCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *chatCache[V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*cacheItem[V]).chatID)
+}
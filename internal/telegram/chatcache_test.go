@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestChatCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newChatCache[string](2, 0)
+	cache.set(1, "ru")
+	cache.set(2, "en")
+	cache.get(1) // чат 1 использовался позже чата 2
+	cache.set(3, "en")
+
+	if cache.len() != 2 {
+		t.Errorf("len = %d, want 2", cache.len())
+	}
+	if _, ok := cache.get(2); ok {
+		t.Error("least recently used chat 2 was not evicted")
+	}
+	if lang, ok := cache.get(1); !ok || lang != "ru" {
+		t.Errorf("get(1) = %q, %t, want ru, true", lang, ok)
+	}
+	if _, ok := cache.get(3); !ok {
+		t.Error("newest chat 3 is missing")
+	}
+}
+
+func TestChatCacheExpires(t *testing.T) {
+	now := time.Now()
+	cache := newChatCache[int](10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.set(1, 42)
+	now = now.Add(59 * time.Second)
+	if value, ok := cache.get(1); !ok || value != 42 {
+		t.Fatalf("get before TTL = %d, %t, want 42, true", value, ok)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := cache.get(1); ok {
+		t.Error("value is still returned after TTL")
+	}
+	if cache.len() != 0 {
+		t.Errorf("expired value was not removed, len = %d", cache.len())
+	}
+}
+
+func TestBotCachesAreBounded(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+
+	for chatID := range int64(maxCachedChats + 50) {
+		bot.rememberLanguage(chatID, &tgbotapi.User{ID: chatID, LanguageCode: "en"})
+		bot.setLastQuestions(chatID, testQuestions())
+		bot.saveReview(chatID, nil)
+	}
+
+	for name, got := range map[string]int{
+		"languages":     bot.languages.len(),
+		"lastQuestions": bot.lastQuestions.len(),
+		"reviews":       bot.reviews.len(),
+	} {
+		if got != maxCachedChats {
+			t.Errorf("%s holds %d chats, want %d", name, got, maxCachedChats)
+		}
+	}
+}
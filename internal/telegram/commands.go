@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// knownCommands - команды, которые обрабатывает handleMessage, для подсказок при опечатках
+var knownCommands = []string{
+	"start", "quiz", "info", "find", "leaderboard", "hideleaderboard", "showleaderboard",
+	"rank", "practice", "pause", "resume", "showq", "answertimes", "reloadconfig",
+	"reload", "status", "preview", "checkoptions", "listq", "mistakes", "stats", "categories",
+	"daily", "settings",
+}
+
+// defaultCommandAliases - встроенные псевдонимы команд, дополняются настройкой CommandAliases
+var defaultCommandAliases = map[string]string{
+	"викторина":    "quiz",
+	"тест":         "quiz",
+	"лидерборд":    "leaderboard",
+	"рейтинг":      "leaderboard",
+	"место":        "rank",
+	"тренировка":   "practice",
+	"пауза":        "pause",
+	"продолжить":   "resume",
+	"инфо":         "info",
+	"поиск":        "find",
+	"категории":    "categories",
+	"ошибки":       "mistakes",
+	"статистика":   "stats",
+	"дня":          "daily",
+	"настройки":    "settings",
+	"top":          "leaderboard",
+	"leaderboards": "leaderboard",
+}
+
+// maxSuggestionDistance - максимальное расстояние редактирования для подсказки команды
+const maxSuggestionDistance = 2
+
+// resolveCommand возвращает команду сообщения с учетом псевдонимов. Telegram распознает
+// команды только из латиницы, поэтому "/лидерборд" разбирается из текста вручную
+func (b *Bot) resolveCommand(message *tgbotapi.Message) string {
+	command := message.Command()
+	if command == "" && strings.HasPrefix(message.Text, "/") {
+		command = strings.TrimPrefix(strings.Fields(message.Text)[0], "/")
+		command, _, _ = strings.Cut(command, "@")
+	}
+	command = strings.ToLower(command)
+
+	if alias, exists := b.cfg().CommandAliases[command]; exists {
+		return alias
+	}
+	if alias, exists := defaultCommandAliases[command]; exists {
+		return alias
+	}
+	return command
+}
+
+// suggestCommand возвращает ближайшую известную команду, если она отличается
+// не больше чем на maxSuggestionDistance символов, иначе пустую строку
+func suggestCommand(command string) string {
+	if command == "" {
+		return ""
+	}
+
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, known := range knownCommands {
+		if distance := editDistance(command, known); distance < bestDistance {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+// editDistance считает расстояние Левенштейна между строками по символам
+func editDistance(a, b string) int {
+	left, right := []rune(a), []rune(b)
+
+	prev := make([]int, len(right)+1)
+	curr := make([]int, len(right)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(left); i++ {
+		curr[0] = i
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(right)]
+}
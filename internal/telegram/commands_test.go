@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"quiz", "quiz", 0},
+		{"quizz", "quiz", 1},
+		{"qiuz", "quiz", 2},
+		{"", "rank", 4},
+		{"лидерборт", "лидерборд", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	tests := []struct {
+		command, want string
+	}{
+		{"quizz", "quiz"},
+		{"leaderbord", "leaderboard"},
+		{"rnak", "rank"},
+		{"mistake", "mistakes"},
+		{"hello", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := suggestCommand(tt.command); got != tt.want {
+			t.Errorf("suggestCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestResolveCommandAliases(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.CommandAliases = map[string]string{"q": "quiz", "рейтинг": "rank"}
+	bot, _, _ := newTestBot(t, cfg)
+
+	tests := []struct {
+		text, want string
+	}{
+		{"/quiz", "quiz"},
+		{"/q", "quiz"},
+		{"/top", "leaderboard"},
+		{"/лидерборд", "leaderboard"},
+		{"/Викторина@quiz_bot", "quiz"},
+		{"/рейтинг", "rank"}, // настройка переопределяет встроенный псевдоним
+		{"/quizz", "quizz"},
+	}
+	for _, tt := range tests {
+		update := textUpdate(1, 7, tt.text)
+		if strings.ContainsFunc(tt.text, func(r rune) bool { return r > 127 }) {
+			// Telegram не отмечает команды не из латиницы
+			update.Message.Entities = nil
+		}
+		if got := bot.resolveCommand(update.Message); got != tt.want {
+			t.Errorf("resolveCommand(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownCommandSuggestion(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+
+	bot.handleUpdate(textUpdate(1, chatID, "/quizz"))
+	want := bot.text(chatID, i18n.UnknownCommand) + bot.text(chatID, i18n.CommandSuggestion, "quiz")
+	if got := ft.texts(chatID); len(got) != 1 || got[0] != want {
+		t.Errorf("reply to /quizz = %q, want %q", got, want)
+	}
+
+	bot.handleUpdate(textUpdate(2, chatID, "/abracadabra"))
+	if got := ft.texts(chatID); len(got) != 2 || got[1] != bot.text(chatID, i18n.UnknownCommand) {
+		t.Errorf("reply to an unrelated command = %q, want no suggestion", got[1:])
+	}
+
+	update := textUpdate(3, chatID, "/лидерборд")
+	update.Message.Entities = nil
+	bot.handleUpdate(update)
+	if got := ft.texts(chatID); len(got) != 3 || got[2] != bot.text(chatID, i18n.LeaderboardEmpty) {
+		t.Errorf("reply to /лидерборд = %q, want the leaderboard", got[2:])
+	}
+}
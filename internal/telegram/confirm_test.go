@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+func TestImportantQuestionConfirmation(t *testing.T) {
+	tests := []struct {
+		name      string
+		important bool
+	}{
+		{"flag off", false},
+		{"flag on", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, ft, _ := newTestBot(t, testConfig(t))
+			const chatID = 7
+
+			bot.beginQuiz(chatID, []service.QuizQuestion{
+				{ID: 1, Question: "2 + 2?", Options: []string{"3", "4"}, Correct: 1, Important: tt.important},
+				{ID: 2, Question: "3 + 3?", Options: []string{"5", "6"}, Correct: 1},
+			})
+			session, exists := bot.getSession(chatID)
+			if !exists {
+				t.Fatal("quiz did not start")
+			}
+
+			tapOption(bot, chatID, 100, 1)
+			if !tt.important {
+				// Без флага ответ оценивается сразу
+				if len(session.Answers) != 1 || !session.Answers[0].Correct {
+					t.Fatalf("answers after one tap: %+v", session.Answers)
+				}
+				return
+			}
+
+			// С флагом первое нажатие только выделяет вариант и добавляет кнопку подтверждения
+			if len(session.Answers) != 0 {
+				t.Fatalf("answer graded before confirmation: %+v", session.Answers)
+			}
+			edits := ft.sent("editMessageReplyMarkup")
+			if len(edits) == 0 {
+				t.Fatal("choice was not highlighted")
+			}
+			markup := edits[len(edits)-1].Params.Get("reply_markup")
+			if !strings.Contains(markup, "👉 4") || !strings.Contains(markup, "confirm_0_1") {
+				t.Fatalf("keyboard has no highlighted choice or confirm button: %s", markup)
+			}
+
+			// Выбор можно изменить до подтверждения
+			tapOption(bot, chatID, 101, 0)
+			if len(session.Answers) != 0 {
+				t.Fatalf("answer graded on the second tap: %+v", session.Answers)
+			}
+
+			update := callbackUpdate(102, chatID, fmt.Sprintf("confirm_%d_%d", 0, 1))
+			update.CallbackQuery.Message.MessageID = session.MessageID
+			bot.handleUpdate(update)
+			if len(session.Answers) != 1 || !session.Answers[0].Correct {
+				t.Errorf("answers after confirmation: %+v", session.Answers)
+			}
+		})
+	}
+}
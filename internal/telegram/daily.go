@@ -0,0 +1,39 @@
+package telegram
+
+import (
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+// dailySeed - seed викторины дня: дата по UTC числом вида 20261016, чтобы вопросы
+// менялись в одно и то же время для всех чатов
+func dailySeed(t time.Time) int64 {
+	year, month, day := t.UTC().Date()
+	return int64(year*10000 + int(month)*100 + day)
+}
+
+// startDailyQuiz запускает викторину дня: выбор, порядок вопросов и их вариантов зависят только от даты,
+// поэтому в течение дня у всех игроков они одинаковые
+func (b *Bot) startDailyQuiz(chatID int64) {
+	questions := b.quizPool()
+	if len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoQuestions))
+		return
+	}
+
+	seed := dailySeed(b.now())
+	questions = service.ShuffleQuestionsWithLimitSeeded(questions, b.cfg().QuizQuestionCount, seed)
+	if b.cfg().ShuffleOptions {
+		shuffled := make([]service.QuizQuestion, len(questions))
+		for i, question := range questions {
+			// У каждого вопроса свой seed, иначе варианты зависели бы от его места в викторине
+			shuffled[i] = service.ShuffleOptionsSeeded(question, seed+int64(question.ID))
+		}
+		questions = shuffled
+	}
+
+	b.sendMessage(chatID, b.text(chatID, i18n.DailyQuizIntro))
+	b.beginPreparedQuiz(chatID, questions)
+}
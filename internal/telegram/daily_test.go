@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+func TestDailySeed(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		time time.Time
+		want int64
+	}{
+		{time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), 20261016},
+		{time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), 20260102},
+		// Полночь по Москве - еще предыдущий день по UTC
+		{time.Date(2026, 10, 16, 1, 0, 0, 0, moscow), 20261015},
+	}
+	for _, tt := range tests {
+		if got := dailySeed(tt.time); got != tt.want {
+			t.Errorf("dailySeed(%v) = %d, want %d", tt.time, got, tt.want)
+		}
+	}
+}
+
+// dailyQuestions - вопросы и варианты викторины дня в чате chatID
+func dailyQuestions(t *testing.T, bot *Bot, chatID int64) []string {
+	t.Helper()
+	bot.handleUpdate(textUpdate(int(chatID), chatID, "/daily"))
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatalf("daily quiz did not start in chat %d", chatID)
+	}
+
+	var questions []string
+	for _, question := range session.Questions {
+		questions = append(questions, fmt.Sprintf("%d:%v", question.ID, question.Options))
+	}
+	return questions
+}
+
+func TestDailyQuizIsTheSameForEveryone(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.QuizQuestionCount = 5
+	cfg.ShuffleQuestions = true
+	cfg.ShuffleOptions = true
+	bot, _, _ := newTestBot(t, cfg)
+
+	var questions []service.QuizQuestion
+	for i := range 20 {
+		questions = append(questions, service.QuizQuestion{ID: i + 1, Question: fmt.Sprintf("Вопрос %d", i+1), Options: []string{"a", "b", "c", "d"}})
+	}
+	bot.quizQuestions = questions
+
+	today := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	bot.now = func() time.Time { return today }
+	first := dailyQuestions(t, bot, 1)
+	if len(first) != 5 {
+		t.Fatalf("daily quiz has %d questions, want 5", len(first))
+	}
+	if second := dailyQuestions(t, bot, 2); !slices.Equal(first, second) {
+		t.Errorf("players got different daily quizzes:\n%v\n%v", first, second)
+	}
+
+	today = today.Add(24 * time.Hour)
+	if tomorrow := dailyQuestions(t, bot, 3); slices.Equal(first, tomorrow) {
+		t.Error("daily quiz did not change the next day")
+	}
+}
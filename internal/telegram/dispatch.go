@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// dispatch выполняет handle в отдельной горутине под блокировкой чата chatID:
+// разные чаты обрабатываются параллельно (пауза между вопросами одного игрока не задерживает
+// остальных), а обновления одного чата - строго по очереди
+func (b *Bot) dispatch(chatID int64, handle func()) {
+	// После Stop новые обработчики (например, от таймеров вопросов) не запускаем
+	if b.stopped.Load() {
+		return
+	}
+
+	b.handlers.Add(1)
+	go func() {
+		defer b.handlers.Done()
+		b.withChatLock(chatID, handle)
+	}()
+}
+
+// chatLock - блокировка чата и число обработчиков, которые ее держат или ждут
+type chatLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// withChatLock выполняет fn, удерживая блокировку чата chatID. Блокировка удаляется,
+// когда ее больше никто не ждет, поэтому chatLocks хранит только чаты с обрабатываемыми обновлениями
+func (b *Bot) withChatLock(chatID int64, fn func()) {
+	b.chatLocksMu.Lock()
+	lock, exists := b.chatLocks[chatID]
+	if !exists {
+		lock = &chatLock{}
+		b.chatLocks[chatID] = lock
+	}
+	lock.refs++
+	b.chatLocksMu.Unlock()
+
+	defer func() {
+		b.chatLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(b.chatLocks, chatID)
+		}
+		b.chatLocksMu.Unlock()
+	}()
+
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	fn()
+}
+
+// updateChatID возвращает ID чата, к которому относится обновление, или 0
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.EditedMessage != nil:
+		return update.EditedMessage.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// answerCurrent отвечает первым вариантом на текущий вопрос викторины чата, как нажатие кнопки под ним
+func answerCurrent(b *Bot, chatID int64, updateID int) {
+	session, exists := b.getSession(chatID)
+	if !exists {
+		return
+	}
+	update := callbackUpdate(updateID, chatID, fmt.Sprintf("quiz_%d_0", session.CurrentQuestion))
+	update.CallbackQuery.Message.MessageID = session.MessageID
+	b.handleUpdate(update)
+}
+
+// dispatchAndWait передает обновление через dispatch и ждет его обработки
+func dispatchAndWait(b *Bot, chatID int64, handle func()) {
+	done := make(chan struct{})
+	b.dispatch(chatID, func() {
+		defer close(done)
+		handle()
+	})
+	<-done
+}
+
+func TestDispatchConcurrentQuizzes(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	bot, _, _ := newTestBot(t, cfg)
+
+	const chats = 20
+	questions := len(testQuestions())
+
+	var wg sync.WaitGroup
+	// Разные чаты проходят викторину целиком параллельно
+	for chat := range chats {
+		chatID := int64(1000 + chat)
+		wg.Go(func() {
+			dispatchAndWait(bot, chatID, func() {
+				bot.handleUpdate(textUpdate(1, chatID, fmt.Sprintf("/quiz %d", questions)))
+			})
+			for i := range questions {
+				dispatchAndWait(bot, chatID, func() { answerCurrent(bot, chatID, i+2) })
+			}
+		})
+	}
+	// Один чат одновременно получает много запусков и ответов
+	const sameChat = int64(1)
+	for i := range 50 {
+		wg.Go(func() {
+			bot.dispatch(sameChat, func() {
+				if i%5 == 0 {
+					bot.handleUpdate(textUpdate(i, sameChat, fmt.Sprintf("/quiz %d", questions)))
+					return
+				}
+				answerCurrent(bot, sameChat, i)
+			})
+		})
+	}
+	wg.Wait()
+	bot.handlers.Wait()
+
+	for chat := range chats {
+		if _, exists := bot.getSession(int64(1000 + chat)); exists {
+			t.Errorf("chat %d: quiz is still active", 1000+chat)
+		}
+	}
+	top, err := bot.leaderboardService.GetTop(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished := 0
+	for _, entry := range top {
+		if entry.UserID >= 1000 {
+			finished++
+		}
+	}
+	if finished != chats {
+		t.Errorf("leaderboard has %d finished quizzes from distinct chats, want %d", finished, chats)
+	}
+}
+
+func TestAnswerDelayIsLocalToTheChat(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	cfg.AnswerDelayMs = 1000
+	bot, _, _ := newTestBot(t, cfg)
+	const slowChat, fastChat = 1, 2
+
+	// Пауза после ответа в первом чате длится, пока ее не отпустят
+	sleeping, release := make(chan struct{}), make(chan struct{})
+	var paused atomic.Bool
+	bot.sleep = func(time.Duration) {
+		if paused.CompareAndSwap(false, true) {
+			close(sleeping)
+			<-release
+		}
+	}
+	defer bot.handlers.Wait()
+	defer close(release)
+
+	dispatchAndWait(bot, slowChat, func() { bot.handleUpdate(textUpdate(1, slowChat, "/quiz 2")) })
+	bot.dispatch(slowChat, func() { answerCurrent(bot, slowChat, 2) })
+	select {
+	case <-sleeping:
+	case <-time.After(5 * time.Second):
+		t.Fatal("answer delay did not start")
+	}
+
+	// Другой чат проходит викторину, пока первый ждет
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		dispatchAndWait(bot, fastChat, func() { bot.handleUpdate(textUpdate(3, fastChat, "/quiz 1")) })
+		dispatchAndWait(bot, fastChat, func() { answerCurrent(bot, fastChat, 4) })
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second chat waited for the first chat's answer delay")
+	}
+	if _, exists := bot.getSession(fastChat); exists {
+		t.Error("second chat's quiz did not finish")
+	}
+
+	// Следующее обновление того же чата ждет окончания паузы
+	next := make(chan struct{})
+	bot.dispatch(slowChat, func() { close(next) })
+	select {
+	case <-next:
+		t.Error("update of the same chat ran during its answer delay")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
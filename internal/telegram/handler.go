@@ -2,66 +2,328 @@ package telegram
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"math/rand"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
 	"github.com/PoluyanbIch/GoTgBot/internal/service"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type Bot struct {
 	api                *tgbotapi.BotAPI
+	topics             *topicClient    // HTTP-клиент api, запоминающий темы форумов входящих обновлений
+	threads            *chatCache[int] // тема форума последнего обновления чата, см. rememberThread
+	updates            updateSource    // источник обновлений, обычно api
+	config             *config.Config
+	configMu           sync.RWMutex
 	quizSessions       map[int64]*service.QuizSession
+	sessionsMu         sync.RWMutex     // защищает quizSessions
+	polls              map[string]int64 // чат опроса-викторины по ID опроса, см. handlePollAnswer
+	pollsMu            sync.Mutex
 	leaderboardService service.LeaderboardService
 	quizQuestions      []service.QuizQuestion
+	questionsMu        sync.RWMutex
+	lastQuestions      *chatCache[[]service.QuizQuestion] // порядок вопросов последней викторины в чате
+	preferences        *service.PreferencesService
+	answerStats        *service.AnswerStats
+	practiceStats      *service.AnswerStats    // статистика тренировок хранится отдельно от настоящих попыток
+	mistakes           *service.MistakeStats   // ошибки пользователей по вопросам для /mistakes
+	streaks            *service.StreakStats    // серии дней с викториной для /stats
+	savedSessions      *service.SessionStore   // незавершенные викторины для продолжения после перезапуска
+	reviews            *chatCache[*quizReview] // разбор ответов последней викторины в чате
+	challenges         map[int64]*challenge    // вызовы друзей по ID бросившего вызов
+	challengesMu       sync.Mutex
+	localizer          *i18n.Localizer
+	languages          *chatCache[string]    // язык чата по language_code пользователя
+	engine             *service.QuizEngine   // подсчет очков и переход между вопросами
+	randIntn           func(n int) int       // источник случайных чисел, подменяется в тестах
+	sleep              func(d time.Duration) // пауза между шагами викторины, подменяется в тестах
+	now                func() time.Time      // текущее время, подменяется в тестах
+	startedAt          time.Time
+	stopped            atomic.Bool
+	chatLocks          map[int64]*chatLock // блокировки чатов с обрабатываемыми обновлениями, см. withChatLock
+	chatLocksMu        sync.Mutex
+	handlers           sync.WaitGroup // обработчики обновлений, запущенные dispatch
+	logger             *slog.Logger
+	logLevel           *slog.LevelVar // уровень логов, который /reloadconfig меняет без перезапуска
 }
 
-func NewBot(token string, leaderboardService service.LeaderboardService, questionsFile string) (*Bot, error) {
-	api, err := tgbotapi.NewBotAPI(token)
+// NewBot создает бота. logger получает структурированные логи бота и его сервисов, nil - slog.Default()
+func NewBot(cfg *config.Config, leaderboardService service.LeaderboardService, logger *slog.Logger) (*Bot, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	// Логи библиотеки (ошибки long polling, запросы в режиме Debug) идут в тот же logger
+	if err := tgbotapi.SetLogger(apiLogger{logger: logger}); err != nil {
+		return nil, err
+	}
+
+	api, err := tgbotapi.NewBotAPI(cfg.Token)
 	if err != nil {
 		return nil, err
 	}
 
-	questions := service.LoadQuizQuestions(questionsFile)
+	questions := service.LoadQuizQuestions(cfg.QuestionsFile, logger)
+
+	expected, mismatched := service.CheckOptionCounts(questions, cfg.ExpectedOptionCount)
+	for _, question := range mismatched {
+		logger.Warn("unexpected option count", "question_id", question.ID, "options", len(question.Options), "expected", expected)
+	}
+
+	return newBot(cfg, api, leaderboardService, questions, logger), nil
+}
+
+// newBot собирает бота вокруг готового клиента Telegram API
+func newBot(cfg *config.Config, api *tgbotapi.BotAPI, leaderboardService service.LeaderboardService, questions []service.QuizQuestion, logger *slog.Logger) *Bot {
+	topics := newTopicClient(api.Client)
+	api.Client = topics
 
-	return &Bot{
+	bot := &Bot{
 		api:                api,
+		topics:             topics,
+		threads:            newChatCache[int](maxCachedChats, 0),
+		updates:            api,
+		config:             cfg,
 		quizSessions:       make(map[int64]*service.QuizSession),
+		polls:              make(map[string]int64),
+		lastQuestions:      newChatCache[[]service.QuizQuestion](maxCachedChats, 0),
+		preferences:        service.NewStorePreferencesService(leaderboardService.Store(), logger),
+		answerStats:        service.NewStoreAnswerStats(leaderboardService.Store(), service.AnswerStatsNamespace, logger),
+		practiceStats:      service.NewStoreAnswerStats(leaderboardService.Store(), service.PracticeStatsNamespace, logger),
+		mistakes:           service.NewStoreMistakeStats(leaderboardService.Store(), logger),
+		streaks:            service.NewStoreStreakStats(leaderboardService.Store(), logger),
+		savedSessions:      service.NewSessionStore(leaderboardService.Store(), logger),
+		reviews:            newChatCache[*quizReview](maxCachedChats, reviewTTL),
+		challenges:         make(map[int64]*challenge),
+		localizer:          i18n.NewLocalizer(),
+		languages:          newChatCache[string](maxCachedChats, 0),
+		chatLocks:          make(map[int64]*chatLock),
+		randIntn:           rand.Intn,
+		sleep:              time.Sleep,
+		now:                time.Now,
+		startedAt:          time.Now(),
 		leaderboardService: leaderboardService,
 		quizQuestions:      questions,
-	}, nil
+		logger:             logger,
+	}
+	if cfg.StatsFlushInterval > 0 {
+		bot.answerStats.EnableBatching()
+		bot.practiceStats.EnableBatching()
+	}
+	bot.engine = &service.QuizEngine{
+		Bonus:  bot.pickBonusQuestion,
+		Refill: bot.refillPractice,
+	}
+	return bot
+}
+
+// SetLogLevel передает боту уровень обработчика логов, чтобы /reloadconfig применял
+// новый уровень без перезапуска
+func (b *Bot) SetLogLevel(level *slog.LevelVar) {
+	b.logLevel = level
+}
+
+// cfg возвращает текущую конфигурацию, которая может быть заменена через /reloadconfig
+func (b *Bot) cfg() *config.Config {
+	b.configMu.RLock()
+	defer b.configMu.RUnlock()
+
+	return b.config
 }
 
+// updateSource - источник обновлений Telegram, его реализует *tgbotapi.BotAPI
+type updateSource interface {
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+}
+
+// Границы задержки перед переподключением к Telegram, если канал обновлений закрылся.
+// tgbotapi сам повторяет неудачные запросы и закрывает канал только после StopReceivingUpdates,
+// поэтому переподключение защищает от источников, которые закрывают канал при ошибке
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 1 * time.Minute
+)
+
 func (b *Bot) Start() {
-	b.api.Debug = true
-	log.Printf("Authorised on account: %s", b.api.Self.UserName)
+	b.api.Debug = b.cfg().Debug
+	b.logger.Info("authorised", "account", b.api.Self.UserName)
+	b.restoreSessions()
+
+	// Статистика, накопленная в памяти, записывается по таймеру и при остановке
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		b.flushStats()
+	}()
+	if interval := b.cfg().StatsFlushInterval; interval > 0 {
+		go b.flushStatsEvery(time.Duration(interval)*time.Second, done)
+	}
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
-	updates := b.api.GetUpdatesChan(u)
-
-	for update := range updates {
-		if update.Message != nil {
-			switch update.Message.Command() {
-			case "start":
-				b.sendMainMenu(update.Message.Chat.ID)
-			case "quiz":
-				b.startQuiz(update.Message.Chat.ID)
-			case "info":
-				b.handleInfo(update.Message.Chat.ID)
-			default:
-				b.sendMessage(update.Message.Chat.ID, "Неизвестная команда")
-			}
+	delay := minReconnectDelay
+	for {
+		updates := b.updates.GetUpdatesChan(u)
+
+		for update := range updates {
+			// Не получаем повторно уже обработанные обновления после переподключения
+			u.Offset = update.UpdateID + 1
+			delay = minReconnectDelay
+
+			b.dispatch(b.updateChat(update), func() { b.handleUpdate(update) })
 		}
-		if update.CallbackQuery != nil {
-			b.handleCallback(update.CallbackQuery)
+
+		if b.stopped.Load() {
+			// Дожидаемся обработки уже полученных обновлений
+			b.handlers.Wait()
+			b.logger.Info("bot stopped")
+			return
 		}
+
+		b.logger.Warn("updates channel closed, reconnecting", "delay", delay)
+		b.sleep(delay)
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// Stop останавливает получение обновлений, после чего Start завершается без переподключения
+func (b *Bot) Stop() {
+	if b.stopped.CompareAndSwap(false, true) {
+		b.updates.StopReceivingUpdates()
 	}
 }
 
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	b.rememberThread(update)
+	if update.Message != nil {
+		b.rememberLanguage(update.Message.Chat.ID, update.Message.From)
+		b.handleMessage(update.Message)
+	}
+	if update.EditedMessage != nil {
+		b.rememberLanguage(update.EditedMessage.Chat.ID, update.EditedMessage.From)
+		b.handleEditedMessage(update.EditedMessage)
+	}
+	if update.CallbackQuery != nil && update.CallbackQuery.Message != nil {
+		b.rememberLanguage(update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.From)
+	}
+	if update.CallbackQuery != nil {
+		b.handleCallback(update.CallbackQuery)
+	}
+	if update.PollAnswer != nil {
+		b.handlePollAnswer(update.PollAnswer)
+	}
+}
+
+func (b *Bot) handleMessage(message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+
+	command := b.resolveCommand(message)
+	switch command {
+	case "start":
+		// Диплинк ?start=quiz приходит из группы, когда викторина переносится в личку,
+		// ?start=challenge_<id> - по ссылке "Вызвать друга"
+		args := message.CommandArguments()
+		switch {
+		case args == "quiz":
+			b.chooseCategory(chatID)
+		case strings.HasPrefix(args, challengePrefix):
+			b.acceptChallenge(chatID, message.From, args)
+		default:
+			b.sendMainMenu(chatID)
+		}
+	case "quiz":
+		// /quiz tag:<тег> - вопросы с тегом, /quiz N - N случайных вопросов без выбора категории
+		args := strings.TrimSpace(message.CommandArguments())
+		start := b.chooseCategory
+		if tag, ok := strings.CutPrefix(args, "tag:"); ok {
+			start = func(chatID int64) { b.startTaggedQuiz(chatID, tag) }
+		} else if count := parseQuestionCount(args, len(b.quizPool())); count > 0 {
+			start = func(chatID int64) { b.startQuiz(chatID, count) }
+		}
+		b.startInPrivate(message.Chat, message.From, start)
+	case "daily":
+		b.startInPrivate(message.Chat, message.From, b.startDailyQuiz)
+	case "info":
+		b.handleInfo(chatID)
+	case "find":
+		b.handleFind(chatID, message.CommandArguments())
+	case "leaderboard":
+		b.handleLeaderboard(chatID, 0, message.From.ID, service.PeriodAll, 1)
+	case "hideleaderboard":
+		b.handleLeaderboardVisibility(message.Chat, message.From.ID, true)
+	case "showleaderboard":
+		b.handleLeaderboardVisibility(message.Chat, message.From.ID, false)
+	case "rank":
+		b.handleRank(chatID, message.From.ID)
+	case "stats":
+		b.handleStats(chatID, message.From.ID)
+	case "practice":
+		b.startInPrivate(message.Chat, message.From, b.choosePracticeCategory)
+	case "pause":
+		b.handlePause(chatID)
+	case "resume":
+		b.handleResume(chatID, message.From)
+	case "showq":
+		b.handleShowQuestion(chatID, message.From.ID, message.CommandArguments())
+	case "answertimes":
+		b.handleAnswerTimes(chatID, message.From.ID)
+	case "reloadconfig":
+		b.handleReloadConfig(chatID, message.From.ID)
+	case "reload":
+		b.handleReloadQuestions(chatID, message.From.ID)
+	case "status":
+		b.handleStatus(chatID, message.From.ID)
+	case "preview":
+		b.handlePreview(chatID, message.From.ID, message.CommandArguments())
+	case "checkoptions":
+		b.handleCheckOptions(chatID, message.From.ID)
+	case "mistakes":
+		b.handleMistakes(chatID, message.From.ID)
+	case "listq":
+		b.handleListQuestions(chatID, 0, message.From.ID, message.CommandArguments())
+	case "categories":
+		b.handleCategories(chatID)
+	case "settings":
+		b.handleSettings(message.Chat, 0)
+	default:
+		text := b.text(chatID, i18n.UnknownCommand)
+		if suggestion := suggestCommand(command); suggestion != "" {
+			text += b.text(chatID, i18n.CommandSuggestion, suggestion)
+		}
+		b.sendMessage(chatID, text)
+	}
+}
+
+// handleEditedMessage обрабатывает отредактированные команды: выполняет их заново
+// или сообщает, что редактирование не поддерживается (зависит от EditedCommands)
+func (b *Bot) handleEditedMessage(message *tgbotapi.Message) {
+	if !message.IsCommand() {
+		return
+	}
+
+	if b.cfg().EditedCommands == config.EditedCommandsHandle {
+		b.handleMessage(message)
+		return
+	}
+
+	b.sendMessage(message.Chat.ID, b.text(message.Chat.ID, i18n.EditedCommand))
+}
+
 func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
 	chatID := callback.Message.Chat.ID
 	data := callback.Data
@@ -69,267 +331,1017 @@ func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
 
 	callbackConfig := tgbotapi.NewCallback(callback.ID, "")
 	if _, err := b.api.Request(callbackConfig); err != nil {
-		log.Printf("Error Answering Callback: %v", err)
+		b.logger.Error("answering callback failed", "chat_id", chatID, "err", err)
 	}
 
 	switch {
 	case data == "start_quiz":
-		b.startQuiz(chatID)
+		b.startInPrivate(callback.Message.Chat, user, b.chooseCategory)
+	case data == "start_quiz_random":
+		b.startInPrivate(callback.Message.Chat, user, b.startRandomLengthQuiz)
+	case data == "restart_same":
+		b.restartSameQuiz(chatID)
+	case data == "start_practice":
+		b.startInPrivate(callback.Message.Chat, user, b.choosePracticeCategory)
+	case data == "stop_practice":
+		b.finishQuiz(chatID, false, user)
+	case strings.HasPrefix(data, "quiz_next_"):
+		b.handleQuizContinue(chatID, data)
 	case strings.HasPrefix(data, "quiz_"):
-		b.handleQuizAnswer(chatID, data, user)
+		b.handleQuizAnswer(chatID, callback.Message.MessageID, data, user)
+	case strings.HasPrefix(data, "confirm_"):
+		b.handleQuizAnswer(chatID, callback.Message.MessageID, data, user)
 	case data == "exit_quiz":
 		b.finishQuiz(chatID, true, user)
+	case data == "skip_quiz":
+		b.handleSkipQuestion(chatID, callback.Message.MessageID, user)
+	case data == "fifty_fifty":
+		b.handleFiftyFifty(chatID, callback.Message.MessageID)
+	case data == "order_reset":
+		b.handleResetOrder(chatID, callback.Message.MessageID)
+	case data == "settings":
+		b.handleSettings(callback.Message.Chat, callback.Message.MessageID)
+	case data == "settings_overtake":
+		b.handleToggleOvertake(callback.Message.Chat, callback.Message.MessageID)
+	case data == "multi_submit":
+		b.handleSubmitChoice(chatID, callback.Message.MessageID, user)
+	case strings.HasPrefix(data, "practice_category_"):
+		b.handlePracticeCategory(chatID, data)
+	case strings.HasPrefix(data, "category_"):
+		b.handleCategory(chatID, data)
+	case strings.HasPrefix(data, "listq_page_"):
+		b.handleListQuestions(chatID, callback.Message.MessageID, user.ID, strings.TrimPrefix(data, "listq_page_"))
+	case strings.HasPrefix(data, "review_"):
+		b.handleReview(chatID, callback.Message.MessageID, data)
 	case data == "back_to_menu":
 		b.sendMainMenu(chatID)
 	case data == "info":
 		b.handleInfo(chatID)
 	case data == "leaderboard":
-		b.handleLeaderboard(chatID)
+		b.handleLeaderboard(chatID, 0, user.ID, service.PeriodAll, 1)
+	case strings.HasPrefix(data, "leaderboard_page_"):
+		b.handleLeaderboard(chatID, callback.Message.MessageID, user.ID, service.PeriodAll, parseLeaderboardPage(data))
+	case data == "leaderboard_week":
+		b.handleLeaderboard(chatID, 0, user.ID, service.PeriodWeek, 1)
+	case data == "leaderboard_month":
+		b.handleLeaderboard(chatID, 0, user.ID, service.PeriodMonth, 1)
+	case data == "leaderboard_active":
+		b.handleActiveLeaderboard(chatID)
+	case data == "leaderboard_composite":
+		b.handleCompositeLeaderboard(chatID)
+	case data == "my_stats":
+		b.handleStats(chatID, user.ID)
 	default:
-		b.sendMessage(chatID, "Неизвестная команда")
+		b.sendMessage(chatID, b.text(chatID, i18n.UnknownCommand))
 	}
 }
 
 func (b *Bot) sendMainMenu(chatID int64) {
-	msg := tgbotapi.NewMessage(chatID, "📋 *Главное меню*")
-	msg.ParseMode = "Markdown"
+	text := b.text(chatID, i18n.MenuTitle)
+	var rows [][]tgbotapi.InlineKeyboardButton
 
-	kb := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🐖Харам тест🐖", "start_quiz"),
-			tgbotapi.NewInlineKeyboardButtonData("🏆 Лидерборд", "leaderboard"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("ℹ️Обо мнеℹ️", "info"),
-		),
-	)
-	msg.ReplyMarkup = kb
-	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sending start message: %v", err)
+	// Без вопросов кнопки викторины не показываем
+	if len(b.quizPool()) > 0 {
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonQuiz), "start_quiz"),
+				tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonRandom), "start_quiz_random"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonPractice), "start_practice"),
+			),
+		)
+	} else {
+		text += "\n\n" + b.text(chatID, i18n.MenuNoQuestions)
+	}
+
+	if !b.preferences.Get(chatID).HideLeaderboard {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonBoard), "leaderboard"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonActive), "leaderboard_active"),
+		), tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonComposite), "leaderboard_composite"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonMyStats), "my_stats"),
+		tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonInfo), "info"),
+	))
+	// Личные настройки открываются только в личке, а ID личного чата совпадает с ID пользователя
+	if chatID > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonSettings), "settings"),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending start message failed", "chat_id", chatID, "err", err)
 	}
 }
 
 func (b *Bot) sendMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
-	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sendinf msg: %v", err)
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending message failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// escapeMarkdown экранирует текст из файла вопросов (варианты, пояснения) для сообщений с ParseMode Markdown:
+// непарный _, *, ` или [ иначе приводит к отказу Telegram отправить сообщение
+func escapeMarkdown(text string) string {
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text)
+}
+
+// explanationText возвращает строку с пояснением к ответу для Markdown-сообщения, пустую - если пояснения нет
+func explanationText(question service.QuizQuestion) string {
+	if question.Explanation == "" {
+		return ""
+	}
+	return "\nℹ️ " + escapeMarkdown(question.Explanation)
+}
+
+// typingAction - действие "печатает..." для чата
+func typingAction(chatID int64) tgbotapi.ChatActionConfig {
+	return tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping)
+}
+
+// sendTyping показывает в чате "печатает...", пока бот готовит следующее сообщение.
+// Telegram отвечает на действие true, а не сообщением, поэтому используется Request, а не Send
+func (b *Bot) sendTyping(chatID int64) {
+	if _, err := b.api.Request(typingAction(chatID)); err != nil {
+		b.logger.Error("sending chat action failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// startInPrivate запускает викторину в текущем чате или, если включен QuizInDM и чат групповой,
+// в личных сообщениях пользователя, чтобы кнопки ответов не засоряли группу
+func (b *Bot) startInPrivate(chat *tgbotapi.Chat, user *tgbotapi.User, start func(chatID int64)) {
+	if !b.cfg().QuizInDM || chat.IsPrivate() {
+		start(chat.ID)
+		return
+	}
+
+	// Бот может написать пользователю, только если тот уже запускал его в личке
+	dm := tgbotapi.NewMessage(user.ID, b.text(user.ID, i18n.DMContinue))
+	if _, err := b.send(dm); err != nil {
+		link := fmt.Sprintf("https://t.me/%s?start=quiz", b.api.Self.UserName)
+		msg := tgbotapi.NewMessage(chat.ID, b.text(chat.ID, i18n.DMInstructions, user.FirstName))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonURL(b.text(chat.ID, i18n.ButtonOpenBot), link),
+			),
+		)
+		if _, err := b.send(msg); err != nil {
+			b.logger.Error("sending DM instructions failed", "chat_id", chat.ID, "user_id", user.ID, "err", err)
+		}
+		return
 	}
+
+	b.sendMessage(chat.ID, b.text(chat.ID, i18n.DMSent, user.FirstName))
+	// Сессия создается в личном чате, поэтому блокируем и его. Обработчики личного чата
+	// не берут блокировку групп, так что взаимной блокировки нет
+	b.withChatLock(user.ID, func() { start(user.ID) })
 }
 
-func (b *Bot) startQuiz(chatID int64) {
-	shuffledQuestions := service.ShuffleQuestions(b.quizQuestions)
+// questions возвращает текущий набор вопросов
+func (b *Bot) questions() []service.QuizQuestion {
+	b.questionsMu.RLock()
+	defer b.questionsMu.RUnlock()
+
+	return b.quizQuestions
+}
 
-	session := &service.QuizSession{
-		UserID:          chatID,
-		CurrentQuestion: 0,
-		Score:           0,
-		Questions:       shuffledQuestions,
+// quizPool возвращает вопросы для основной части викторины.
+// Когда бонусные вопросы включены, они в основную часть не попадают
+func (b *Bot) quizPool() []service.QuizQuestion {
+	active := service.ActiveQuestions(b.questions())
+	if !b.cfg().BonusQuestion {
+		return active
 	}
 
-	b.quizSessions[chatID] = session
+	regular, _ := service.SplitBonusQuestions(active)
+	return regular
+}
+
+// practicePool возвращает вопросы для тренировки: отмеченные флагом practice,
+// а если таких нет - все вопросы основной викторины
+func (b *Bot) practicePool() []service.QuizQuestion {
+	if practice := service.PracticeQuestions(service.ActiveQuestions(b.questions())); len(practice) > 0 {
+		return practice
+	}
+	return b.quizPool()
+}
+
+// pickBonusQuestion выбирает случайный бонусный вопрос, если функция включена и такие вопросы есть
+func (b *Bot) pickBonusQuestion() (service.QuizQuestion, bool) {
+	if !b.cfg().BonusQuestion {
+		return service.QuizQuestion{}, false
+	}
+
+	_, bonus := service.SplitBonusQuestions(service.ActiveQuestions(b.questions()))
+	if len(bonus) == 0 {
+		return service.QuizQuestion{}, false
+	}
+	return b.prepareQuestions([]service.QuizQuestion{bonus[b.randIntn(len(bonus))]})[0], true
+}
+
+// practiceQuestions возвращает вопросы тренировки по категории category, пустая - все
+func (b *Bot) practiceQuestions(category string) []service.QuizQuestion {
+	questions := b.practicePool()
+	if category == "" {
+		return questions
+	}
+	return service.QuestionsInCategory(questions, category)
+}
+
+// refillPractice перемешивает вопросы категории тренировки для нового круга
+func (b *Bot) refillPractice(session *service.QuizSession) []service.QuizQuestion {
+	return b.prepareQuestions(service.ShuffleQuestions(b.practiceQuestions(session.Category)))
+}
+
+// startQuiz запускает викторину из count вопросов. count <= 0 - размер по умолчанию:
+// QuizQuestionCount вопросов (или все, если их меньше)
+func (b *Bot) startQuiz(chatID int64, count int) {
+	if count <= 0 {
+		count = b.cfg().QuizQuestionCount
+	}
+	b.beginQuiz(chatID, b.selectQuestions(b.quizPool(), count))
+}
+
+// parseQuestionCount разбирает количество вопросов из аргумента /quiz N. Для пустого
+// или некорректного аргумента возвращает 0 (размер по умолчанию), больше available - available
+func parseQuestionCount(args string, available int) int {
+	count, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil || count <= 0 {
+		return 0
+	}
+	if count > available {
+		return available
+	}
+	return count
+}
+
+// startTaggedQuiz запускает викторину только из вопросов с тегом tag
+func (b *Bot) startTaggedQuiz(chatID int64, tag string) {
+	tag = strings.TrimSpace(tag)
+	questions := service.QuestionsWithTag(b.quizPool(), tag)
+	if len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoTaggedQuestions, tag))
+		return
+	}
+
+	b.beginQuiz(chatID, b.selectQuestions(questions, b.cfg().QuizQuestionCount))
+}
+
+// selectQuestions выбирает limit вопросов с учетом настройки ShuffleQuestions
+func (b *Bot) selectQuestions(questions []service.QuizQuestion, limit int) []service.QuizQuestion {
+	return pickQuestions(questions, limit, b.cfg().ShuffleQuestions)
+}
+
+// pickQuestions выбирает limit вопросов (limit <= 0 - все): перемешанных при shuffle,
+// иначе первых по порядку файла
+func pickQuestions(questions []service.QuizQuestion, limit int, shuffle bool) []service.QuizQuestion {
+	if shuffle {
+		return service.ShuffleQuestionsWithLimit(questions, limit)
+	}
+
+	if limit <= 0 || limit > len(questions) {
+		limit = len(questions)
+	}
+	ordered := make([]service.QuizQuestion, limit)
+	copy(ordered, questions)
+	return ordered
+}
+
+// startRandomLengthQuiz запускает викторину со случайным количеством вопросов
+// из настроенного диапазона, ограниченного числом доступных вопросов
+func (b *Bot) startRandomLengthQuiz(chatID int64) {
+	questions := b.quizPool()
+
+	minLen, maxLen := b.cfg().RandomQuizMin, b.cfg().RandomQuizMax
+	if maxLen > len(questions) {
+		maxLen = len(questions)
+	}
+	if minLen > maxLen {
+		minLen = maxLen
+	}
+
+	count := minLen + b.randIntn(maxLen-minLen+1)
+	b.beginQuiz(chatID, b.selectQuestions(questions, count))
+}
+
+// prepareQuestions готовит вопросы к показу в сессии: при включенном ShuffleOptions
+// перемешивает варианты ответа каждого вопроса. Исходный срез не изменяется
+func (b *Bot) prepareQuestions(questions []service.QuizQuestion) []service.QuizQuestion {
+	if !b.cfg().ShuffleOptions {
+		return questions
+	}
+
+	prepared := make([]service.QuizQuestion, len(questions))
+	for i, question := range questions {
+		prepared[i] = service.ShuffleOptions(question)
+	}
+	return prepared
+}
+
+// restartSameQuiz запускает викторину с теми же вопросами в том же порядке, что и в прошлый раз.
+// Варианты ответа тоже остаются в прошлом порядке, чтобы попытки можно было честно сравнить
+func (b *Bot) restartSameQuiz(chatID int64) {
+	questions, exists := b.getLastQuestions(chatID)
+	if !exists || len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoPreviousQuestions))
+		return
+	}
+
+	b.beginPreparedQuiz(chatID, slices.Clone(questions))
+}
+
+// startPractice запускает тренировку по категории category (пустая - все вопросы): вопросы
+// перемешиваются заново по кругу, пока пользователь не нажмет "Стоп", результат не сохраняется
+func (b *Bot) startPractice(chatID int64, category string) {
+	questions := b.practiceQuestions(category)
+	if len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoQuestions))
+		return
+	}
+
+	session := b.engine.StartPractice(chatID, b.prepareQuestions(service.ShuffleQuestions(questions)), category)
+	session.ThreadID = b.chatThread(chatID)
+
+	if !b.setSession(chatID, session) {
+		b.sendMessage(chatID, b.text(chatID, i18n.SessionLimitReached))
+		return
+	}
+	b.sendQuestion(chatID, 0)
+}
+
+// beginQuiz создает сессию с уже подготовленными вопросами и отправляет первый вопрос
+func (b *Bot) beginQuiz(chatID int64, questions []service.QuizQuestion) {
+	// Пустая сессия закончилась бы делением на ноль при подсчете результата
+	if len(questions) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoQuestions))
+		return
+	}
+	b.beginPreparedQuiz(chatID, b.prepareQuestions(questions))
+}
+
+// beginPreparedQuiz создает сессию с вопросами, варианты которых уже перемешаны, и отправляет первый вопрос
+func (b *Bot) beginPreparedQuiz(chatID int64, questions []service.QuizQuestion) {
+	session := b.engine.StartSession(chatID, questions)
+	session.SkipsRemaining = b.cfg().QuizSkips
+	session.PartialCredit = b.cfg().ScoringMode == config.ScoringPartial
+	session.ThreadID = b.chatThread(chatID)
+
+	if !b.setSession(chatID, session) {
+		b.sendMessage(chatID, b.text(chatID, i18n.SessionLimitReached))
+		return
+	}
 	b.sendQuestion(chatID, 0)
 }
 
 func (b *Bot) sendQuestion(chatID int64, questionIndex int) {
-	session, exists := b.quizSessions[chatID]
+	session, exists := b.getSession(chatID)
 	if !exists || questionIndex >= len(session.Questions) {
 		return
 	}
 	question := session.Questions[questionIndex]
 
-	message := fmt.Sprintf("❓ *Вопрос %d/%d*\n\n%s",
+	message := b.text(chatID, i18n.QuestionHeader,
 		questionIndex+1,
-		len(session.Questions),
+		session.Total(),
 		question.Question)
+	if session.IsBonus(questionIndex) {
+		message = b.text(chatID, i18n.BonusQuestionHeader, question.Question)
+	}
+	if session.Practice {
+		message = b.text(chatID, i18n.PracticeHeader, session.Answered+1, question.Question)
+	}
+	if question.Ordered() {
+		message += b.text(chatID, i18n.OrderHint)
+	}
+	if question.Multi() {
+		message += b.text(chatID, i18n.MultiHint)
+	}
+	// Уведомление о перезапуске - текст с разметкой, поэтому такой вопрос отправляется с кнопками, а не опросом
+	recovered := session.Recovered
+	if recovered {
+		// Уведомление о перезапуске показывается один раз, с первым вопросом после восстановления
+		number := questionIndex + 1
+		if session.Practice {
+			number = session.Answered + 1
+		}
+		message = b.text(chatID, i18n.SessionRecovered, number) + message
+		session.Recovered = false
+	}
 
 	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ReplyMarkup = b.questionKeyboard(chatID, session, questionIndex, -1)
+
+	// Опрос предыдущего вопроса, оставшийся без ответа (таймаут, пропуск), больше не ждет ответа
+	b.forgetPoll(session.PollID)
+	session.PollID = ""
+
+	switch {
+	case b.cfg().QuizPollMode && pollQuestion(question) && !recovered:
+		b.sendPollQuestion(chatID, session, questionIndex, msg)
+	case question.Image != "":
+		b.sendImageQuestion(chatID, session, question.Image, msg)
+	default:
+		b.updateQuizMessage(chatID, session, msg)
+	}
+	session.QuestionSentAt = b.now()
+	session.CurrentAnswered = false
+	b.scheduleTimeout(chatID, session, questionIndex)
+	b.saveSession(chatID, session)
+}
+
+// updateQuizMessage показывает msg в сообщении викторины: редактирует session.MessageID,
+// а если его нет, это фото или опрос или редактирование не удалось (например, сообщение слишком старое),
+// отправляет новое сообщение и запоминает его ID
+func (b *Bot) updateQuizMessage(chatID int64, session *service.QuizSession, msg tgbotapi.MessageConfig) {
+	if session.MessageID != 0 && !session.PhotoMessage && session.PollID == "" {
+		edit := tgbotapi.NewEditMessageText(chatID, session.MessageID, msg.Text)
+		edit.ParseMode = msg.ParseMode
+		if keyboard, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+			edit.ReplyMarkup = &keyboard
+		}
+
+		_, err := b.send(edit)
+		if err == nil {
+			return
+		}
+		b.logger.Warn("editing quiz message failed, sending a new one", "chat_id", chatID, "err", err)
+	}
+
+	session.PhotoMessage = false
+	b.forgetPoll(session.PollID)
+	session.PollID = ""
+	sent, err := b.sendToThread(msg, session.ThreadID)
+	if err != nil {
+		b.logger.Error("sending quiz message failed", "chat_id", chatID, "err", err)
+		session.MessageID = 0
+		return
+	}
+	session.MessageID = sent.MessageID
+}
+
+// questionKeyboard строит клавиатуру вопроса. Если selected >= 0, выбранный вариант
+// подсвечивается и добавляется кнопка подтверждения (для вопросов с флагом Important).
+// В вопросе на порядок нажатые варианты отмечаются своим номером, в вопросе с несколькими
+// ответами - галочкой
+func (b *Bot) questionKeyboard(chatID int64, session *service.QuizSession, questionIndex, selected int) tgbotapi.InlineKeyboardMarkup {
+	question := session.Questions[questionIndex]
 
 	var rows [][]tgbotapi.InlineKeyboardButton
 	for i, option := range question.Options {
+		// Варианты, убранные подсказкой 50/50, не показываются, индексы остальных не меняются
+		if questionIndex == session.CurrentQuestion && session.OptionHidden(i) {
+			continue
+		}
+		if i == selected {
+			option = "👉 " + option
+		}
+		if questionIndex == session.CurrentQuestion && question.Ordered() {
+			if n := session.OptionTapped(i); n > 0 {
+				option = fmt.Sprintf("%d) %s", n, option)
+			}
+		}
+		if questionIndex == session.CurrentQuestion && session.OptionSelected(i) {
+			option = "☑️ " + option
+		}
 		callbackData := fmt.Sprintf("quiz_%d_%d", questionIndex, i)
 		button := tgbotapi.NewInlineKeyboardButtonData(option, callbackData)
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
 	}
 
-	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("🚪Выйти из викторины🚪", "exit_quiz"),
-	))
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
-	msg.ReplyMarkup = keyboard
+	if selected >= 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonConfirm), fmt.Sprintf("confirm_%d_%d", questionIndex, selected)),
+		))
+	}
+	if questionIndex == session.CurrentQuestion && len(session.Sequence) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonResetOrder), "order_reset"),
+		))
+	}
+	if questionIndex == session.CurrentQuestion && len(session.Selection) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonSubmitChoice), "multi_submit"),
+		))
+	}
 
-	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sending quesion: %v", err)
+	if session.Practice {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonStopPractice), "stop_practice"),
+		))
+	} else {
+		// Подсказки показываются, пока доступны: 50/50 - один раз, пропуски - пока не закончились
+		var lifelines []tgbotapi.InlineKeyboardButton
+		if session.FiftyFiftyAvailable() {
+			lifelines = append(lifelines, tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonFiftyFifty), "fifty_fifty"))
+		}
+		if session.SkipsRemaining > 0 {
+			lifelines = append(lifelines, tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonSkip, session.SkipsRemaining), "skip_quiz"))
+		}
+		if len(lifelines) > 0 {
+			rows = append(rows, lifelines)
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonExitQuiz), "exit_quiz"),
+		))
 	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
-func (b *Bot) handleQuizAnswer(chatID int64, data string, user *tgbotapi.User) {
+// handleQuizAnswer обрабатывает ответ "quiz_<вопрос>_<вариант>". Для вопросов с флагом Important
+// первое нажатие только выделяет вариант, а оценивается ответ после "confirm_<вопрос>_<вариант>".
+// В вопросе с несколькими ответами нажатие отмечает вариант, а оценивается выбор по кнопке "Готово"
+func (b *Bot) handleQuizAnswer(chatID int64, messageID int, data string, user *tgbotapi.User) {
 	parts := strings.Split(data, "_")
 	if len(parts) != 3 {
 		return
 	}
-	questionIndex, _ := strconv.Atoi(parts[1])
-	answerIndex, _ := strconv.Atoi(parts[2])
+	questionIndex, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return
+	}
+	answerIndex, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
 
-	session, exists := b.quizSessions[chatID]
-	if !exists {
+	session, exists := b.getSession(chatID)
+	if !exists || session.AwaitingContinue || session.CurrentAnswered {
+		return
+	}
+	// Повторное нажатие на уже отвеченный вопрос не должно засчитаться следующему,
+	// а индексы из старых или подделанных callback - выйти за границы
+	if questionIndex != session.CurrentQuestion || questionIndex < 0 || questionIndex >= len(session.Questions) {
+		return
+	}
+	if answerIndex < 0 || answerIndex >= len(session.Questions[questionIndex].Options) || session.OptionHidden(answerIndex) {
+		return
+	}
+	// Кнопки под старыми сообщениями (например, до /resume) уже не относятся к текущему вопросу
+	if session.MessageID != 0 && messageID != session.MessageID {
+		return
+	}
+	if session.Paused {
+		b.sendMessage(chatID, b.text(chatID, i18n.QuizPaused))
 		return
 	}
 	question := session.Questions[questionIndex]
-	isCorrect := answerIndex == question.Correct
+
+	var result service.AnswerResult
+	var done bool
+	switch {
+	case question.Ordered():
+		// Вопрос на порядок оценивается, когда нажаты все варианты
+		var answered bool
+		if result, answered, done = b.engine.Tap(session, answerIndex); !answered {
+			b.refreshQuestionKeyboard(chatID, messageID, session)
+			return
+		}
+	case question.Multi():
+		b.engine.Toggle(session, answerIndex)
+		b.refreshQuestionKeyboard(chatID, messageID, session)
+		return
+	case question.Important && !strings.HasPrefix(data, "confirm_"):
+		edit := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, b.questionKeyboard(chatID, session, questionIndex, answerIndex))
+		if _, err := b.send(edit); err != nil {
+			b.logger.Error("highlighting answer failed", "chat_id", chatID, "err", err)
+		}
+		return
+	default:
+		result, done = b.engine.Answer(session, answerIndex)
+	}
+
+	b.acceptAnswer(chatID, messageID, session, result, done, user)
+}
+
+// handleSubmitChoice оценивает отмеченные варианты вопроса с несколькими ответами по кнопке "Готово".
+// Кнопки под старыми сообщениями, нажатия после ответа и пустой выбор игнорируются
+func (b *Bot) handleSubmitChoice(chatID int64, messageID int, user *tgbotapi.User) {
+	session, exists := b.getSession(chatID)
+	if !exists || session.AwaitingContinue || session.CurrentAnswered {
+		return
+	}
+	if session.MessageID != 0 && messageID != session.MessageID {
+		return
+	}
+	if session.Paused {
+		b.sendMessage(chatID, b.text(chatID, i18n.QuizPaused))
+		return
+	}
+
+	result, submitted, done := b.engine.Submit(session)
+	if !submitted {
+		return
+	}
+	b.acceptAnswer(chatID, messageID, session, result, done, user)
+}
+
+// acceptAnswer завершает принятый ответ: останавливает таймер, пишет статистику и ошибки,
+// показывает результат ответа и переходит к следующему вопросу
+func (b *Bot) acceptAnswer(chatID int64, messageID int, session *service.QuizSession, result service.AnswerResult, done bool, user *tgbotapi.User) {
+	question := result.Question
+	session.StopTimer()
+	session.Player = &service.Player{ID: user.ID, Username: user.UserName, FirstName: user.FirstName}
+
+	if !session.QuestionSentAt.IsZero() {
+		stats := b.answerStats
+		if session.Practice {
+			stats = b.practiceStats
+		}
+		stats.Record(question, b.now().Sub(session.QuestionSentAt))
+	}
+
+	if !result.Correct {
+		session.Mistakes = append(session.Mistakes, service.PendingMistake{UserID: user.ID, QuestionID: question.ID, Question: question.Question})
+	}
+
+	if delay := b.cfg().AnswerRevealDelayMs; delay > 0 {
+		// Убираем кнопки, пока "обрабатываем" ответ: нажатия за это время некуда отправить
+		edit := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		if _, err := b.send(edit); err != nil {
+			b.logger.Error("removing answer keyboard failed", "chat_id", chatID, "err", err)
+		}
+		b.sleep(time.Duration(delay) * time.Millisecond)
+	}
 
 	resultMsg := tgbotapi.NewMessage(chatID, "")
-	if isCorrect {
-		session.Score++
-		resultMsg.Text = "✅ *Правильно!* 🎉"
+	if result.Correct && result.Bonus {
+		resultMsg.Text = b.text(chatID, i18n.AnswerCorrectBonus)
+	} else if result.Correct {
+		resultMsg.Text = b.text(chatID, i18n.AnswerCorrect)
+		if result.Points > 1 {
+			resultMsg.Text += b.text(chatID, i18n.AnswerPoints, result.Points)
+		}
+	} else if result.Points > 0 {
+		// Частичный зачет вопроса с несколькими ответами
+		resultMsg.Text = b.text(chatID, i18n.AnswerPartial, result.Points)
+		if !b.cfg().HideCorrectAnswer {
+			resultMsg.Text += b.text(chatID, i18n.CorrectAnswer, escapeMarkdown(question.CorrectText()))
+		}
+	} else if b.cfg().HideCorrectAnswer {
+		resultMsg.Text = b.text(chatID, i18n.AnswerWrong)
 	} else {
-		correctAnswer := question.Options[question.Correct]
-		resultMsg.Text = fmt.Sprintf("❌ *Неправильно!*\nПравильный ответ: %s", correctAnswer)
+		correctAnswer := escapeMarkdown(question.RevealText(b.randIntn))
+		resultMsg.Text = b.text(chatID, i18n.AnswerWrong) + b.text(chatID, i18n.CorrectAnswer, correctAnswer)
+	}
+	if session.PollID == "" {
+		// Под опросом пояснение уже показал Telegram
+		resultMsg.Text += explanationText(question)
+	}
+	if session.Streak > 1 {
+		resultMsg.Text += b.text(chatID, i18n.Streak, session.Streak)
+		if result.StreakBonus > 0 {
+			resultMsg.Text += b.text(chatID, i18n.StreakBonus, result.StreakBonus)
+		}
 	}
 	resultMsg.ParseMode = "Markdown"
-	if _, err := b.api.Send(resultMsg); err != nil {
-		log.Printf("Error sending result: %v", err)
+
+	b.advanceQuiz(chatID, session, resultMsg, done, user)
+}
+
+// handleSkipQuestion пропускает текущий вопрос по кнопке "Пропустить" без ответа и очков.
+// Кнопки под старыми сообщениями и нажатия после ответа игнорируются
+func (b *Bot) handleSkipQuestion(chatID int64, messageID int, user *tgbotapi.User) {
+	session, exists := b.getSession(chatID)
+	if !exists || session.AwaitingContinue || session.CurrentAnswered {
+		return
+	}
+	if session.MessageID != 0 && messageID != session.MessageID {
+		return
+	}
+	if session.Paused {
+		b.sendMessage(chatID, b.text(chatID, i18n.QuizPaused))
+		return
+	}
+
+	skipped, done := b.engine.Skip(session)
+	if !skipped {
+		return
+	}
+	session.StopTimer()
+	session.Player = &service.Player{ID: user.ID, Username: user.UserName, FirstName: user.FirstName}
+
+	resultMsg := tgbotapi.NewMessage(chatID, b.text(chatID, i18n.QuestionSkipped, session.SkipsRemaining))
+	b.advanceQuiz(chatID, session, resultMsg, done, user)
+}
+
+// handleResetOrder сбрасывает нажатые варианты текущего вопроса на порядок по кнопке "Сбросить"
+func (b *Bot) handleResetOrder(chatID int64, messageID int) {
+	session, exists := b.getSession(chatID)
+	if !exists || session.AwaitingContinue || session.CurrentAnswered || session.Paused {
+		return
+	}
+	if session.MessageID != 0 && messageID != session.MessageID {
+		return
 	}
 
-	// Переходим к следующему вопросу или завершаем
-	session.CurrentQuestion++
-	if session.CurrentQuestion < len(session.Questions) {
-		// Ждем секунду и показываем следующий вопрос
-		time.Sleep(1 * time.Second)
+	b.engine.ResetOrder(session)
+	b.refreshQuestionKeyboard(chatID, messageID, session)
+}
+
+// refreshQuestionKeyboard перерисовывает клавиатуру текущего вопроса после подсказки или нажатия в вопросе на порядок
+func (b *Bot) refreshQuestionKeyboard(chatID int64, messageID int, session *service.QuizSession) {
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, b.questionKeyboard(chatID, session, session.CurrentQuestion, -1))
+	if _, err := b.send(edit); err != nil {
+		b.logger.Error("updating question keyboard failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// handleFiftyFifty убирает половину неправильных вариантов текущего вопроса и обновляет клавиатуру.
+// Подсказка доступна один раз за викторину, повторные нажатия игнорируются
+func (b *Bot) handleFiftyFifty(chatID int64, messageID int) {
+	session, exists := b.getSession(chatID)
+	if !exists || session.AwaitingContinue || session.CurrentAnswered || session.Paused {
+		return
+	}
+	if session.MessageID != 0 && messageID != session.MessageID {
+		return
+	}
+	if !b.engine.UseFiftyFifty(session) {
+		return
+	}
+
+	b.refreshQuestionKeyboard(chatID, messageID, session)
+}
+
+// advanceQuiz отправляет результат ответа и показывает следующий вопрос или, если done, завершает викторину.
+// К этому моменту движок викторины уже перевел сессию на следующий вопрос
+func (b *Bot) advanceQuiz(chatID int64, session *service.QuizSession, resultMsg tgbotapi.MessageConfig, done bool, user *tgbotapi.User) {
+	hasNext := !done
+
+	// В ручном режиме следующий вопрос откроется по кнопке "Далее"
+	manualContinue := hasNext && b.cfg().ManualContinue
+	if manualContinue {
+		session.AwaitingContinue = true
+		resultMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonNext), fmt.Sprintf("quiz_next_%d", session.CurrentQuestion)),
+			),
+		)
+	}
+
+	// Результат ненадолго заменяет вопрос в том же сообщении, затем его сменит следующий вопрос
+	b.updateQuizMessage(chatID, session, resultMsg)
+
+	if manualContinue {
+		return
+	}
+
+	// Ждем перед следующим вопросом или итогами, при нулевой паузе переходим сразу
+	if delay := b.cfg().AnswerDelayMs; delay > 0 {
+		b.sendTyping(chatID)
+		b.sleep(time.Duration(delay) * time.Millisecond)
+	}
+
+	// На паузе не переходим дальше - /resume покажет текущий вопрос
+	if session.Paused {
+		return
+	}
+
+	if hasNext {
 		b.sendQuestion(chatID, session.CurrentQuestion)
 	} else {
-		// Викторина завершена
-		time.Sleep(1 * time.Second)
+		// Викторина завершена. Если игрок неизвестен (в группе никто не ответил до таймаута),
+		// сохранять результат некому
+		b.finishQuiz(chatID, user == nil, user)
+	}
+}
+
+// handlePause ставит викторину на паузу: ответы не принимаются, следующий вопрос не показывается
+func (b *Bot) handlePause(chatID int64) {
+	session, exists := b.getSession(chatID)
+	if !exists {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoActiveQuiz))
+		return
+	}
+
+	session.Paused = true
+	b.saveSession(chatID, session)
+	b.sendMessage(chatID, b.text(chatID, i18n.QuizPaused))
+}
+
+// handleResume снимает викторину с паузы и заново отправляет текущий вопрос
+func (b *Bot) handleResume(chatID int64, user *tgbotapi.User) {
+	session, exists := b.getSession(chatID)
+	if !exists || !session.Paused {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoPausedQuiz))
+		return
+	}
+
+	session.Paused = false
+	session.AwaitingContinue = false
+	// Сообщение с вопросом осталось выше сообщений о паузе - показываем вопрос заново внизу чата
+	session.MessageID = 0
+
+	if session.CurrentQuestion >= len(session.Questions) {
 		b.finishQuiz(chatID, false, user)
+		return
 	}
+	b.sendQuestion(chatID, session.CurrentQuestion)
+}
+
+// handleQuizContinue показывает следующий вопрос по кнопке "Далее" (callback "quiz_next_<n>").
+// Кнопки от старых сообщений игнорируются
+func (b *Bot) handleQuizContinue(chatID int64, data string) {
+	questionIndex, err := strconv.Atoi(strings.TrimPrefix(data, "quiz_next_"))
+	if err != nil {
+		return
+	}
+
+	session, exists := b.getSession(chatID)
+	if !exists || session.Paused || !session.AwaitingContinue || questionIndex != session.CurrentQuestion {
+		return
+	}
+
+	session.AwaitingContinue = false
+	b.sendQuestion(chatID, session.CurrentQuestion)
 }
 
 func (b *Bot) finishQuiz(chatID int64, exited bool, user *tgbotapi.User) {
-	session, exists := b.quizSessions[chatID]
+	session, exists := b.deleteSession(chatID)
 	if !exists {
 		return
 	}
 
-	delete(b.quizSessions, chatID)
+	result := b.engine.Finish(session)
+	b.mistakes.RecordAll(session.Mistakes)
+
+	if session.Practice {
+		b.finishPractice(chatID, result)
+		return
+	}
+
+	// Запоминаем порядок вопросов, чтобы можно было пройти их заново
+	lastQuestions := make([]service.QuizQuestion, session.Total())
+	copy(lastQuestions, session.Questions)
+	b.setLastQuestions(chatID, lastQuestions)
 
 	finalMsg := tgbotapi.NewMessage(chatID, "")
 	resultText := ""
+	challengeLink := ""
 	if exited {
-		resultText = "🚪 Викторина прервана.\nВаш результат не сохранен."
+		resultText = b.text(chatID, i18n.QuizExited)
 	} else {
-		percentage := (session.Score * 100) / len(session.Questions)
+		// Процент считается от максимально возможных очков с учетом сложности вопросов
+		percentage := b.formatPercentage(chatID, result.Score, result.Total)
 
-		isNewBest := b.leaderboardService.AddEntry(
+		before := b.topSnapshot(user.ID)
+		b.markActive(user.ID)
+		b.streaks.Record(user.ID, b.now(), b.cfg().StreakGraceDays)
+		isNewBest, err := b.leaderboardService.AddEntry(
 			user.ID,
 			user.UserName,
 			user.FirstName,
-			session.Score,
-			len(session.Questions),
+			result.Score,
+			result.Total,
+			result.BonusPoints,
+			result.Duration,
 		)
 
-		resultText = fmt.Sprintf(
-			"🏁 *Викторина завершена!*\n\n"+
-				"📊 Результат: %d/%d\n"+
-				"📈 Процент правильных: %d%%\n\n",
-			session.Score, len(session.Questions), percentage)
+		resultText = b.text(chatID, i18n.QuizFinished, result.Score, result.Total, percentage)
+
+		if session.BonusAsked || result.BonusPoints > 0 {
+			resultText += b.text(chatID, i18n.QuizBonusPoints, result.BonusPoints)
+		}
 
-		if isNewBest {
-			position, _ := b.leaderboardService.GetUserPosition(user.ID)
-			if position != -1 {
-				resultText += fmt.Sprintf("🎉 *Новый рекорд!* Вы на %d месте в лидерборде!\n\n", position)
+		if result.MaxStreak > 1 {
+			resultText += b.text(chatID, i18n.QuizBestStreak, result.MaxStreak)
+		}
+
+		if b.cfg().PassPercentage > 0 && result.Total > 0 {
+			resultText += b.passVerdict(chatID, result, b.cfg().PassPercentage)
+		}
+
+		minPercent := b.cfg().MinLeaderboardPercent
+		if err != nil {
+			b.logger.Error("saving result failed", "chat_id", chatID, "user_id", user.ID, "err", err)
+			resultText += b.text(chatID, i18n.QuizSaveFailed)
+		} else if result.Score*100 < minPercent*result.Total {
+			resultText += b.text(chatID, i18n.QuizNotRanked, minPercent)
+		} else if isNewBest {
+			// Один снимок после сохранения дает и место рекорда, и обогнанных игроков
+			after := b.topSnapshot(user.ID)
+			position := after.Position
+			if position <= 0 {
+				// Игрок еще не прошел фильтр MinAttempts - место как в /rank
+				if position, _, err = b.leaderboardService.GetUserRank(user.ID, b.cfg().MinAttempts); err != nil {
+					b.logger.Error("loading leaderboard position failed", "chat_id", chatID, "user_id", user.ID, "err", err)
+				}
+			}
+			if position > 0 {
+				resultText += b.recordMessage(chatID, position)
 			}
+			b.notifyOvertaken(user, before, after)
+		}
+
+		if session.ChallengerID != 0 {
+			b.finishChallenge(session, user)
+		} else if chatID == user.ID {
+			// Вызов можно отправить только из лички: ссылка открывает бота у друга
+			challengeLink = b.saveChallenge(user, session)
 		}
 	}
 	finalMsg.ParseMode = "Markdown"
 	finalMsg.Text = resultText
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+	rows := [][]tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎯 Начать заново", "start_quiz"),
-			tgbotapi.NewInlineKeyboardButtonData("🔙 В меню", "back_to_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonRestart), "start_quiz"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonBackToMenu), "back_to_menu"),
 		),
-	)
+	}
+	if len(result.Answers) > 0 {
+		b.saveReview(chatID, result.Answers)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonReview), "review_start"),
+		))
+	}
+	if _, exists := b.getLastQuestions(chatID); exists {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonSameQuestions), "restart_same"),
+		))
+	}
+	if challengeLink != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(b.text(chatID, i18n.ButtonChallenge), challengeShareURL(challengeLink, b.text(chatID, i18n.ChallengeShareText))),
+		))
+	}
 
-	finalMsg.ReplyMarkup = keyboard
+	finalMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 
-	if _, err := b.api.Send(finalMsg); err != nil {
-		log.Printf("Error sending final message: %v", err)
+	if _, err := b.sendToThread(finalMsg, session.ThreadID); err != nil {
+		b.logger.Error("sending final message failed", "chat_id", chatID, "err", err)
 	}
 }
 
-func (b *Bot) handleLeaderboard(chatID int64) {
-	top := b.leaderboardService.GetTop(10) // Топ 10
+// celebrations - поздравления для рекордов в топ-3
+var celebrations = []i18n.Key{i18n.Celebration1, i18n.Celebration2, i18n.Celebration3, i18n.Celebration4}
 
-	if len(top) == 0 {
-		b.sendMessage(chatID, "🏆 *Лидерборд*\n\nПока нет результатов. Будьте первым! 🎯")
-		return
+// recordMessage возвращает сообщение о новом рекорде. Для топ-3 при включенном CelebrateTop
+// добавляется случайное поздравление
+func (b *Bot) recordMessage(chatID int64, position int) string {
+	text := b.text(chatID, i18n.NewRecord, b.ordinal(chatID, position))
+	if b.cfg().CelebrateTop && position <= 3 {
+		text += b.text(chatID, celebrations[b.randIntn(len(celebrations))]) + "\n\n"
 	}
+	return text
+}
 
-	message := "🏆 <b>Топ 10 игроков</b>\n\n"
-
-	for i, entry := range top {
-		username := entry.FirstName
-		if entry.Username != "" {
-			username = "@" + entry.Username
-		}
-
-		medal := "🔸"
-		switch i {
-		case 0:
-			medal = "🥇"
-		case 1:
-			medal = "🥈"
-		case 2:
-			medal = "🥉"
-		}
-
-		message += fmt.Sprintf("%s %d. %s - %d%% (%d/%d)\n   📅 %s\n\n",
-			medal, i+1, username, entry.Percentage, entry.Score, entry.Total, entry.Date)
+// passVerdict возвращает вердикт "Сдано/Не сдано" относительно проходного процента
+func (b *Bot) passVerdict(chatID int64, result service.QuizResult, passPercentage int) string {
+	if result.Passed(passPercentage) {
+		return b.text(chatID, i18n.QuizPassed, passPercentage)
 	}
+	return b.text(chatID, i18n.QuizFailed, passPercentage)
+}
 
-	msg := tgbotapi.NewMessage(chatID, message)
-	msg.ParseMode = "HTML"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+// finishPractice показывает точность ответов за тренировку, не трогая лидерборд
+func (b *Bot) finishPractice(chatID int64, result service.QuizResult) {
+	msg := tgbotapi.NewMessage(chatID, b.text(chatID, i18n.PracticeFinished,
+		result.Score, result.Total, b.formatPercentage(chatID, result.Score, result.Total)))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎯 Начать викторину", "start_quiz"),
-			tgbotapi.NewInlineKeyboardButtonData("📋 Главное меню", "back_to_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonMorePractice), "start_practice"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonBackToMenu), "back_to_menu"),
 		),
 	)
 
-	msg.ReplyMarkup = keyboard
-
-	if _, err := b.api.Send(msg); err != nil {
-		log.Printf("Error sending leaderboard: %v", err)
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending practice result failed", "chat_id", chatID, "err", err)
 	}
 }
 
 func (b *Bot) handleInfo(chatID int64) {
-	msg := "Мой исходный код:\n" +
-		"https://github.com/PoluyanbIch/GoTgBot\n" +
-		"Можно поставить звездочку⭐ на него и подписаться:\n" +
-		"https://github.com/PoluyanbIch\n" +
-		"отзывы, предложения, предпочтения -> https://t.me/PoluyanbIch"
-
-	infoMsg := tgbotapi.NewMessage(chatID, msg)
+	infoMsg := tgbotapi.NewMessage(chatID, b.text(chatID, i18n.InfoText))
 	infoMsg.ParseMode = "Markdown"
 
 	// Добавляем кнопки для удобства
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonURL("📂 GitHub репозиторий", "https://github.com/PoluyanbIch/GoTgBot"),
+			tgbotapi.NewInlineKeyboardButtonURL(b.text(chatID, i18n.ButtonRepository), "https://github.com/PoluyanbIch/GoTgBot"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonURL("👤 Автор", "https://github.com/PoluyanbIch"),
-			tgbotapi.NewInlineKeyboardButtonURL("💬 Написать", "https://t.me/PoluyanbIch"),
+			tgbotapi.NewInlineKeyboardButtonURL(b.text(chatID, i18n.ButtonAuthor), "https://github.com/PoluyanbIch"),
+			tgbotapi.NewInlineKeyboardButtonURL(b.text(chatID, i18n.ButtonWrite), "https://t.me/PoluyanbIch"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back_to_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonBack), "back_to_menu"),
 		),
 	)
 
 	infoMsg.ReplyMarkup = keyboard
 
-	if _, err := b.api.Send(infoMsg); err != nil {
-		log.Printf("Error sending info: %v", err)
+	if _, err := b.send(infoMsg); err != nil {
+		b.logger.Error("sending info failed", "chat_id", chatID, "err", err)
 	}
 }
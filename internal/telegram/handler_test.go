@@ -0,0 +1,864 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/config"
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeUpdates - источник обновлений, который отдает по одной пачке на каждое подключение
+// и закрывает канал, как при обрыве соединения. После последней пачки бот останавливается
+type fakeUpdates struct {
+	bot     *Bot
+	batches [][]tgbotapi.Update
+
+	mu      sync.Mutex
+	offsets []int
+	stops   int
+}
+
+func (f *fakeUpdates) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	f.mu.Lock()
+	call := len(f.offsets)
+	f.offsets = append(f.offsets, config.Offset)
+	f.mu.Unlock()
+
+	ch := make(chan tgbotapi.Update, len(f.batches[call]))
+	for _, update := range f.batches[call] {
+		ch <- update
+	}
+	if call == len(f.batches)-1 {
+		f.bot.Stop()
+	}
+	close(ch)
+	return ch
+}
+
+func (f *fakeUpdates) StopReceivingUpdates() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stops++
+}
+
+func TestStartReconnectsWithBackoff(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+
+	source := &fakeUpdates{
+		bot: bot,
+		batches: [][]tgbotapi.Update{
+			{{UpdateID: 1}, {UpdateID: 2}},
+			{},
+			{},
+			{{UpdateID: 3}},
+			{},
+		},
+	}
+	bot.updates = source
+
+	var delays []time.Duration
+	bot.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	bot.Start()
+
+	// Переподключаемся без повторного получения обработанных обновлений
+	if want := []int{0, 3, 3, 3, 4}; !slices.Equal(source.offsets, want) {
+		t.Errorf("offsets = %v, want %v", source.offsets, want)
+	}
+	// Задержка растет, пока обновлений нет, и сбрасывается после полученного обновления
+	want := []time.Duration{minReconnectDelay, 2 * minReconnectDelay, 4 * minReconnectDelay, minReconnectDelay}
+	if !slices.Equal(delays, want) {
+		t.Errorf("delays = %v, want %v", delays, want)
+	}
+	if source.stops != 1 {
+		t.Errorf("StopReceivingUpdates called %d times, want 1", source.stops)
+	}
+}
+
+func TestStopDoesNotReconnect(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+
+	source := &fakeUpdates{bot: bot, batches: [][]tgbotapi.Update{{{UpdateID: 7}}}}
+	bot.updates = source
+	bot.sleep = func(d time.Duration) { t.Errorf("unexpected reconnect delay %v after Stop", d) }
+
+	bot.Start()
+
+	if len(source.offsets) != 1 {
+		t.Errorf("GetUpdatesChan called %d times, want 1", len(source.offsets))
+	}
+	// Повторный Stop не трогает источник
+	bot.Stop()
+	if source.stops != 1 {
+		t.Errorf("StopReceivingUpdates called %d times, want 1", source.stops)
+	}
+}
+
+func TestStopFlushesAnswerStats(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+	bot.updates = &fakeUpdates{bot: bot, batches: [][]tgbotapi.Update{{}}}
+
+	bot.answerStats.Record(testQuestions()[0], time.Second)
+	store := bot.leaderboardService.Store()
+	if values, _ := store.List(service.AnswerStatsNamespace); len(values) != 0 {
+		t.Fatalf("stats written before the flush: %v", values)
+	}
+
+	bot.Start()
+
+	if values, _ := store.List(service.AnswerStatsNamespace); len(values) != 1 {
+		t.Errorf("stats after Stop = %v, want the recorded answer", values)
+	}
+}
+
+func TestPassVerdict(t *testing.T) {
+	tests := []struct {
+		name           string
+		passPercentage int
+		correct        int
+		want           i18n.Key
+	}{
+		{"below", 50, 1, i18n.QuizFailed},
+		{"at", 50, 2, i18n.QuizPassed},
+		{"above", 50, 3, i18n.QuizPassed},
+		{"unset", 0, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.PassPercentage = tt.passPercentage
+			bot, ft, _ := newTestBot(t, cfg)
+			const chatID = 9
+
+			questions := make([]service.QuizQuestion, 4)
+			for i := range questions {
+				questions[i] = service.QuizQuestion{ID: i + 1, Question: "?", Options: []string{"да", "нет"}, Correct: 0}
+			}
+			bot.beginQuiz(chatID, questions)
+			for i := range questions {
+				option := 1
+				if i < tt.correct {
+					option = 0
+				}
+				tapOption(bot, chatID, 100+i, option)
+			}
+			if _, exists := bot.getSession(chatID); exists {
+				t.Fatal("quiz did not finish after the last answer")
+			}
+
+			texts := ft.texts(chatID)
+			result := texts[len(texts)-1]
+			for _, key := range []i18n.Key{i18n.QuizPassed, i18n.QuizFailed} {
+				verdict := bot.text(chatID, key, tt.passPercentage)
+				if got := strings.Contains(result, verdict); got != (key == tt.want) {
+					t.Errorf("verdict %q shown = %t in:\n%s", verdict, got, result)
+				}
+			}
+			if tt.want == "" && strings.Contains(result, "50%") {
+				t.Errorf("verdict shown without a pass percentage:\n%s", result)
+			}
+		})
+	}
+}
+
+func TestMultiAnswerFlow(t *testing.T) {
+	tests := []struct {
+		name        string
+		scoringMode string
+		wantScore   string
+	}{
+		{"exact", config.ScoringExact, "0/1"},
+		{"partial", config.ScoringPartial, "1/2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.ScoringMode = tt.scoringMode
+			bot, ft, _ := newTestBot(t, cfg)
+			const chatID = 11
+
+			bot.beginQuiz(chatID, []service.QuizQuestion{
+				{ID: 1, Question: "Четные?", Options: []string{"1", "2", "3", "4"}, Correct: 1, CorrectSet: []int{1, 3}},
+			})
+			session, exists := bot.getSession(chatID)
+			if !exists {
+				t.Fatal("quiz did not start")
+			}
+			if texts := ft.texts(chatID); !strings.Contains(texts[len(texts)-1], strings.TrimSpace(bot.text(chatID, i18n.MultiHint))) {
+				t.Errorf("question has no hint: %q", texts[len(texts)-1])
+			}
+
+			tapOption(bot, chatID, 100, 1)
+			tapOption(bot, chatID, 101, 2)
+			tapOption(bot, chatID, 102, 2) // повторное нажатие снимает отметку
+			if session.CurrentAnswered || !slices.Equal(session.Selection, []int{1}) {
+				t.Fatalf("after toggling: answered = %v, selection = %v", session.CurrentAnswered, session.Selection)
+			}
+			edits := ft.sent("editMessageReplyMarkup")
+			if len(edits) == 0 || !strings.Contains(edits[len(edits)-1].Params.Get("reply_markup"), "multi_submit") {
+				t.Fatal("keyboard was not redrawn with the submit button")
+			}
+
+			update := callbackUpdate(103, chatID, "multi_submit")
+			update.CallbackQuery.Message.MessageID = session.MessageID
+			bot.handleUpdate(update)
+
+			if _, exists := bot.getSession(chatID); exists {
+				t.Fatal("quiz did not finish after the choice was submitted")
+			}
+			texts := ft.texts(chatID)
+			if result := texts[len(texts)-1]; !strings.Contains(result, tt.wantScore) {
+				t.Errorf("result does not show %s:\n%s", tt.wantScore, result)
+			}
+		})
+	}
+}
+
+func TestStartQuizWithEmptyBank(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+	bot.quizQuestions = nil
+
+	bot.handleUpdate(callbackUpdate(1, chatID, "start_quiz"))
+
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("quiz started without questions")
+	}
+	texts := ft.texts(chatID)
+	if len(texts) == 0 || texts[len(texts)-1] != bot.text(chatID, i18n.NoQuestions) {
+		t.Errorf("sent %q, want the no questions message", texts)
+	}
+
+	// В меню без вопросов нет кнопок викторины
+	bot.sendMainMenu(chatID)
+	requests := ft.sent("sendMessage")
+	menu := requests[len(requests)-1]
+	if markup := menu.Params.Get("reply_markup"); strings.Contains(markup, "start_quiz") {
+		t.Errorf("menu offers a quiz without questions: %s", markup)
+	}
+	if !strings.Contains(menu.Params.Get("text"), bot.text(chatID, i18n.MenuNoQuestions)) {
+		t.Errorf("menu does not explain the missing quiz: %q", menu.Params.Get("text"))
+	}
+}
+
+func TestRetiredQuestionsNeverAsked(t *testing.T) {
+	var questions []service.QuizQuestion
+	for id := 1; id <= 10; id++ {
+		questions = append(questions, service.QuizQuestion{
+			ID: id, Question: "?", Options: []string{"a", "b"},
+			Category: "Общее", Tags: []string{"общее"}, Practice: true,
+			Retired: id%2 == 0,
+		})
+	}
+	starts := map[string]func(b *Bot, chatID int64){
+		"quiz":     func(b *Bot, chatID int64) { b.startQuiz(chatID, 0) },
+		"random":   func(b *Bot, chatID int64) { b.startRandomLengthQuiz(chatID) },
+		"category": func(b *Bot, chatID int64) { b.startCategoryQuiz(chatID, "Общее") },
+		"tag":      func(b *Bot, chatID int64) { b.startTaggedQuiz(chatID, "общее") },
+		"practice": func(b *Bot, chatID int64) { b.startPractice(chatID, "") },
+	}
+	for name, start := range starts {
+		t.Run(name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.QuizQuestionCount = 0
+			cfg.RandomQuizMin, cfg.RandomQuizMax = 10, 10
+			bot, _, _ := newTestBot(t, cfg)
+			bot.quizQuestions = questions
+			const chatID = 7
+
+			start(bot, chatID)
+			session, exists := bot.getSession(chatID)
+			if !exists {
+				t.Fatal("quiz did not start")
+			}
+			if len(session.Questions) != 5 {
+				t.Errorf("quiz has %d questions, want the 5 active ones", len(session.Questions))
+			}
+			for _, question := range session.Questions {
+				if question.Retired {
+					t.Errorf("retired question %d was asked", question.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestRevealAcceptedAnswer(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	bot.quizQuestions = []service.QuizQuestion{{
+		ID: 1, Question: "Северная столица?", Options: []string{"Москва", "Санкт-Петербург"}, Correct: 1,
+		AcceptedAnswers: []string{"Петербург", "Питер"},
+	}}
+	var calls []int
+	bot.randIntn = func(n int) int {
+		calls = append(calls, n)
+		return n - 1
+	}
+	const chatID = 7
+
+	bot.handleUpdate(textUpdate(1, chatID, "/quiz"))
+	answerCurrent(bot, chatID, 2) // "Москва" - неправильный ответ
+
+	// Результат ответа заменяет сообщение с вопросом
+	want := bot.text(chatID, i18n.CorrectAnswer, "Питер")
+	edits := ft.sent("editMessageText")
+	if !slices.ContainsFunc(edits, func(r apiRequest) bool { return strings.Contains(r.Params.Get("text"), want) }) {
+		t.Errorf("edits %v, want the reveal %q", edits, want)
+	}
+	if !slices.Contains(calls, 3) {
+		t.Errorf("randIntn calls %v, want a pick among 3 phrasings", calls)
+	}
+}
+
+func TestAnswerRevealDelayIgnoresRapidTaps(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	cfg.AnswerRevealDelayMs = 300
+	bot, ft, _ := newTestBot(t, cfg)
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+	tap := func(updateID, option int) tgbotapi.Update {
+		update := callbackUpdate(updateID, chatID, fmt.Sprintf("quiz_0_%d", option))
+		update.CallbackQuery.Message.MessageID = session.MessageID
+		return update
+	}
+
+	// Второе нажатие приходит, пока ответ "обрабатывается"
+	var delays []time.Duration
+	bot.sleep = func(d time.Duration) {
+		delays = append(delays, d)
+		if len(delays) == 1 {
+			bot.handleUpdate(tap(3, 1))
+		}
+	}
+	bot.handleUpdate(tap(2, 0)) // "3" - неправильный ответ на "2 + 2?"
+
+	if !slices.Equal(delays, []time.Duration{300 * time.Millisecond}) {
+		t.Errorf("delays = %v, want one reveal delay", delays)
+	}
+	if session.CurrentQuestion != 1 || len(session.Answers) != 1 || session.Score != 0 {
+		t.Errorf("question %d, %d answers, score %d: want only the first tap counted",
+			session.CurrentQuestion, len(session.Answers), session.Score)
+	}
+
+	// Кнопки убираются до показа результата
+	markups := ft.sent("editMessageReplyMarkup")
+	if len(markups) == 0 || strings.Contains(markups[0].Params.Get("reply_markup"), "callback_data") {
+		t.Errorf("keyboard was not removed during the delay: %v", markups)
+	}
+
+	// Запоздалое нажатие под старым сообщением тоже не засчитывается
+	bot.handleUpdate(tap(4, 1))
+	if session.CurrentQuestion != 1 || len(session.Answers) != 1 {
+		t.Errorf("late tap on the old question was counted: question %d, %d answers", session.CurrentQuestion, len(session.Answers))
+	}
+}
+
+func TestQuizQuestionCount(t *testing.T) {
+	var questions []service.QuizQuestion
+	for id := 1; id <= 12; id++ {
+		questions = append(questions, service.QuizQuestion{ID: id, Question: fmt.Sprintf("Вопрос %d", id), Options: []string{"a", "b"}})
+	}
+
+	tests := []struct {
+		count, want int
+	}{
+		{5, 5},
+		{12, 12},
+		{50, 12}, // больше, чем есть вопросов - все вопросы
+		{0, 12},  // 0 - все вопросы
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		cfg.QuizQuestionCount = tt.count
+		bot, _, _ := newTestBot(t, cfg)
+		bot.quizQuestions = questions
+		const chatID = 7
+
+		bot.startQuiz(chatID, 0)
+		session, exists := bot.getSession(chatID)
+		if !exists {
+			t.Fatalf("count %d: quiz did not start", tt.count)
+		}
+		if got := session.Total(); got != tt.want {
+			t.Errorf("count %d: %d questions, want %d", tt.count, got, tt.want)
+		}
+		if ids := questionIDs(session); len(slices.Compact(slices.Sorted(slices.Values(ids)))) != len(ids) {
+			t.Errorf("count %d: repeated questions %v", tt.count, ids)
+		}
+	}
+}
+
+func TestSessionShufflesOptions(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleOptions = true
+	bot, _, _ := newTestBot(t, cfg)
+	questions := testQuestions()
+	bot.quizQuestions = questions
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+	for _, question := range session.Questions {
+		source := questions[slices.IndexFunc(questions, func(q service.QuizQuestion) bool { return q.ID == question.ID })]
+		if question.Options[question.Correct] != source.Options[source.Correct] {
+			t.Errorf("question %d: correct option %q, want %q", question.ID, question.Options[question.Correct], source.Options[source.Correct])
+		}
+	}
+	if !slices.Equal(questions[0].Options, testQuestions()[0].Options) {
+		t.Errorf("question bank was reordered: %v", questions[0].Options)
+	}
+}
+
+func TestQuizMessageEditedInPlace(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	bot.quizQuestions = append(testQuestions(), service.QuizQuestion{ID: 4, Question: "1 + 1?", Options: []string{"2", "3"}})
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+	first := session.MessageID
+	if first == 0 {
+		t.Fatal("question message ID was not stored in the session")
+	}
+	sentBefore := len(ft.texts(chatID))
+
+	// Ответ и следующий вопрос показываются в том же сообщении
+	answerCurrent(bot, chatID, 1)
+	if session.CurrentQuestion != 1 {
+		t.Fatalf("current question = %d after the answer, want 1", session.CurrentQuestion)
+	}
+	if session.MessageID != first {
+		t.Errorf("message ID changed to %d, want %d", session.MessageID, first)
+	}
+	if got := len(ft.texts(chatID)); got != sentBefore {
+		t.Errorf("sent %d new messages instead of editing", got-sentBefore)
+	}
+	edits := ft.sent("editMessageText")
+	if len(edits) == 0 {
+		t.Fatal("quiz message was not edited")
+	}
+	for _, edit := range edits {
+		if edit.Params.Get("message_id") != strconv.Itoa(first) {
+			t.Errorf("edited message %s, want %d", edit.Params.Get("message_id"), first)
+		}
+	}
+
+	// Нажатие под старым сообщением не засчитывается текущему вопросу
+	stale := callbackUpdate(2, chatID, "quiz_1_0")
+	stale.CallbackQuery.Message.MessageID = first + 100
+	bot.handleUpdate(stale)
+	if session.CurrentQuestion != 1 {
+		t.Fatalf("answer from a stale message was accepted")
+	}
+
+	// Сообщение нельзя отредактировать - вопрос отправляется новым сообщением и его ID запоминается
+	ft.failMethod("editMessageText")
+	answerCurrent(bot, chatID, 3)
+	if session.CurrentQuestion != 2 {
+		t.Fatalf("current question = %d after the answer, want 2", session.CurrentQuestion)
+	}
+	if got := len(ft.texts(chatID)); got == sentBefore {
+		t.Fatal("no new message after the edit failed")
+	}
+	if session.MessageID == first || session.MessageID == 0 {
+		t.Errorf("message ID = %d, want the ID of the new message", session.MessageID)
+	}
+
+	// Следующий ответ принимается под новым сообщением
+	answerCurrent(bot, chatID, 4)
+	if session.CurrentQuestion != 3 {
+		t.Errorf("current question = %d after answering under the new message, want 3", session.CurrentQuestion)
+	}
+}
+
+func TestStreakShownInResults(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	var questions []service.QuizQuestion
+	for id := 1; id <= 3; id++ {
+		questions = append(questions, service.QuizQuestion{ID: id, Question: fmt.Sprintf("Вопрос %d", id), Options: []string{"да", "нет"}})
+	}
+	bot.quizQuestions = questions
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	for updateID := 1; updateID <= len(questions); updateID++ {
+		answerCurrent(bot, chatID, updateID)
+	}
+
+	var results []string
+	for _, request := range append(ft.sent("editMessageText"), ft.sent("sendMessage")...) {
+		results = append(results, request.Params.Get("text"))
+	}
+	all := strings.Join(results, "\n---\n")
+	for _, want := range []string{"🔥 Серия: 2", "🔥 Серия: 3", "+1 бонусное очко", "🔥 Лучшая серия: 3"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("messages do not mention %q:\n%s", want, all)
+		}
+	}
+}
+
+func TestInvalidAnswerCallbacksIgnored(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	bot, _, _ := newTestBot(t, cfg)
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+	// Переходим ко второму вопросу, чтобы у викторины был и прошедший вопрос
+	answerCurrent(bot, chatID, 1)
+	score, answers := session.Score, len(session.Answers)
+
+	for i, data := range []string{
+		"quiz_0_1",                 // уже отвеченный вопрос
+		"quiz_2_0",                 // вопрос, до которого игрок еще не дошел
+		"quiz_99_0",                // вопрос за концом викторины
+		"quiz_-1_0",                // отрицательный вопрос
+		"quiz_1_4",                 // вариант за концом списка
+		"quiz_1_-1",                // отрицательный вариант
+		"quiz_1_99999999999999999", // число не помещается в int
+		"quiz_1",                   // нет варианта
+		"quiz_x_y",                 // не числа
+		"confirm_7_0",              // подтверждение чужого вопроса
+	} {
+		// Выход за границы среза паникует, и handleUpdate уронил бы тест
+		update := callbackUpdate(i+2, chatID, data)
+		update.CallbackQuery.Message.MessageID = session.MessageID
+		bot.handleUpdate(update)
+
+		if session.Score != score || len(session.Answers) != answers || session.CurrentQuestion != 1 {
+			t.Fatalf("%s: score %d, %d answers, question %d - the callback was counted",
+				data, session.Score, len(session.Answers), session.CurrentQuestion)
+		}
+	}
+
+	// Игра продолжается как обычно
+	answerCurrent(bot, chatID, 20)
+	if session.CurrentQuestion != 2 || len(session.Answers) != answers+1 {
+		t.Errorf("valid answer after invalid callbacks: question %d, %d answers", session.CurrentQuestion, len(session.Answers))
+	}
+}
+
+func TestDoubleAnswerCountedOnce(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	cfg.AnswerRevealDelayMs = 0
+	bot, _, _ := newTestBot(t, cfg)
+	bot.quizQuestions = testQuestions()
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+	messageID := session.MessageID
+
+	// Оба нажатия пришли под одним сообщением на один и тот же вопрос: "4" - правильный ответ на "2 + 2?"
+	for updateID := 1; updateID <= 2; updateID++ {
+		update := callbackUpdate(updateID, chatID, "quiz_0_1")
+		update.CallbackQuery.Message.MessageID = messageID
+		bot.handleUpdate(update)
+	}
+
+	if session.Score != 1 {
+		t.Errorf("score = %d, want 1", session.Score)
+	}
+	if len(session.Answers) != 1 || session.CurrentQuestion != 1 {
+		t.Errorf("%d answers, question %d: want one answer and the second question", len(session.Answers), session.CurrentQuestion)
+	}
+
+	// Повторное нажатие, пока ответ на текущий вопрос уже принят, тоже не засчитывается
+	session.CurrentAnswered = true
+	update := callbackUpdate(3, chatID, "quiz_1_1")
+	update.CallbackQuery.Message.MessageID = session.MessageID
+	bot.handleUpdate(update)
+	if session.Score != 1 || len(session.Answers) != 1 {
+		t.Errorf("answer accepted while the question was already answered: score %d, %d answers", session.Score, len(session.Answers))
+	}
+}
+
+func TestExplanationShownAfterAnswer(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	bot, ft, _ := newTestBot(t, cfg)
+	bot.quizQuestions = []service.QuizQuestion{
+		{ID: 1, Question: "2 + 2?", Options: []string{"4", "5"}, Explanation: "Считаем *на пальцах*"},
+		{ID: 2, Question: "3 + 3?", Options: []string{"6", "7"}},
+		{ID: 3, Question: "4 + 4?", Options: []string{"8", "9"}},
+	}
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	// answer отвечает на текущий вопрос и возвращает тексты, которыми бот отредактировал сообщение
+	answer := func(updateID int) string {
+		before := len(ft.sent("editMessageText"))
+		answerCurrent(bot, chatID, updateID)
+		var texts []string
+		for _, edit := range ft.sent("editMessageText")[before:] {
+			texts = append(texts, edit.Params.Get("text"))
+		}
+		return strings.Join(texts, "\n---\n")
+	}
+
+	// Разметка из файла вопросов экранируется, чтобы Telegram принял сообщение
+	if result := answer(1); !strings.Contains(result, `ℹ️ Считаем \*на пальцах\*`) {
+		t.Errorf("explanation not shown after the answer:\n%s", result)
+	}
+	if result := answer(2); strings.Contains(result, "ℹ️") {
+		t.Errorf("question without an explanation shows one:\n%s", result)
+	}
+}
+
+func TestParseQuestionCount(t *testing.T) {
+	tests := []struct {
+		args string
+		want int
+	}{
+		{"5", 5},
+		{" 3 ", 3},
+		{"12", 12},
+		{"13", 12}, // больше, чем есть вопросов
+		{"1000000", 12},
+		{"", 0},
+		{"0", 0},
+		{"-4", 0},
+		{"пять", 0},
+		{"5 вопросов", 0},
+		{"2.5", 0},
+	}
+	for _, tt := range tests {
+		if got := parseQuestionCount(tt.args, 12); got != tt.want {
+			t.Errorf("parseQuestionCount(%q, 12) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestQuizCountCommand(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantTotal int // 0 - викторина не начинается, бот предлагает выбрать категорию
+	}{
+		{"/quiz 2", 2},
+		{"/quiz 1", 1},
+		{"/quiz 50", 3},
+		{"/quiz", 0},
+		{"/quiz abc", 0},
+		{"/quiz 0", 0},
+	}
+	for i, tt := range tests {
+		bot, ft, _ := newTestBot(t, testConfig(t))
+		bot.quizQuestions = testQuestions()
+		chatID := int64(100 + i)
+
+		bot.handleUpdate(textUpdate(1, chatID, tt.text))
+		session, exists := bot.getSession(chatID)
+		switch {
+		case tt.wantTotal == 0 && exists:
+			t.Errorf("%s: quiz started with %d questions, want the category picker", tt.text, session.Total())
+		case tt.wantTotal == 0:
+			if texts := ft.texts(chatID); len(texts) != 1 || !strings.Contains(texts[0], bot.text(chatID, i18n.ChooseCategory)) {
+				t.Errorf("%s: replied %q, want the category picker", tt.text, texts)
+			}
+		case !exists:
+			t.Errorf("%s: quiz did not start", tt.text)
+		case session.Total() != tt.wantTotal:
+			t.Errorf("%s: %d questions, want %d", tt.text, session.Total(), tt.wantTotal)
+		}
+	}
+}
+
+func TestTypingAction(t *testing.T) {
+	action := typingAction(42)
+	if action.ChatID != 42 || action.Action != tgbotapi.ChatTyping {
+		t.Errorf("typingAction(42) = chat %d, action %q, want chat 42, %q", action.ChatID, action.Action, tgbotapi.ChatTyping)
+	}
+	if action.ChannelUsername != "" {
+		t.Errorf("typingAction(42) has channel %q", action.ChannelUsername)
+	}
+}
+
+func TestTypingDuringAnswerDelay(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.AnswerDelayMs = 500
+	bot, ft, _ := newTestBot(t, cfg)
+	bot.quizQuestions = testQuestions()
+	const chatID = 7
+
+	// "Печатает..." должно уйти до паузы, иначе игрок весь интервал видит молчащий чат
+	typingBeforeSleep := false
+	bot.sleep = func(time.Duration) {
+		for _, request := range ft.sent("sendChatAction") {
+			if request.Params.Get("chat_id") == "7" && request.Params.Get("action") == "typing" {
+				typingBeforeSleep = true
+			}
+		}
+	}
+
+	bot.startQuiz(chatID, 0)
+	answerCurrent(bot, chatID, 1)
+	if !typingBeforeSleep {
+		t.Error("typing action was not sent before the delay")
+	}
+
+	// Без паузы действие не нужно
+	cfg = testConfig(t)
+	cfg.AnswerDelayMs = 0
+	bot, ft, _ = newTestBot(t, cfg)
+	bot.quizQuestions = testQuestions()
+	bot.startQuiz(chatID, 0)
+	answerCurrent(bot, chatID, 1)
+	if actions := ft.sent("sendChatAction"); len(actions) != 0 {
+		t.Errorf("sent %d chat actions without a delay", len(actions))
+	}
+}
+
+func TestAnswerDelay(t *testing.T) {
+	for _, delayMs := range []int{250, 0} {
+		cfg := testConfig(t)
+		cfg.AnswerDelayMs = delayMs
+		bot, _, _ := newTestBot(t, cfg)
+		bot.quizQuestions = testQuestions()
+		var delays []time.Duration
+		bot.sleep = func(d time.Duration) { delays = append(delays, d) }
+		const chatID = 7
+
+		bot.startQuiz(chatID, 0)
+		for updateID := 1; updateID <= len(testQuestions()); updateID++ {
+			answerCurrent(bot, chatID, updateID)
+		}
+
+		// Пауза после каждого ответа, включая последний перед итогами; 0 - без пауз
+		var want []time.Duration
+		if delayMs > 0 {
+			want = slices.Repeat([]time.Duration{time.Duration(delayMs) * time.Millisecond}, len(testQuestions()))
+		}
+		if !slices.Equal(delays, want) {
+			t.Errorf("delay %d ms: slept %v, want %v", delayMs, delays, want)
+		}
+	}
+}
+
+// lifelineUpdate - нажатие кнопки подсказки под текущим сообщением викторины
+func lifelineUpdate(bot *Bot, updateID int, chatID int64, data string) tgbotapi.Update {
+	update := callbackUpdate(updateID, chatID, data)
+	if session, exists := bot.getSession(chatID); exists {
+		update.CallbackQuery.Message.MessageID = session.MessageID
+	}
+	return update
+}
+
+func TestSkipQuestion(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.QuizSkips = 2
+	bot, ft, _ := newTestBot(t, cfg)
+	bot.quizQuestions = append(testQuestions(), service.QuizQuestion{ID: 4, Question: "1 + 1?", Options: []string{"2", "3"}})
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+	if sends := ft.sent("sendMessage"); !strings.Contains(sends[len(sends)-1].Params.Get("reply_markup"), "skip_quiz") {
+		t.Fatal("question has no skip button")
+	}
+
+	lastMarkup := func() string {
+		edits := ft.sent("editMessageText")
+		if len(edits) == 0 {
+			t.Fatal("question was not redrawn after the skip")
+		}
+		return edits[len(edits)-1].Params.Get("reply_markup")
+	}
+
+	// Пропуск переводит к следующему вопросу без очков и тратит один пропуск
+	bot.handleUpdate(lifelineUpdate(bot, 1, chatID, "skip_quiz"))
+	if session.CurrentQuestion != 1 || session.SkipsRemaining != 1 || session.Score != 0 {
+		t.Fatalf("after a skip: question %d, skips %d, score %d, want 1, 1, 0", session.CurrentQuestion, session.SkipsRemaining, session.Score)
+	}
+	if !strings.Contains(lastMarkup(), "skip_quiz") {
+		t.Error("skip button hidden while a skip is left")
+	}
+
+	// Последний пропуск убирает кнопку
+	bot.handleUpdate(lifelineUpdate(bot, 2, chatID, "skip_quiz"))
+	if session.CurrentQuestion != 2 || session.SkipsRemaining != 0 {
+		t.Fatalf("after the last skip: question %d, skips %d, want 2 and 0", session.CurrentQuestion, session.SkipsRemaining)
+	}
+	if strings.Contains(lastMarkup(), "skip_quiz") {
+		t.Error("skip button shown with no skips left")
+	}
+
+	// Нажатие устаревшей кнопки без пропусков игнорируется
+	bot.handleUpdate(lifelineUpdate(bot, 3, chatID, "skip_quiz"))
+	if session.CurrentQuestion != 2 || session.SkipsRemaining != 0 {
+		t.Errorf("skip with none left: question %d, skips %d, want 2 and 0", session.CurrentQuestion, session.SkipsRemaining)
+	}
+}
+
+func TestFiftyFiftyLifeline(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	bot.quizQuestions = testQuestions()
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+	correct := session.Questions[0].Correct
+
+	bot.handleUpdate(lifelineUpdate(bot, 1, chatID, "fifty_fifty"))
+	if !session.FiftyFiftyUsed {
+		t.Fatal("lifeline was not used")
+	}
+	edits := ft.sent("editMessageReplyMarkup")
+	if len(edits) != 1 {
+		t.Fatalf("keyboard redrawn %d times, want 1", len(edits))
+	}
+	markup := edits[0].Params.Get("reply_markup")
+	if !strings.Contains(markup, fmt.Sprintf(`"quiz_0_%d"`, correct)) {
+		t.Errorf("correct option %d removed: %s", correct, markup)
+	}
+	// Из трех неправильных вариантов убирается половина с округлением вниз
+	if got := strings.Count(markup, `"quiz_0_`); got != 3 {
+		t.Errorf("%d options left, want 3", got)
+	}
+	if strings.Contains(markup, "fifty_fifty") {
+		t.Error("lifeline button shown after it was used")
+	}
+
+	// Подсказка одна на викторину: повторное нажатие ничего не меняет
+	hidden := slices.Clone(session.HiddenOptions)
+	bot.handleUpdate(lifelineUpdate(bot, 2, chatID, "fifty_fifty"))
+	if got := len(ft.sent("editMessageReplyMarkup")); got != 1 || !slices.Equal(session.HiddenOptions, hidden) {
+		t.Errorf("second press: %d redraws, hidden %v, want 1 and %v", got, session.HiddenOptions, hidden)
+	}
+
+	// На следующем вопросе все варианты снова на месте, а кнопки подсказки нет
+	answer := lifelineUpdate(bot, 3, chatID, fmt.Sprintf("quiz_0_%d", correct))
+	bot.handleUpdate(answer)
+	next := ft.sent("editMessageText")
+	if session.CurrentQuestion != 1 || len(next) == 0 {
+		t.Fatalf("current question = %d after the answer, want 1", session.CurrentQuestion)
+	}
+	markup = next[len(next)-1].Params.Get("reply_markup")
+	if strings.Count(markup, `"quiz_1_`) != 4 || strings.Contains(markup, "fifty_fifty") {
+		t.Errorf("next question keyboard: %s", markup)
+	}
+}
@@ -0,0 +1,47 @@
+package telegram
+
+import (
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// rememberLanguage запоминает язык чата по language_code пользователя из обновления.
+// В группе язык берется у последнего написавшего участника
+func (b *Bot) rememberLanguage(chatID int64, user *tgbotapi.User) {
+	if user == nil || user.LanguageCode == "" {
+		return
+	}
+
+	b.languages.set(chatID, b.localizer.Language(user.LanguageCode))
+}
+
+// text возвращает сообщение key на языке чата (по умолчанию - на русском)
+func (b *Bot) text(chatID int64, key i18n.Key, args ...any) string {
+	return b.localizer.T(b.lang(chatID), key, args...)
+}
+
+// lang возвращает язык чата, пустой - язык по умолчанию
+func (b *Bot) lang(chatID int64) string {
+	lang, _ := b.languages.get(chatID)
+	return lang
+}
+
+// formatPercentage выводит процент с точностью из конфигурации и десятичным разделителем языка чата
+func (b *Bot) formatPercentage(chatID int64, score, total int) string {
+	return b.localizer.Number(b.lang(chatID), service.FormatPercentage(score, total, b.cfg().PercentPrecision))
+}
+
+// ordinal выводит место в рейтинге по правилам языка чата
+func (b *Bot) ordinal(chatID int64, position int) string {
+	return b.localizer.Ordinal(b.lang(chatID), position)
+}
+
+// entryDate выводит дату лучшего результата в формате языка чата. У записей
+// без времени остается сохраненная строка Date
+func (b *Bot) entryDate(chatID int64, entry service.LeaderboardEntry) string {
+	if entry.CreatedAt.IsZero() {
+		return entry.Date
+	}
+	return b.localizer.DateTime(b.lang(chatID), entry.CreatedAt)
+}
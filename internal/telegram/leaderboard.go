@@ -0,0 +1,388 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// displayName возвращает @username или имя, если username не задан
+func displayName(entry service.LeaderboardEntry) string {
+	if entry.Username != "" {
+		return "@" + entry.Username
+	}
+	return entry.FirstName
+}
+
+// medal возвращает медаль для места в лидерборде
+func medal(position int) string {
+	switch position {
+	case 1:
+		return "🥇"
+	case 2:
+		return "🥈"
+	case 3:
+		return "🥉"
+	}
+	return "🔸"
+}
+
+// leaderboardRow форматирует строку лидерборда в HTML: место, игрок, результат и строка с деталями
+func (b *Bot) leaderboardRow(chatID int64, position int, entry service.LeaderboardEntry, details string) string {
+	return fmt.Sprintf("%s %d. %s - %s%% (%d/%d)\n   %s\n\n",
+		medal(position), position, html.EscapeString(displayName(entry)),
+		b.formatPercentage(chatID, entry.Score, entry.Total), entry.Score, entry.Total, details)
+}
+
+// leaderboardKeyboard - кнопки под лидербордом
+func (b *Bot) leaderboardKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonStartQuiz), "start_quiz"),
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonMainMenu), "back_to_menu"),
+		),
+	)
+}
+
+// leaderboardPeriods - периоды лидерборда в порядке кнопок: название и callback
+var leaderboardPeriods = []struct {
+	period   service.Period
+	title    i18n.Key
+	callback string
+}{
+	{service.PeriodAll, i18n.LeaderboardAllTime, "leaderboard"},
+	{service.PeriodWeek, i18n.LeaderboardWeek, "leaderboard_week"},
+	{service.PeriodMonth, i18n.LeaderboardMonth, "leaderboard_month"},
+}
+
+// periodKeyboard - кнопки лидерборда с переключателем периода, текущий период отмечен
+func (b *Bot) periodKeyboard(chatID int64, current service.Period) tgbotapi.InlineKeyboardMarkup {
+	var periodRow []tgbotapi.InlineKeyboardButton
+	for _, p := range leaderboardPeriods {
+		title := b.text(chatID, p.title)
+		if p.period == current {
+			title = "• " + title
+		}
+		periodRow = append(periodRow, tgbotapi.NewInlineKeyboardButtonData(title, p.callback))
+	}
+
+	keyboard := b.leaderboardKeyboard(chatID)
+	keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{periodRow}, keyboard.InlineKeyboard...)
+	return keyboard
+}
+
+// leaderboardError логирует ошибку хранилища лидерборда и сообщает о ней пользователю
+func (b *Bot) leaderboardError(chatID int64, err error) {
+	b.logger.Error("loading leaderboard failed", "chat_id", chatID, "err", err)
+	b.sendMessage(chatID, b.text(chatID, i18n.LeaderboardUnavail))
+}
+
+// leaderboardHidden проверяет, скрыт ли лидерборд в чате, и если да - сообщает об этом
+func (b *Bot) leaderboardHidden(chatID int64) bool {
+	if !b.preferences.Get(chatID).HideLeaderboard {
+		return false
+	}
+
+	b.sendMessage(chatID, b.text(chatID, i18n.LeaderboardHidden))
+	return true
+}
+
+// handleLeaderboardVisibility скрывает или показывает лидерборд в группе (для админов чата и бота)
+func (b *Bot) handleLeaderboardVisibility(chat *tgbotapi.Chat, userID int64, hide bool) {
+	if chat.IsPrivate() {
+		b.sendMessage(chat.ID, b.text(chat.ID, i18n.VisibilityGroupsOnly))
+		return
+	}
+
+	if !b.cfg().IsAdmin(userID) {
+		member, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+			ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chat.ID, UserID: userID},
+		})
+		if err != nil {
+			b.logger.Error("getting chat member failed", "chat_id", chat.ID, "user_id", userID, "err", err)
+			return
+		}
+		if !member.IsCreator() && !member.IsAdministrator() {
+			b.sendMessage(chat.ID, b.text(chat.ID, i18n.VisibilityAdminsOnly))
+			return
+		}
+	}
+
+	prefs := b.preferences.Get(chat.ID)
+	prefs.HideLeaderboard = hide
+	b.preferences.Set(chat.ID, prefs)
+
+	if hide {
+		b.sendMessage(chat.ID, b.text(chat.ID, i18n.LeaderboardHiddenSet))
+	} else {
+		b.sendMessage(chat.ID, b.text(chat.ID, i18n.LeaderboardShown))
+	}
+}
+
+// leaderboardPageSize - сколько игроков на одной странице лидерборда
+const leaderboardPageSize = 10
+
+// parseLeaderboardPage разбирает номер страницы из callback "leaderboard_page_<n>", при ошибке - первая страница
+func parseLeaderboardPage(data string) int {
+	page, err := strconv.Atoi(strings.TrimPrefix(data, "leaderboard_page_"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// handleLeaderboard показывает лидерборд за период. Лидерборд за все время листается
+// по leaderboardPageSize игроков (page считается с 1), за неделю и месяц - только топ.
+// При messageID != 0 редактируется сообщение с предыдущей страницей. Под лидербордом за все время
+// игрок userID видит свое место
+func (b *Bot) handleLeaderboard(chatID int64, messageID int, userID int64, period service.Period, page int) {
+	if b.leaderboardHidden(chatID) {
+		return
+	}
+
+	// Игроки с малым числом попыток ни в один топ не попадают, но видят свое место через /rank
+	var top []service.LeaderboardEntry
+	var nav []tgbotapi.InlineKeyboardButton
+	var err error
+	var footer string
+	title := b.text(chatID, i18n.LeaderboardTop)
+	offset := 0
+	switch period {
+	case service.PeriodWeek:
+		top, err = b.leaderboardService.GetTopByPeriod(leaderboardPageSize, period, b.cfg().MinAttempts)
+		title = b.text(chatID, i18n.LeaderboardTopWeek)
+	case service.PeriodMonth:
+		top, err = b.leaderboardService.GetTopByPeriod(leaderboardPageSize, period, b.cfg().MinAttempts)
+		title = b.text(chatID, i18n.LeaderboardTopMonth)
+	default:
+		// Страница и место игрока считаются по одной загрузке и сортировке лидерборда
+		var lb service.LeaderboardPage
+		offset = (page - 1) * leaderboardPageSize
+		lb, err = b.leaderboardService.GetTopWithPosition(offset, leaderboardPageSize, b.cfg().MinAttempts, userID)
+
+		pages := (lb.Players + leaderboardPageSize - 1) / leaderboardPageSize
+		if err == nil && page > 1 && len(lb.Entries) == 0 && pages > 0 {
+			// Лидерборд мог сократиться, пока листали - показываем последнюю страницу
+			page = pages
+			offset = (page - 1) * leaderboardPageSize
+			lb, err = b.leaderboardService.GetTopWithPosition(offset, leaderboardPageSize, b.cfg().MinAttempts, userID)
+		}
+		top = lb.Entries
+		if lb.Position > 0 {
+			footer = b.text(chatID, i18n.LeaderboardYou, b.ordinal(chatID, lb.Position), lb.Players)
+		}
+		if page > 1 {
+			title = b.text(chatID, i18n.LeaderboardPlayers, offset+1, offset+len(top))
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️", fmt.Sprintf("leaderboard_page_%d", page-1)))
+		}
+		if page < pages {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("▶️", fmt.Sprintf("leaderboard_page_%d", page+1)))
+		}
+	}
+
+	if err != nil {
+		b.leaderboardError(chatID, err)
+		return
+	}
+
+	message := b.text(chatID, i18n.LeaderboardEmpty)
+	if len(top) > 0 {
+		message = "🏆 <b>" + title + "</b>\n\n"
+		for i, entry := range top {
+			details := "📅 " + html.EscapeString(b.entryDate(chatID, entry))
+			if entry.Bonus > 0 {
+				details += fmt.Sprintf(" · ⭐ %d", entry.Bonus)
+			}
+			message += b.leaderboardRow(chatID, offset+i+1, entry, details)
+		}
+		message += footer
+	}
+
+	keyboard := b.periodKeyboard(chatID, period)
+	if len(nav) > 0 {
+		keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{nav}, keyboard.InlineKeyboard...)
+	}
+
+	if messageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, message, keyboard)
+		edit.ParseMode = "HTML"
+		if _, err := b.send(edit); err != nil {
+			b.logger.Error("editing leaderboard failed", "chat_id", chatID, "err", err)
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+
+	if err := b.sendLongMessage(msg); err != nil {
+		b.logger.Error("sending leaderboard failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// handleActiveLeaderboard показывает игроков, прошедших больше всего викторин
+func (b *Bot) handleActiveLeaderboard(chatID int64) {
+	if b.leaderboardHidden(chatID) {
+		return
+	}
+
+	top, err := b.leaderboardService.GetTopByAttempts(10, b.cfg().MinAttempts)
+	if err != nil {
+		b.leaderboardError(chatID, err)
+		return
+	}
+
+	if len(top) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.ActiveLeaderboardNil))
+		return
+	}
+
+	message := "🏃 <b>" + b.text(chatID, i18n.ActiveLeaderboard) + "</b>\n\n"
+
+	for i, entry := range top {
+		message += b.leaderboardRow(chatID, i+1, entry, b.text(chatID, i18n.QuizzesPlayed, entry.Attempts))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = b.leaderboardKeyboard(chatID)
+
+	if err := b.sendLongMessage(msg); err != nil {
+		b.logger.Error("sending active leaderboard failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// handleCompositeLeaderboard показывает рейтинг, учитывающий и точность, и время прохождения
+func (b *Bot) handleCompositeLeaderboard(chatID int64) {
+	if b.leaderboardHidden(chatID) {
+		return
+	}
+
+	penalty := b.cfg().CompositeTimePenalty
+	top, err := b.leaderboardService.GetTopComposite(10, penalty, b.cfg().MinAttempts)
+	if err != nil {
+		b.leaderboardError(chatID, err)
+		return
+	}
+
+	if len(top) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.CompositeEmpty))
+		return
+	}
+
+	message := b.text(chatID, i18n.CompositeTitle, penalty)
+
+	for i, entry := range top {
+		message += b.leaderboardRow(chatID, i+1, entry, b.text(chatID, i18n.CompositeDetails,
+			entry.Duration, service.CompositeScore(entry, penalty)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = b.leaderboardKeyboard(chatID)
+
+	if err := b.sendLongMessage(msg); err != nil {
+		b.logger.Error("sending composite leaderboard failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// handleRank сообщает пользователю только его место в лидерборде. Место считается с тем же
+// порогом попыток, что и в лидерборде, поэтому совпадает с местом под лидербордом
+func (b *Bot) handleRank(chatID, userID int64) {
+	if b.leaderboardHidden(chatID) {
+		return
+	}
+
+	position, players, err := b.leaderboardService.GetUserRank(userID, b.cfg().MinAttempts)
+	if err != nil {
+		b.leaderboardError(chatID, err)
+		return
+	}
+	if position == -1 {
+		b.sendMessage(chatID, b.text(chatID, i18n.RankMissing))
+		return
+	}
+	b.sendMessage(chatID, b.text(chatID, i18n.RankPosition, b.ordinal(chatID, position), players))
+}
+
+// handleStats показывает личную статистику игрока: место, лучший результат и число викторин
+func (b *Bot) handleStats(chatID, userID int64) {
+	if b.leaderboardHidden(chatID) {
+		return
+	}
+
+	stats, found, err := b.leaderboardService.GetUserStats(userID)
+	if err != nil {
+		b.leaderboardError(chatID, err)
+		return
+	}
+	if !found {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoStats))
+		return
+	}
+
+	best := stats.Best
+	lastPlayed := best.LastPlayed
+	if lastPlayed == "" {
+		// Записи, сохраненные до появления LastPlayed
+		lastPlayed = best.Date
+	}
+
+	text := b.text(chatID, i18n.StatsText,
+		stats.Position, stats.Players,
+		b.formatPercentage(chatID, best.Score, best.Total), best.Score, best.Total, html.EscapeString(b.entryDate(chatID, best)),
+		best.Attempts, html.EscapeString(lastPlayed))
+	if best.Bonus > 0 {
+		text += b.text(chatID, i18n.StatsBonus, best.Bonus)
+	}
+	if days, graceLeft := b.streaks.Get(userID).Status(b.now(), b.cfg().StreakGraceDays); days > 0 {
+		text += b.text(chatID, i18n.StatsDailyStreak, days, graceLeft)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = b.leaderboardKeyboard(chatID)
+
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending stats failed", "chat_id", chatID, "err", err)
+	}
+}
+
+func (b *Bot) handleFind(chatID int64, query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		b.sendMessage(chatID, b.text(chatID, i18n.FindUsage))
+		return
+	}
+	if b.leaderboardHidden(chatID) {
+		return
+	}
+
+	found, err := b.leaderboardService.FindByUsername(query)
+	if err != nil {
+		b.leaderboardError(chatID, err)
+		return
+	}
+	if len(found) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.PlayerNotFound, query))
+		return
+	}
+
+	message := b.text(chatID, i18n.FindResults, html.EscapeString(query))
+	for _, ranked := range found {
+		message += b.leaderboardRow(chatID, ranked.Position, ranked.Entry, "📅 "+b.entryDate(chatID, ranked.Entry))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ParseMode = "HTML"
+
+	if err := b.sendLongMessage(msg); err != nil {
+		b.logger.Error("sending find result failed", "chat_id", chatID, "err", err)
+	}
+}
@@ -0,0 +1,372 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestLeaderboardLocaleFormatting(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.PercentPrecision = 1
+	bot, ft, _ := newTestBot(t, cfg)
+
+	for userID, score := range map[int64]int{1: 3, 2: 2} {
+		if _, err := bot.leaderboardService.AddEntry(userID, "", "Player", score, 3, 0, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bot.rememberLanguage(2, &tgbotapi.User{ID: 2, LanguageCode: "en"})
+	bot.handleRank(2, 2)
+	bot.handleRank(1, 1)
+
+	if texts := ft.texts(2); !slices.Contains(texts, "🏆 You are 2nd of 2") {
+		t.Errorf("en rank = %q", texts)
+	}
+	if texts := ft.texts(1); !slices.Contains(texts, "🏆 Вы на 1 месте из 2") {
+		t.Errorf("ru rank = %q", texts)
+	}
+
+	bot.handleLeaderboard(2, 0, 2, 0, 1)
+	bot.handleLeaderboard(1, 0, 1, 0, 1)
+
+	en := ft.texts(2)
+	if board := en[len(en)-1]; !strings.Contains(board, "66.7%") || !strings.Contains(board, "📍 Your place: 2nd of 2") {
+		t.Errorf("en leaderboard:\n%s", board)
+	}
+	ru := ft.texts(1)
+	if board := ru[len(ru)-1]; !strings.Contains(board, "66,7%") || !strings.Contains(board, "📍 Ваше место: 1 из 2") {
+		t.Errorf("ru leaderboard:\n%s", board)
+	}
+}
+
+func TestMessagesFollowChatLanguage(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	bot.rememberLanguage(2, &tgbotapi.User{ID: 2, LanguageCode: "en"})
+
+	for _, chatID := range []int64{1, 2} {
+		bot.handleStats(chatID, chatID)
+		bot.handleFind(chatID, "nobody")
+		bot.handlePause(chatID)
+		bot.handleMistakes(chatID, chatID)
+	}
+
+	wantEn := []string{
+		"📊 You have no results yet - take a quiz! 🎯",
+		"🔍 Player “nobody” is not on the leaderboard",
+		"There is no active quiz",
+		"📝 No mistakes yet - or you haven't taken a quiz 🎯",
+	}
+	if got := ft.texts(2); !slices.Equal(got, wantEn) {
+		t.Errorf("en texts = %q, want %q", got, wantEn)
+	}
+	wantRu := []string{
+		"📊 У вас пока нет результатов - пройдите викторину! 🎯",
+		"🔍 Игрок «nobody» не найден в лидерборде",
+		"Нет активной викторины",
+		"📝 Ошибок пока нет - или вы еще не проходили викторину 🎯",
+	}
+	if got := ft.texts(1); !slices.Equal(got, wantRu) {
+		t.Errorf("ru texts = %q, want %q", got, wantRu)
+	}
+}
+
+// groupTextUpdate - сообщение text от пользователя userID в группе groupID
+func groupTextUpdate(updateID int, groupID, userID int64, text string) tgbotapi.Update {
+	update := textUpdate(updateID, userID, text)
+	update.Message.Chat = &tgbotapi.Chat{ID: groupID, Type: "group"}
+	return update
+}
+
+func TestLeaderboardVisibility(t *testing.T) {
+	const group, admin = -100, 7
+	t.Setenv("BOT_ADMINS", "7")
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	if _, err := bot.leaderboardService.AddEntry(admin, "", "Champion", 9, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	lastText := func(chatID int64) string {
+		texts := ft.texts(chatID)
+		if len(texts) == 0 {
+			return ""
+		}
+		return texts[len(texts)-1]
+	}
+	menuMarkup := func(chatID int64) string {
+		bot.sendMainMenu(chatID)
+		requests := ft.sent("sendMessage")
+		return requests[len(requests)-1].Params.Get("reply_markup")
+	}
+
+	// По умолчанию лидерборд виден
+	bot.handleUpdate(groupTextUpdate(1, group, admin, "/leaderboard"))
+	if !strings.Contains(lastText(group), "Champion") {
+		t.Fatalf("leaderboard not shown by default: %q", lastText(group))
+	}
+	if !strings.Contains(menuMarkup(group), `"leaderboard"`) {
+		t.Error("menu has no leaderboard button by default")
+	}
+
+	bot.handleUpdate(groupTextUpdate(2, group, admin, "/hideleaderboard"))
+	bot.handleUpdate(groupTextUpdate(3, group, admin, "/leaderboard"))
+	if got := lastText(group); got != bot.text(group, i18n.LeaderboardHidden) {
+		t.Errorf("hidden leaderboard replied %q", got)
+	}
+	if strings.Contains(menuMarkup(group), `"leaderboard"`) {
+		t.Error("menu shows the leaderboard button while it is hidden")
+	}
+	// Места и результаты не показываются и через команды игрока
+	for i, command := range []string{"/find Champion", "/rank", "/stats"} {
+		bot.handleUpdate(groupTextUpdate(10+i, group, admin, command))
+		if got := lastText(group); got != bot.text(group, i18n.LeaderboardHidden) {
+			t.Errorf("%s with the leaderboard hidden replied %q", command, got)
+		}
+	}
+
+	// Настройка группы не влияет на личные чаты
+	bot.handleUpdate(textUpdate(4, admin, "/leaderboard"))
+	if !strings.Contains(lastText(admin), "Champion") {
+		t.Errorf("private leaderboard affected by the group setting: %q", lastText(admin))
+	}
+
+	bot.handleUpdate(groupTextUpdate(5, group, admin, "/showleaderboard"))
+	bot.handleUpdate(groupTextUpdate(6, group, admin, "/leaderboard"))
+	if !strings.Contains(lastText(group), "Champion") {
+		t.Errorf("leaderboard not shown again: %q", lastText(group))
+	}
+}
+
+func TestRankBelowMinAttempts(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MinAttempts = 2
+	bot, ft, _ := newTestBot(t, cfg)
+	const rookie, regular = 7, 8
+	if _, err := bot.leaderboardService.AddEntry(rookie, "", "Rookie", 10, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := bot.leaderboardService.AddEntry(regular, "", "Regular", 5, 10, 0, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bot.handleUpdate(textUpdate(1, rookie, "/leaderboard"))
+	board := ft.texts(rookie)
+	if len(board) != 1 || strings.Contains(board[0], "Rookie") || !strings.Contains(board[0], "Regular") {
+		t.Fatalf("leaderboard with min attempts 2:\n%q", board)
+	}
+
+	// Свое место игрок ниже порога все равно видит
+	bot.handleUpdate(textUpdate(2, rookie, "/rank"))
+	texts := ft.texts(rookie)
+	if got, want := texts[len(texts)-1], bot.text(rookie, i18n.RankPosition, bot.ordinal(rookie, 1), 2); got != want {
+		t.Errorf("/rank = %q, want %q", got, want)
+	}
+
+	// Новичок выше по результату не сдвигает место в /rank: оно совпадает с местом под лидербордом
+	bot.handleUpdate(textUpdate(3, regular, "/leaderboard"))
+	bot.handleUpdate(textUpdate(4, regular, "/rank"))
+	texts = ft.texts(regular)
+	if len(texts) != 2 {
+		t.Fatalf("regular got %q, want the leaderboard and the rank", texts)
+	}
+	if footer := bot.text(regular, i18n.LeaderboardYou, bot.ordinal(regular, 1), 1); !strings.Contains(texts[0], footer) {
+		t.Errorf("leaderboard footer missing %q:\n%s", footer, texts[0])
+	}
+	if got, want := texts[1], bot.text(regular, i18n.RankPosition, bot.ordinal(regular, 1), 1); got != want {
+		t.Errorf("/rank = %q, want %q to match the leaderboard", got, want)
+	}
+}
+
+func TestStatsShowDailyStreak(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.StreakGraceDays = 1
+	bot, ft, _ := newTestBot(t, cfg)
+	const chatID = 5
+
+	day := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	bot.now = func() time.Time { return day }
+	playQuiz := func(updateID int) {
+		bot.handleUpdate(textUpdate(updateID, chatID, "/quiz 1"))
+		answerCurrent(bot, chatID, updateID+1)
+		if _, exists := bot.getSession(chatID); exists {
+			t.Fatal("quiz did not finish")
+		}
+	}
+	lastText := func() string {
+		texts := ft.texts(chatID)
+		return texts[len(texts)-1]
+	}
+
+	playQuiz(1)
+	day = day.AddDate(0, 0, 2) // один пропущенный день прощается
+	playQuiz(3)
+
+	bot.handleStats(chatID, chatID)
+	if want := bot.text(chatID, i18n.StatsDailyStreak, 2, 0); !strings.HasSuffix(lastText(), want) {
+		t.Errorf("stats:\n%s\nwant the streak %q", lastText(), want)
+	}
+
+	day = day.AddDate(0, 0, 2)
+	bot.handleStats(chatID, chatID)
+	if strings.Contains(lastText(), "📆") {
+		t.Errorf("broken streak is still shown:\n%s", lastText())
+	}
+}
+
+func TestLeaderboardPeriodToggle(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+	if _, err := bot.leaderboardService.AddEntry(8, "", "Newcomer", 9, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	old, err := json.Marshal(service.LeaderboardEntry{UserID: 9, FirstName: "Oldtimer", Score: 10, Total: 10, Percentage: 100,
+		Attempts: 1, CreatedAt: time.Now().AddDate(0, -2, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bot.leaderboardService.Store().Set("leaderboard", "9", old); err != nil {
+		t.Fatal(err)
+	}
+	last := func() apiRequest {
+		requests := ft.sent("sendMessage")
+		return requests[len(requests)-1]
+	}
+
+	tests := []struct {
+		callback, title, button string
+		withOld                 bool
+	}{
+		{"leaderboard_week", "Топ 10 игроков за неделю", "• За неделю", false},
+		{"leaderboard_month", "Топ 10 игроков за месяц", "• За месяц", false},
+		{"leaderboard", "Топ 10 игроков", "• За все время", true},
+	}
+	for i, tt := range tests {
+		bot.handleUpdate(callbackUpdate(i+1, chatID, tt.callback))
+		text, markup := last().Params.Get("text"), last().Params.Get("reply_markup")
+		if !strings.Contains(text, tt.title) || !strings.Contains(text, "Newcomer") {
+			t.Errorf("%s: text = %q, want %q with the recent player", tt.callback, text, tt.title)
+		}
+		if strings.Contains(text, "Oldtimer") != tt.withOld {
+			t.Errorf("%s: old result shown = %v, want %v", tt.callback, !tt.withOld, tt.withOld)
+		}
+		if !strings.Contains(markup, tt.button) {
+			t.Errorf("%s: keyboard does not mark the period %q: %s", tt.callback, tt.button, markup)
+		}
+	}
+}
+
+func TestParseLeaderboardPage(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		{"leaderboard_page_1", 1},
+		{"leaderboard_page_2", 2},
+		{"leaderboard_page_15", 15},
+		{"leaderboard_page_0", 1},
+		{"leaderboard_page_-3", 1},
+		{"leaderboard_page_x", 1},
+		{"leaderboard_page_", 1},
+	}
+	for _, tt := range tests {
+		if got := parseLeaderboardPage(tt.data); got != tt.want {
+			t.Errorf("parseLeaderboardPage(%q) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestLeaderboardPagination(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+	// 25 игроков: у игрока i результат i из 30, поэтому на первом месте игрок 25
+	for id := int64(1); id <= 25; id++ {
+		if _, err := bot.leaderboardService.AddEntry(id+100, "", fmt.Sprintf("Player%02d", id), int(id), 30, 0, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bot.handleUpdate(textUpdate(1, chatID, "/leaderboard"))
+	first := ft.sent("sendMessage")[0]
+	if text := first.Params.Get("text"); !strings.Contains(text, "Player25") || strings.Contains(text, "Player15") {
+		t.Errorf("first page = %q, want players 25..16", text)
+	}
+	if markup := first.Params.Get("reply_markup"); !strings.Contains(markup, "leaderboard_page_2") || strings.Contains(markup, "◀️") {
+		t.Errorf("first page keyboard = %s, want only the next page button", markup)
+	}
+
+	page := func(updateID, number int) apiRequest {
+		t.Helper()
+		update := callbackUpdate(updateID, chatID, fmt.Sprintf("leaderboard_page_%d", number))
+		update.CallbackQuery.Message.MessageID = 42
+		bot.handleUpdate(update)
+		edits := ft.sent("editMessageText")
+		if len(edits) == 0 {
+			t.Fatalf("page %d: leaderboard message was not edited", number)
+		}
+		edit := edits[len(edits)-1]
+		if edit.Params.Get("message_id") != "42" {
+			t.Errorf("page %d: edited message %s, want 42", number, edit.Params.Get("message_id"))
+		}
+		return edit
+	}
+
+	second := page(2, 2)
+	if text := second.Params.Get("text"); !strings.Contains(text, "Player15") || strings.Contains(text, "Player16") || strings.Contains(text, "Player05") {
+		t.Errorf("second page = %q, want players 15..6", text)
+	}
+	if markup := second.Params.Get("reply_markup"); !strings.Contains(markup, "leaderboard_page_1") || !strings.Contains(markup, "leaderboard_page_3") {
+		t.Errorf("second page keyboard = %s, want both page buttons", markup)
+	}
+
+	// Страница за концом лидерборда показывается как последняя
+	last := page(3, 99)
+	if text := last.Params.Get("text"); !strings.Contains(text, "Player01") || strings.Contains(text, "Player06") {
+		t.Errorf("last page = %q, want players 5..1", text)
+	}
+	if markup := last.Params.Get("reply_markup"); strings.Contains(markup, "▶️") || !strings.Contains(markup, "leaderboard_page_2") {
+		t.Errorf("last page keyboard = %s, want only the previous page button", markup)
+	}
+}
+
+func TestLeaderboardUnavailable(t *testing.T) {
+	bot, ft, logs := newTestBot(t, testConfig(t))
+	const chatID = 7
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("github is down")
+	})}
+	failing := service.NewGistLeaderboardServiceWithClient("gist-id", "token", 0, client)
+	failing.Store().(*service.GistStore).SetRetryPolicy(service.RetryPolicy{Attempts: 1})
+	bot.leaderboardService = failing
+
+	bot.handleUpdate(textUpdate(1, chatID, "/leaderboard"))
+	if texts := ft.texts(chatID); len(texts) != 1 || texts[0] != bot.text(chatID, i18n.LeaderboardUnavail) {
+		t.Errorf("/leaderboard replied %q, want the unavailable notice", texts)
+	}
+	if _, found := logs.find(slog.LevelError, "loading leaderboard failed"); !found {
+		t.Error("leaderboard failure was not logged")
+	}
+
+	// Результат викторины не сохранился - игрок узнает об этом, а не видит пустой лидерборд
+	bot.quizQuestions = testQuestions()[:1]
+	bot.startQuiz(chatID, 0)
+	answerCurrent(bot, chatID, 2)
+	var all []string
+	for _, request := range append(ft.sent("sendMessage"), ft.sent("editMessageText")...) {
+		all = append(all, request.Params.Get("text"))
+	}
+	if !strings.Contains(strings.Join(all, "\n"), "Не удалось сохранить результат") {
+		t.Errorf("quiz result does not mention the failed save:\n%s", strings.Join(all, "\n---\n"))
+	}
+}
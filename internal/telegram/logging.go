@@ -0,0 +1,21 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// apiLogger передает логи tgbotapi в slog. Через Printf библиотека пишет запросы и ответы
+// в режиме Debug, через Println - ошибки получения обновлений
+type apiLogger struct {
+	logger *slog.Logger
+}
+
+func (l apiLogger) Println(v ...any) {
+	l.logger.Warn(strings.TrimSpace(fmt.Sprintln(v...)), "source", "tgbotapi")
+}
+
+func (l apiLogger) Printf(format string, v ...any) {
+	l.logger.Debug(strings.TrimSpace(fmt.Sprintf(format, v...)), "source", "tgbotapi")
+}
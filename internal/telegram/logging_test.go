@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestSendFailureIsLogged(t *testing.T) {
+	bot, ft, logs := newTestBot(t, testConfig(t))
+	ft.failMethod("sendMessage")
+
+	bot.sendMessage(42, "привет")
+
+	record, ok := logs.find(slog.LevelError, "sending message failed")
+	if !ok {
+		t.Fatal("no error entry for the failed send")
+	}
+	if chatID, ok := recordAttr(record, "chat_id"); !ok || chatID.Int64() != 42 {
+		t.Errorf("chat_id = %v, want 42", chatID)
+	}
+	if err, ok := recordAttr(record, "err"); !ok || err.String() == "" {
+		t.Errorf("err attribute is missing")
+	}
+}
+
+func TestAPILogger(t *testing.T) {
+	logs := &logRecorder{}
+	logger := apiLogger{logger: slog.New(logs)}
+
+	logger.Println(errors.New("connection reset"))
+	logger.Printf("Endpoint: %s, params: %v\n", "getMe", map[string]string{})
+
+	if _, ok := logs.find(slog.LevelWarn, "connection reset"); !ok {
+		t.Error("Println should log a warning")
+	}
+	if _, ok := logs.find(slog.LevelDebug, "Endpoint: getMe, params: map[]"); !ok {
+		t.Error("Printf should log a debug entry")
+	}
+}
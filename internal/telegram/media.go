@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxCaptionLength - лимит Telegram на подпись к фото в символах
+const maxCaptionLength = 1024
+
+// questionImage - файл картинки вопроса: ссылка или file_id уже загруженного в Telegram фото
+func questionImage(image string) tgbotapi.RequestFileData {
+	if strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+		return tgbotapi.FileURL(image)
+	}
+	return tgbotapi.FileID(image)
+}
+
+// sendImageQuestion отправляет вопрос с картинкой. Текст вопроса с кнопками идет подписью к фото,
+// а если он длиннее лимита подписи - отдельным сообщением сразу после фото: иначе Telegram
+// отклонил бы фото целиком. Вопрос всегда отправляется новым сообщением
+func (b *Bot) sendImageQuestion(chatID int64, session *service.QuizSession, image string, msg tgbotapi.MessageConfig) {
+	photo := tgbotapi.NewPhoto(chatID, questionImage(image))
+	combined := utf8.RuneCountInString(msg.Text) <= maxCaptionLength
+	if combined {
+		photo.Caption = msg.Text
+		photo.ParseMode = msg.ParseMode
+		photo.ReplyMarkup = msg.ReplyMarkup
+	}
+
+	sent, err := b.sendPhotoToThread(photo, session.ThreadID)
+	if err != nil {
+		// Вопрос без картинки лучше, чем пропавший вопрос
+		b.logger.Error("sending question image failed", "chat_id", chatID, "err", err)
+		combined = false
+	}
+	if combined {
+		session.MessageID = sent.MessageID
+		session.PhotoMessage = true
+		return
+	}
+
+	session.MessageID = 0
+	b.updateQuizMessage(chatID, session, msg)
+}
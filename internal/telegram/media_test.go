@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+func TestImageQuestionCaption(t *testing.T) {
+	const image = "https://example.com/Cat.jpg"
+	tests := []struct {
+		name     string
+		question string
+		combined bool
+	}{
+		{"short caption", "Кто на фото?", true},
+		{"caption over the limit", strings.Repeat("Очень длинный вопрос. ", 60), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, ft, _ := newTestBot(t, testConfig(t))
+			const chatID = 7
+
+			bot.beginQuiz(chatID, []service.QuizQuestion{
+				{ID: 1, Question: tt.question, Options: []string{"Кот", "Пес"}, Image: image},
+				{ID: 2, Question: "2 + 2?", Options: []string{"4", "3"}},
+			})
+			session, exists := bot.getSession(chatID)
+			if !exists {
+				t.Fatal("quiz did not start")
+			}
+
+			photos := ft.sent("sendPhoto")
+			if len(photos) != 1 || photos[0].Params.Get("photo") != image {
+				t.Fatalf("sent photos %v, want one with %s", photos, image)
+			}
+			caption := photos[0].Params.Get("caption")
+			texts := ft.texts(chatID)
+			if tt.combined {
+				if !strings.Contains(caption, tt.question) || !strings.Contains(photos[0].Params.Get("reply_markup"), "quiz_0_0") {
+					t.Errorf("photo caption %q has no question or keyboard", caption)
+				}
+				if len(texts) != 0 {
+					t.Errorf("question also sent as text: %q", texts)
+				}
+			} else {
+				// Фото уходит без подписи, вопрос с кнопками - следующим сообщением
+				if caption != "" {
+					t.Errorf("photo sent with an oversized caption of %d bytes", len(caption))
+				}
+				if len(texts) != 1 || !strings.Contains(texts[0], strings.TrimSpace(tt.question)) {
+					t.Fatalf("question text messages %q, want one after the photo", texts)
+				}
+			}
+
+			// Кнопки под фото отвечают на вопрос, а результат приходит новым сообщением:
+			// текст фото не редактируется
+			questionMessage := strconv.Itoa(session.MessageID)
+			answerCurrent(bot, chatID, 100)
+			if len(session.Answers) != 1 || !session.Answers[0].Correct {
+				t.Fatalf("answers: %+v", session.Answers)
+			}
+			for _, edit := range ft.sent("editMessageText") {
+				if tt.combined && edit.Params.Get("message_id") == questionMessage {
+					t.Error("tried to edit the text of a photo message")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package telegram
+
+import (
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// mistakesLimit - сколько вопросов показывает /mistakes
+const mistakesLimit = 10
+
+// handleMistakes показывает пользователю вопросы, в которых он чаще всего ошибался.
+// Правильные ответы не раскрываются, чтобы список можно было использовать для повторения
+func (b *Bot) handleMistakes(chatID, userID int64) {
+	top := b.mistakes.Top(userID, mistakesLimit)
+	if len(top) == 0 {
+		b.sendMessage(chatID, b.text(chatID, i18n.NoMistakes))
+		return
+	}
+
+	text := b.text(chatID, i18n.MistakesHeader)
+	for i, mistakes := range top {
+		text += b.text(chatID, i18n.MistakesLine, i+1, mistakes.Question, mistakes.Count)
+	}
+	text += b.text(chatID, i18n.MistakesFooter)
+
+	if err := b.sendLongMessage(tgbotapi.NewMessage(chatID, text)); err != nil {
+		b.logger.Error("sending mistakes failed", "chat_id", chatID, "err", err)
+	}
+}
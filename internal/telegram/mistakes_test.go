@@ -0,0 +1,79 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+)
+
+func TestHandleMistakes(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const chatID, otherID = 7, 8
+	questions := testQuestions()
+	bot.mistakes.Record(chatID, questions[0])
+	bot.mistakes.Record(chatID, questions[2])
+	bot.mistakes.Record(chatID, questions[2])
+
+	bot.handleUpdate(textUpdate(1, chatID, "/mistakes"))
+	want := bot.text(chatID, i18n.MistakesHeader) +
+		bot.text(chatID, i18n.MistakesLine, 1, questions[2].Question, 2) +
+		bot.text(chatID, i18n.MistakesLine, 2, questions[0].Question, 1) +
+		bot.text(chatID, i18n.MistakesFooter)
+	got := ft.texts(chatID)
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("/mistakes = %q, want %q", got, want)
+	}
+	// Правильные ответы не раскрываются
+	if strings.Contains(got[0], questions[2].Options[questions[2].Correct]) {
+		t.Errorf("/mistakes reveals the correct answer:\n%s", got[0])
+	}
+
+	bot.handleUpdate(textUpdate(2, otherID, "/mistakes"))
+	if got := ft.texts(otherID); len(got) != 1 || got[0] != bot.text(otherID, i18n.NoMistakes) {
+		t.Errorf("/mistakes without data = %q", got)
+	}
+}
+
+func TestMistakesSavedWhenQuizFinishes(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+	bot.quizQuestions = testQuestions()
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+	wrongOption := func() int {
+		return (session.Questions[session.CurrentQuestion].Correct + 1) % len(session.Questions[session.CurrentQuestion].Options)
+	}
+
+	tapOption(bot, chatID, 1, wrongOption())
+	tapOption(bot, chatID, 2, wrongOption())
+	// Пока викторина идет, ошибки копятся в сессии и в хранилище не пишутся
+	if len(session.Mistakes) != 2 {
+		t.Fatalf("session holds %d mistakes, want 2", len(session.Mistakes))
+	}
+	if top := bot.mistakes.Top(chatID, 10); len(top) != 0 {
+		t.Fatalf("mistakes saved before the quiz finished: %+v", top)
+	}
+
+	tapOption(bot, chatID, 3, session.Questions[session.CurrentQuestion].Correct)
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("quiz did not finish")
+	}
+	if top := bot.mistakes.Top(chatID, 10); len(top) != 2 {
+		t.Errorf("saved mistakes = %+v, want the two wrong answers", top)
+	}
+
+	// Ошибки викторины, из которой вышли, тоже сохраняются
+	bot.startQuiz(chatID, 0)
+	session, _ = bot.getSession(chatID)
+	tapOption(bot, chatID, 4, wrongOption())
+	bot.handleUpdate(callbackUpdate(5, chatID, "exit_quiz"))
+	total := 0
+	for _, mistakes := range bot.mistakes.Top(chatID, 10) {
+		total += mistakes.Count
+	}
+	if total != 3 {
+		t.Errorf("%d mistakes saved after exiting, want 3", total)
+	}
+}
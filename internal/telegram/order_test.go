@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+// tapOption нажимает вариант option текущего вопроса под актуальным сообщением
+func tapOption(b *Bot, chatID int64, updateID, option int) {
+	session, _ := b.getSession(chatID)
+	update := callbackUpdate(updateID, chatID, fmt.Sprintf("quiz_%d_%d", session.CurrentQuestion, option))
+	update.CallbackQuery.Message.MessageID = session.MessageID
+	b.handleUpdate(update)
+}
+
+func TestOrderedQuestionFlow(t *testing.T) {
+	tests := []struct {
+		name        string
+		reverse     bool
+		wantCorrect bool
+	}{
+		{"right order", false, true},
+		{"wrong order", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			bot, ft, _ := newTestBot(t, cfg)
+			const chatID = 7
+
+			bot.beginQuiz(chatID, []service.QuizQuestion{
+				{ID: 1, Question: "По возрастанию", Options: []string{"3", "1", "2"}, Correct: 1, OrderedAnswer: []int{1, 2, 0}},
+				{ID: 2, Question: "2 + 2?", Options: []string{"3", "4"}, Correct: 1},
+			})
+			session, exists := bot.getSession(chatID)
+			if !exists {
+				t.Fatal("quiz did not start")
+			}
+			if texts := ft.texts(chatID); !strings.Contains(texts[len(texts)-1], strings.TrimSpace(bot.text(chatID, i18n.OrderHint))) {
+				t.Errorf("question has no order hint: %q", texts[len(texts)-1])
+			}
+
+			order := slices.Clone(session.Questions[0].OrderedAnswer)
+			if tt.reverse {
+				slices.Reverse(order)
+			}
+
+			tapOption(bot, chatID, 100, order[0])
+			tapOption(bot, chatID, 101, order[0]) // повторное нажатие не считается
+			if session.CurrentAnswered || len(session.Sequence) != 1 {
+				t.Fatalf("after one tap: answered = %v, sequence = %v", session.CurrentAnswered, session.Sequence)
+			}
+			edits := ft.sent("editMessageReplyMarkup")
+			if len(edits) == 0 || !strings.Contains(edits[len(edits)-1].Params.Get("reply_markup"), "order_reset") {
+				t.Error("keyboard was not redrawn with the reset button")
+			}
+
+			tapOption(bot, chatID, 102, order[1])
+			tapOption(bot, chatID, 103, order[2])
+			if session.Answered != 1 {
+				t.Fatalf("Answered = %d after tapping every option, want 1", session.Answered)
+			}
+			if got := session.Answers[0].Correct; got != tt.wantCorrect {
+				t.Errorf("Correct = %v, want %v", got, tt.wantCorrect)
+			}
+		})
+	}
+}
+
+func TestResetOrderButton(t *testing.T) {
+	cfg := testConfig(t)
+	bot, _, _ := newTestBot(t, cfg)
+	const chatID = 8
+
+	bot.beginQuiz(chatID, []service.QuizQuestion{
+		{ID: 1, Question: "По возрастанию", Options: []string{"3", "1", "2"}, Correct: 1, OrderedAnswer: []int{1, 2, 0}},
+	})
+	session, _ := bot.getSession(chatID)
+
+	tapOption(bot, chatID, 100, 0)
+	update := callbackUpdate(101, chatID, "order_reset")
+	update.CallbackQuery.Message.MessageID = session.MessageID
+	bot.handleUpdate(update)
+	if len(session.Sequence) != 0 {
+		t.Errorf("Sequence = %v after reset, want empty", session.Sequence)
+	}
+}
@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// topSnapshot возвращает первую страницу лидерборда и место игрока userID, чтобы после
+// сохранения результата найти обогнанных им игроков. При ошибке возвращается пустая страница
+func (b *Bot) topSnapshot(userID int64) service.LeaderboardPage {
+	page, err := b.leaderboardService.GetTopWithPosition(0, leaderboardPageSize, b.cfg().MinAttempts, userID)
+	if err != nil {
+		b.logger.Error("loading leaderboard for overtake alerts failed", "user_id", userID, "err", err)
+	}
+	return page
+}
+
+// overtaken возвращает игроков из топа before, которых userID обогнал к снимку after:
+// раньше они стояли выше него (или его не было в лидерборде), а теперь ниже или выпали из топа
+func overtaken(before, after service.LeaderboardPage, userID int64) []service.LeaderboardEntry {
+	if after.Position <= 0 {
+		return nil
+	}
+
+	afterPositions := make(map[int64]int, len(after.Entries))
+	for i, entry := range after.Entries {
+		afterPositions[entry.UserID] = i + 1
+	}
+
+	var passed []service.LeaderboardEntry
+	for i, entry := range before.Entries {
+		if entry.UserID == userID {
+			continue
+		}
+		wasAbove := before.Position <= 0 || i+1 < before.Position
+		position, inTop := afterPositions[entry.UserID]
+		if wasAbove && (!inTop || position > after.Position) {
+			passed = append(passed, entry)
+		}
+	}
+	return passed
+}
+
+// notifyOvertaken сообщает игрокам, которых player обогнал между снимками before и after, о потере места.
+// Сообщения отправляются через dispatch под блокировкой чата получателя, чтобы не держать
+// блокировку чата игрока, пока уходят личные сообщения
+func (b *Bot) notifyOvertaken(player *tgbotapi.User, before, after service.LeaderboardPage) {
+	name := displayName(service.LeaderboardEntry{Username: player.UserName, FirstName: player.FirstName})
+	for _, entry := range overtaken(before, after, player.ID) {
+		userID := entry.UserID
+		b.dispatch(userID, func() { b.sendOvertakeAlert(userID, name) })
+	}
+}
+
+// sendOvertakeAlert сообщает игроку userID, что его обогнал name. Игроки, не включившие уведомления
+// или заблокировавшие бота, сообщений не получают
+func (b *Bot) sendOvertakeAlert(userID int64, name string) {
+	prefs := b.preferences.Get(userID)
+	if !prefs.NotifyOvertake || prefs.BotBlocked {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.text(userID, i18n.OvertakeAlert, name))
+	if _, err := b.send(msg); err != nil {
+		b.logger.Warn("sending overtake alert failed", "user_id", userID, "err", err)
+		if botBlocked(err) {
+			// Пользователь заблокировал бота - не пытаемся писать ему, пока он не вернется
+			prefs.BotBlocked = true
+			b.preferences.Set(userID, prefs)
+		}
+	}
+}
+
+// markActive снимает отметку о блокировке с игрока, который снова пользуется ботом
+func (b *Bot) markActive(userID int64) {
+	prefs := b.preferences.Get(userID)
+	if prefs.BotBlocked {
+		prefs.BotBlocked = false
+		b.preferences.Set(userID, prefs)
+	}
+}
+
+// botBlocked проверяет, что Telegram отказал в отправке, потому что пользователь заблокировал бота
+// или удалил свой аккаунт
+func botBlocked(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden
+}
@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func entryIDs(entries []service.LeaderboardEntry) []int64 {
+	var ids []int64
+	for _, entry := range entries {
+		ids = append(ids, entry.UserID)
+	}
+	return ids
+}
+
+func TestOvertaken(t *testing.T) {
+	page := func(position int, ids ...int64) service.LeaderboardPage {
+		var entries []service.LeaderboardEntry
+		for _, id := range ids {
+			entries = append(entries, service.LeaderboardEntry{UserID: id})
+		}
+		return service.LeaderboardPage{Entries: entries, Position: position}
+	}
+
+	tests := []struct {
+		name          string
+		before, after service.LeaderboardPage
+		want          []int64
+	}{
+		{"new player", page(-1, 1, 2, 3), page(2, 1, 7, 2, 3), []int64{2, 3}},
+		{"climbed", page(3, 1, 2, 7), page(1, 7, 1, 2), []int64{1, 2}},
+		{"pushed out of the top", page(-1, 1, 2), page(2, 1, 7), []int64{2}},
+		{"same place", page(2, 1, 7, 2), page(2, 1, 7, 2), nil},
+		{"not ranked", page(-1, 1, 2), page(-1, 1, 2), nil},
+	}
+	for _, tt := range tests {
+		if got := entryIDs(overtaken(tt.before, tt.after, 7)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: overtaken = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// overtakeAll записывает игроку результат выше всех остальных и рассылает уведомления
+func overtakeAll(t *testing.T, bot *Bot, player *tgbotapi.User, score int) {
+	t.Helper()
+	before := bot.topSnapshot(player.ID)
+	if _, err := bot.leaderboardService.AddEntry(player.ID, "", player.FirstName, score, 10, 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	bot.notifyOvertaken(player, before, bot.topSnapshot(player.ID))
+	bot.handlers.Wait()
+}
+
+func TestOvertakeAlerts(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const notified, muted, blocked = 10, 11, 12
+	for _, userID := range []int64{notified, muted, blocked} {
+		if _, err := bot.leaderboardService.AddEntry(userID, "", "Player", 5, 10, 0, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Уведомления выключены по умолчанию, muted их не включал
+	for _, userID := range []int64{notified, blocked} {
+		prefs := bot.preferences.Get(userID)
+		prefs.NotifyOvertake = true
+		bot.preferences.Set(userID, prefs)
+	}
+	ft.blockChat(blocked)
+
+	overtakeAll(t, bot, &tgbotapi.User{ID: 7, FirstName: "Runner"}, 8)
+
+	if got := ft.texts(notified); len(got) != 1 {
+		t.Errorf("overtaken player got %q, want one alert", got)
+	}
+	if got := ft.texts(muted); len(got) != 0 {
+		t.Errorf("player who did not opt in got %q", got)
+	}
+	if got := ft.texts(blocked); len(got) != 1 {
+		t.Fatalf("blocked player: %d attempts, want 1", len(got))
+	}
+	if !bot.preferences.Get(blocked).BotBlocked {
+		t.Fatal("403 did not mark the player as blocked")
+	}
+
+	// Заблокировавшему бота больше не пишем
+	overtakeAll(t, bot, &tgbotapi.User{ID: 8, FirstName: "Sprinter"}, 10)
+	if got := ft.texts(blocked); len(got) != 1 {
+		t.Errorf("blocked player: %d attempts after the second overtake, want 1", len(got))
+	}
+	if got := ft.texts(notified); len(got) != 2 {
+		t.Errorf("overtaken player got %d alerts, want 2", len(got))
+	}
+
+	// Вернувшийся игрок снова получает уведомления
+	bot.markActive(blocked)
+	if bot.preferences.Get(blocked).BotBlocked {
+		t.Error("playing again did not clear the block")
+	}
+}
+
+func TestToggleOvertakeSetting(t *testing.T) {
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+
+	bot.handleUpdate(textUpdate(1, chatID, "/settings"))
+	if len(ft.texts(chatID)) != 1 {
+		t.Fatalf("settings not shown: %q", ft.texts(chatID))
+	}
+
+	if bot.preferences.Get(chatID).NotifyOvertake {
+		t.Fatal("alerts are on before the player opted in")
+	}
+	bot.handleUpdate(callbackUpdate(2, chatID, "settings_overtake"))
+	if !bot.preferences.Get(chatID).NotifyOvertake {
+		t.Fatal("alerts still off after the toggle")
+	}
+	bot.handleUpdate(callbackUpdate(3, chatID, "settings_overtake"))
+	if bot.preferences.Get(chatID).NotifyOvertake {
+		t.Error("alerts still on after the second toggle")
+	}
+}
+
+func TestFinishQuizRecordAndAlerts(t *testing.T) {
+	tests := []struct {
+		name        string
+		minAttempts int
+		wantAlerts  int
+	}{
+		{"on the board", 0, 1},
+		// Игрок еще не прошел фильтр попыток: место как в /rank, но в видимом топе он никого не обогнал
+		{"below min attempts", 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.MinAttempts = tt.minAttempts
+			bot, ft, _ := newTestBot(t, cfg)
+			const chatID, rival = 7, 10
+			for range 2 {
+				if _, err := bot.leaderboardService.AddEntry(rival, "", "Rival", 1, 10, 0, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+			bot.preferences.Set(rival, service.ChatPreferences{NotifyOvertake: true})
+
+			bot.startQuiz(chatID, 0)
+			session, _ := bot.getSession(chatID)
+			for i, question := range session.Questions {
+				bot.handleUpdate(lifelineUpdate(bot, i+1, chatID, fmt.Sprintf("quiz_%d_%d", i, question.Correct)))
+			}
+			bot.handlers.Wait()
+
+			texts := ft.texts(chatID)
+			if final := texts[len(texts)-1]; !strings.Contains(final, "Новый рекорд") {
+				t.Errorf("final message has no record:\n%s", final)
+			}
+			if got := ft.texts(rival); len(got) != tt.wantAlerts {
+				t.Errorf("rival got %q, want %d alerts", got, tt.wantAlerts)
+			}
+		})
+	}
+}
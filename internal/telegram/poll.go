@@ -0,0 +1,188 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Режим опросов (QuizPollMode): вопрос с одним правильным ответом отправляется нативным опросом
+// Telegram типа quiz. После ответа Telegram сам показывает правильный вариант и пояснение, а ответ
+// приходит обновлением poll_answer без чата, поэтому чат каждого опроса запоминается в polls
+
+// Лимиты Telegram для опросов в символах и вариантах
+const (
+	maxPollQuestionLength    = 300
+	maxPollOptionLength      = 100
+	maxPollExplanationLength = 200
+	minPollOptions           = 2
+	maxPollOptions           = 10
+)
+
+// Telegram принимает open_period от 5 до 600 секунд, с другим ограничением времени опрос не закрывается сам
+const (
+	minPollOpenPeriod = 5 * time.Second
+	maxPollOpenPeriod = 600 * time.Second
+)
+
+// pollQuestion проверяет, можно ли отправить вопрос опросом. Вопросы на порядок, с несколькими
+// ответами, с подтверждением и с картинкой, а также не влезающие в лимиты остаются с кнопками
+func pollQuestion(question service.QuizQuestion) bool {
+	if question.Ordered() || question.Multi() || question.Important || question.Image != "" {
+		return false
+	}
+	if len(question.Options) < minPollOptions || len(question.Options) > maxPollOptions {
+		return false
+	}
+	if utf8.RuneCountInString(question.Question) > maxPollQuestionLength {
+		return false
+	}
+	for _, option := range question.Options {
+		if utf8.RuneCountInString(option) > maxPollOptionLength {
+			return false
+		}
+	}
+	return true
+}
+
+// pollExplanation возвращает пояснение для опроса в HTML курсивом, пустую строку - если пояснения нет.
+// Длинное пояснение обрезается до лимита Telegram: лимит считается по тексту без разметки
+func pollExplanation(explanation string) string {
+	if explanation == "" {
+		return ""
+	}
+	if runes := []rune(explanation); len(runes) > maxPollExplanationLength {
+		explanation = string(runes[:maxPollExplanationLength-1]) + "…"
+	}
+	return "<i>" + html.EscapeString(explanation) + "</i>"
+}
+
+// pollQuestionText - текст вопроса в опросе. Опрос не поддерживает разметку, поэтому вместо
+// заголовка вопроса перед ним стоит только номер
+func pollQuestionText(session *service.QuizSession, questionIndex int) string {
+	question := session.Questions[questionIndex].Question
+	if session.Practice || session.IsBonus(questionIndex) {
+		return question
+	}
+	text := fmt.Sprintf("%d/%d. %s", questionIndex+1, session.Total(), question)
+	if utf8.RuneCountInString(text) > maxPollQuestionLength {
+		return question
+	}
+	return text
+}
+
+// sendPollQuestion отправляет вопрос опросом-викториной. Под опросом остаются кнопки пропуска и выхода,
+// а 50/50 недоступна: варианты опроса нельзя скрыть. Если опрос отправить не удалось,
+// вопрос отправляется обычным сообщением с кнопками
+func (b *Bot) sendPollQuestion(chatID int64, session *service.QuizSession, questionIndex int, fallback tgbotapi.MessageConfig) {
+	question := session.Questions[questionIndex]
+
+	poll := tgbotapi.NewPoll(chatID, pollQuestionText(session, questionIndex), question.Options...)
+	poll.Type = "quiz"
+	poll.IsAnonymous = false
+	poll.CorrectOptionID = int64(question.Correct)
+	if explanation := pollExplanation(question.Explanation); explanation != "" {
+		poll.Explanation = explanation
+		poll.ExplanationParseMode = "HTML"
+	}
+	if limit := b.timeLimit(question); limit >= minPollOpenPeriod && limit <= maxPollOpenPeriod {
+		poll.OpenPeriod = int(limit / time.Second)
+	}
+	poll.ReplyMarkup = b.pollKeyboard(chatID, session)
+
+	sent, err := b.sendPollToThread(poll, session.ThreadID)
+	if err != nil || sent.Poll == nil {
+		b.logger.Error("sending quiz poll failed, sending buttons instead", "chat_id", chatID, "err", err)
+		session.MessageID = 0
+		b.updateQuizMessage(chatID, session, fallback)
+		return
+	}
+
+	session.MessageID = sent.MessageID
+	session.PhotoMessage = false
+	session.PollID = sent.Poll.ID
+	b.rememberPoll(session.PollID, chatID)
+}
+
+// pollKeyboard - кнопки под опросом: пропуск и выход из викторины или остановка тренировки
+func (b *Bot) pollKeyboard(chatID int64, session *service.QuizSession) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if session.Practice {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonStopPractice), "stop_practice"),
+		))
+		return tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	if session.SkipsRemaining > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonSkip, session.SkipsRemaining), "skip_quiz"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonExitQuiz), "exit_quiz"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// rememberPoll запоминает чат опроса pollID, чтобы направить ответ на него в этот чат
+func (b *Bot) rememberPoll(pollID string, chatID int64) {
+	b.pollsMu.Lock()
+	defer b.pollsMu.Unlock()
+	b.polls[pollID] = chatID
+}
+
+// forgetPoll забывает опрос, на который больше не ждем ответа
+func (b *Bot) forgetPoll(pollID string) {
+	if pollID == "" {
+		return
+	}
+	b.pollsMu.Lock()
+	defer b.pollsMu.Unlock()
+	delete(b.polls, pollID)
+}
+
+// pollChat возвращает чат опроса pollID, 0 - опрос неизвестен
+func (b *Bot) pollChat(pollID string) int64 {
+	b.pollsMu.Lock()
+	defer b.pollsMu.Unlock()
+	return b.polls[pollID]
+}
+
+// updateChat возвращает ID чата обновления, как updateChatID, а для ответа на опрос - чат опроса
+func (b *Bot) updateChat(update tgbotapi.Update) int64 {
+	if update.PollAnswer != nil {
+		return b.pollChat(update.PollAnswer.PollID)
+	}
+	return updateChatID(update)
+}
+
+// handlePollAnswer принимает ответ на опрос текущего вопроса. Ответы на старые опросы, повторные
+// ответы и ответы на паузе не засчитываются
+func (b *Bot) handlePollAnswer(answer *tgbotapi.PollAnswer) {
+	chatID := b.pollChat(answer.PollID)
+	if chatID == 0 || len(answer.OptionIDs) == 0 {
+		return
+	}
+	session, exists := b.getSession(chatID)
+	if !exists || session.PollID != answer.PollID || session.AwaitingContinue || session.CurrentAnswered {
+		return
+	}
+	answerIndex := answer.OptionIDs[0]
+	if answerIndex < 0 || answerIndex >= len(session.Questions[session.CurrentQuestion].Options) {
+		return
+	}
+	if session.Paused {
+		b.sendMessage(chatID, b.text(chatID, i18n.QuizPaused))
+		return
+	}
+
+	user := answer.User
+	result, done := b.engine.Answer(session, answerIndex)
+	b.acceptAnswer(chatID, session.MessageID, session, result, done, &user)
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pollAnswerUpdate - ответ пользователя userID на опрос pollID вариантом option
+func pollAnswerUpdate(updateID int, userID int64, pollID string, option int) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: updateID,
+		PollAnswer: &tgbotapi.PollAnswer{
+			PollID:    pollID,
+			User:      tgbotapi.User{ID: userID, FirstName: "Player"},
+			OptionIDs: []int{option},
+		},
+	}
+}
+
+func TestPollExplanation(t *testing.T) {
+	if got := pollExplanation(""); got != "" {
+		t.Errorf("empty explanation = %q, want none", got)
+	}
+	if got, want := pollExplanation("2 < 3 & 3 > 2"), "<i>2 &lt; 3 &amp; 3 &gt; 2</i>"; got != want {
+		t.Errorf("explanation = %q, want %q", got, want)
+	}
+
+	// Лимит Telegram считается по тексту без разметки и экранирования
+	long := pollExplanation(strings.Repeat("я&", 150))
+	text := strings.TrimSuffix(strings.TrimPrefix(long, "<i>"), "</i>")
+	text = strings.ReplaceAll(text, "&amp;", "&")
+	if n := utf8.RuneCountInString(text); n != maxPollExplanationLength {
+		t.Errorf("truncated explanation has %d characters, want %d", n, maxPollExplanationLength)
+	}
+	if !strings.HasSuffix(text, "…") {
+		t.Errorf("truncated explanation %q does not end with an ellipsis", text)
+	}
+}
+
+func TestPollQuestion(t *testing.T) {
+	base := service.QuizQuestion{Question: "2 + 2?", Options: []string{"3", "4"}, Correct: 1}
+	tests := []struct {
+		name   string
+		modify func(q *service.QuizQuestion)
+		want   bool
+	}{
+		{"single answer", func(q *service.QuizQuestion) {}, true},
+		{"important", func(q *service.QuizQuestion) { q.Important = true }, false},
+		{"image", func(q *service.QuizQuestion) { q.Image = "https://example.com/q.png" }, false},
+		{"one option", func(q *service.QuizQuestion) { q.Options = []string{"4"} }, false},
+		{"too many options", func(q *service.QuizQuestion) { q.Options = strings.Split("abcdefghijk", "") }, false},
+		{"long option", func(q *service.QuizQuestion) { q.Options = []string{"3", strings.Repeat("4", 101)} }, false},
+		{"long question", func(q *service.QuizQuestion) { q.Question = strings.Repeat("?", 301) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := base
+			question.Options = append([]string(nil), base.Options...)
+			tt.modify(&question)
+			if got := pollQuestion(question); got != tt.want {
+				t.Errorf("pollQuestion = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuizPollMode(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.QuizPollMode = true
+	bot, ft, _ := newTestBot(t, cfg)
+	questions := testQuestions()
+	questions[0].Explanation = "Дважды два - четыре"
+	questions[1].Explanation = "Париж - столица <Франции>"
+	bot.quizQuestions = questions
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+
+	for i := range session.Questions {
+		polls := ft.sent("sendPoll")
+		if len(polls) != i+1 {
+			t.Fatalf("question %d: %d polls sent, want %d", i+1, len(polls), i+1)
+		}
+		params := polls[i].Params
+		question := session.Questions[i]
+		if params.Get("type") != "quiz" || params.Get("is_anonymous") != "false" {
+			t.Errorf("question %d: poll type %q, anonymous %q, want a non-anonymous quiz", i+1, params.Get("type"), params.Get("is_anonymous"))
+		}
+		if got, want := params.Get("correct_option_id"), strconv.Itoa(question.Correct); got != want {
+			t.Errorf("question %d: correct_option_id = %s, want %s", i+1, got, want)
+		}
+		// Без пояснения опрос уходит без explanation и parse mode
+		if question.Explanation == "" {
+			if params.Has("explanation") || params.Has("explanation_parse_mode") {
+				t.Errorf("question %d without explanation: explanation %q, parse mode %q", i+1, params.Get("explanation"), params.Get("explanation_parse_mode"))
+			}
+		} else if params.Get("explanation") != pollExplanation(question.Explanation) || params.Get("explanation_parse_mode") != "HTML" {
+			t.Errorf("question %d: explanation %q with parse mode %q", i+1, params.Get("explanation"), params.Get("explanation_parse_mode"))
+		}
+
+		pollID := "poll-" + strconv.Itoa(session.MessageID)
+		answer := pollAnswerUpdate(i+1, chatID, pollID, question.Correct)
+		if got := bot.updateChat(answer); got != chatID {
+			t.Fatalf("poll answer routed to chat %d, want %d", got, chatID)
+		}
+		bot.handleUpdate(answer)
+		if len(session.Answers) != i+1 || !session.Answers[i].Correct {
+			t.Fatalf("answer to question %d was not accepted as correct: %+v", i+1, session.Answers)
+		}
+
+		// Повторный ответ на тот же опрос не засчитывается следующему вопросу
+		bot.handleUpdate(pollAnswerUpdate(100+i, chatID, pollID, question.Correct))
+		if len(session.Answers) != i+1 {
+			t.Fatalf("a repeated answer to question %d was counted", i+1)
+		}
+	}
+
+	// Пояснение уже показано в опросе и не повторяется в сообщении с результатом
+	for _, text := range ft.texts(chatID) {
+		if strings.Contains(text, "ℹ️") {
+			t.Errorf("result message repeats the explanation: %q", text)
+		}
+	}
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("quiz did not finish after the last poll answer")
+	}
+	if len(bot.polls) != 0 {
+		t.Errorf("finished quiz left polls %v", bot.polls)
+	}
+}
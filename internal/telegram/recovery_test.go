@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// restartBot создает новый экземпляр бота поверх хранилища старого, как после перезапуска процесса
+func restartBot(t *testing.T, old *Bot) (*Bot, *fakeTelegram) {
+	t.Helper()
+	ft := newFakeTelegram(t)
+	api, err := tgbotapi.NewBotAPIWithClient(old.cfg().Token, ft.server.URL+"/bot%s/%s", ft.server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+
+	bot := newBot(old.cfg(), api, old.leaderboardService, old.questions(), slog.New(&logRecorder{}))
+	bot.sleep = func(time.Duration) {}
+	return bot, ft
+}
+
+func TestRecoveredSessionNotice(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.PersistSessions = true
+	bot, _, _ := newTestBot(t, cfg)
+	const chatID = 7
+
+	bot.beginQuiz(chatID, []service.QuizQuestion{
+		{ID: 1, Question: "2 + 2?", Options: []string{"4", "3"}},
+		{ID: 2, Question: "3 + 3?", Options: []string{"6", "5"}},
+		{ID: 3, Question: "4 + 4?", Options: []string{"8", "7"}},
+	})
+	answerCurrent(bot, chatID, 100)
+
+	restarted, ft := restartBot(t, bot)
+	restarted.restoreSessions()
+
+	session, exists := restarted.getSession(chatID)
+	if !exists {
+		t.Fatal("session was not restored")
+	}
+	if session.CurrentQuestion != 1 || session.Score != 1 {
+		t.Fatalf("restored at question %d with score %d, want question 1 and score 1", session.CurrentQuestion, session.Score)
+	}
+
+	notice := strings.TrimSpace(restarted.text(chatID, i18n.SessionRecovered, 2))
+	texts := ft.texts(chatID)
+	if len(texts) != 1 || !strings.HasPrefix(texts[0], notice) || !strings.Contains(texts[0], "3 + 3?") {
+		t.Fatalf("after restore sent %q, want the second question with the notice", texts)
+	}
+
+	// Следующий вопрос приходит уже без уведомления
+	answerCurrent(restarted, chatID, 101)
+	count := 0
+	for _, request := range append(ft.sent("sendMessage"), ft.sent("editMessageText")...) {
+		if strings.Contains(request.Params.Get("text"), notice) {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("notice shown %d times, want once", count)
+	}
+
+	// Завершенная викторина больше не восстанавливается
+	answerCurrent(restarted, chatID, 102)
+	if _, exists := restarted.getSession(chatID); exists {
+		t.Fatal("quiz did not finish")
+	}
+	again, _ := restartBot(t, restarted)
+	again.restoreSessions()
+	if _, exists := again.getSession(chatID); exists {
+		t.Error("finished quiz was restored")
+	}
+}
+
+func TestSessionsNotRestoredWhenDisabled(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+	bot.beginQuiz(chatID, testQuestions())
+
+	restarted, ft := restartBot(t, bot)
+	restarted.restoreSessions()
+	if _, exists := restarted.getSession(chatID); exists {
+		t.Error("session restored with PersistSessions off")
+	}
+	if texts := ft.texts(chatID); len(texts) != 0 {
+		t.Errorf("sent %q after restart", texts)
+	}
+}
@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// reviewTTL - сколько хранится разбор ответов после завершения викторины
+const reviewTTL = 10 * time.Minute
+
+// quizReview - ответы завершенной викторины для постраничного разбора
+type quizReview struct {
+	answers []service.AnswerRecord
+}
+
+// saveReview сохраняет ответы для разбора, они доступны в течение reviewTTL
+func (b *Bot) saveReview(chatID int64, answers []service.AnswerRecord) {
+	b.reviews.set(chatID, &quizReview{answers: answers})
+}
+
+// handleReview показывает ответ на вопрос с индексом из callback "review_<n>".
+// "review_start" отправляет новое сообщение, остальные редактируют текущее
+func (b *Bot) handleReview(chatID int64, messageID int, data string) {
+	review, exists := b.reviews.get(chatID)
+
+	if !exists {
+		b.sendMessage(chatID, b.text(chatID, i18n.ReviewUnavailable))
+		return
+	}
+
+	arg := strings.TrimPrefix(data, "review_")
+	index, err := strconv.Atoi(arg)
+	if arg == "start" {
+		index, err = 0, nil
+	}
+	if err != nil || index < 0 || index >= len(review.answers) {
+		return
+	}
+
+	text := b.formatReviewAnswer(chatID, review.answers[index], index, len(review.answers))
+	keyboard := b.reviewKeyboard(chatID, index, len(review.answers))
+
+	if arg == "start" {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = keyboard
+		if _, err := b.send(msg); err != nil {
+			b.logger.Error("sending review failed", "chat_id", chatID, "err", err)
+		}
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard)
+	if _, err := b.send(edit); err != nil {
+		b.logger.Error("editing review failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// formatReviewAnswer форматирует ответ пользователя и правильный ответ на вопрос
+func (b *Bot) formatReviewAnswer(chatID int64, answer service.AnswerRecord, index, total int) string {
+	question := answer.Question
+
+	result := b.text(chatID, i18n.ReviewCorrect)
+	if !answer.Correct {
+		result = b.text(chatID, i18n.ReviewWrong)
+	}
+
+	selected := answer.AnswerText()
+	if selected == "" {
+		selected = "-"
+	}
+
+	text := b.text(chatID, i18n.ReviewAnswer,
+		index+1, total, question.Question, selected, question.CorrectText(), result)
+	if question.Explanation != "" {
+		text += "\n\nℹ️ " + question.Explanation
+	}
+	return text
+}
+
+// reviewKeyboard - кнопки навигации по разбору
+func (b *Bot) reviewKeyboard(chatID int64, index, total int) tgbotapi.InlineKeyboardMarkup {
+	var nav []tgbotapi.InlineKeyboardButton
+	if index > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️", fmt.Sprintf("review_%d", index-1)))
+	}
+	if index < total-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("▶️", fmt.Sprintf("review_%d", index+1)))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonBackToMenu), "back_to_menu"),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+// getSession возвращает активную викторину чата
+func (b *Bot) getSession(chatID int64) (*service.QuizSession, bool) {
+	b.sessionsMu.RLock()
+	defer b.sessionsMu.RUnlock()
+
+	session, exists := b.quizSessions[chatID]
+	return session, exists
+}
+
+// setSession сохраняет викторину чата. Если достигнут лимит MaxSessions, новая викторина
+// не создается и возвращается false. Викторина, заменяющая уже идущую в этом чате, под лимит не попадает
+func (b *Bot) setSession(chatID int64, session *service.QuizSession) bool {
+	maxSessions := b.cfg().MaxSessions
+
+	b.sessionsMu.Lock()
+	defer b.sessionsMu.Unlock()
+
+	if _, exists := b.quizSessions[chatID]; !exists && maxSessions > 0 && len(b.quizSessions) >= maxSessions {
+		b.logger.Warn("session limit reached", "chat_id", chatID, "active", len(b.quizSessions))
+		return false
+	}
+
+	b.quizSessions[chatID] = session
+	return true
+}
+
+// deleteSession удаляет и возвращает викторину чата. Если две горутины завершают
+// одну викторину одновременно, сессию получит только одна из них
+func (b *Bot) deleteSession(chatID int64) (*service.QuizSession, bool) {
+	b.sessionsMu.Lock()
+	defer b.sessionsMu.Unlock()
+
+	session, exists := b.quizSessions[chatID]
+	delete(b.quizSessions, chatID)
+	if exists {
+		b.forgetPoll(session.PollID)
+	}
+	if exists && b.cfg().PersistSessions {
+		b.savedSessions.Delete(chatID)
+	}
+	return session, exists
+}
+
+// saveSession сохраняет викторину чата в хранилище, если включено PersistSessions.
+// Викторина сохраняется при отправке каждого вопроса
+func (b *Bot) saveSession(chatID int64, session *service.QuizSession) {
+	if b.cfg().PersistSessions {
+		b.savedSessions.Save(chatID, session)
+	}
+}
+
+// restoreSessions продолжает викторины, сохраненные до перезапуска: текущий вопрос отправляется
+// заново новым сообщением вместе с уведомлением о перезапуске. Викторины на паузе ждут /resume,
+// уведомление придет вместе с вопросом после него
+func (b *Bot) restoreSessions() {
+	if !b.cfg().PersistSessions {
+		return
+	}
+
+	for chatID, session := range b.savedSessions.LoadAll() {
+		session.Recovered = true
+		session.MessageID = 0
+		session.CurrentAnswered = false
+		session.AwaitingContinue = false
+
+		if !b.setSession(chatID, session) {
+			b.savedSessions.Delete(chatID)
+			continue
+		}
+		b.logger.Info("quiz session restored", "chat_id", chatID, "question", session.CurrentQuestion+1)
+
+		if !session.Paused {
+			b.sendQuestion(chatID, session.CurrentQuestion)
+		}
+	}
+}
+
+// sessionCount возвращает число активных викторин
+func (b *Bot) sessionCount() int {
+	b.sessionsMu.RLock()
+	defer b.sessionsMu.RUnlock()
+
+	return len(b.quizSessions)
+}
+
+// getLastQuestions возвращает вопросы последней викторины чата
+func (b *Bot) getLastQuestions(chatID int64) ([]service.QuizQuestion, bool) {
+	return b.lastQuestions.get(chatID)
+}
+
+// setLastQuestions запоминает вопросы последней викторины чата
+func (b *Bot) setLastQuestions(chatID int64, questions []service.QuizQuestion) {
+	b.lastQuestions.set(chatID, questions)
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+func TestSessionLimit(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxSessions = 2
+	bot, ft, _ := newTestBot(t, cfg)
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		bot.handleUpdate(textUpdate(int(chatID), chatID, "/quiz 2"))
+	}
+
+	if got := bot.sessionCount(); got != 2 {
+		t.Fatalf("sessionCount = %d, want 2", got)
+	}
+	if _, exists := bot.getSession(3); exists {
+		t.Error("third quiz started over the limit")
+	}
+	if !slices.Contains(ft.texts(3), bot.text(3, i18n.SessionLimitReached)) {
+		t.Errorf("chat 3 was not told the bot is busy: %q", ft.texts(3))
+	}
+
+	// Перезапуск викторины в чате, где она уже идет, под лимит не попадает
+	bot.handleUpdate(textUpdate(4, 1, "/quiz 2"))
+	if got := bot.sessionCount(); got != 2 {
+		t.Errorf("sessionCount after restart = %d, want 2", got)
+	}
+
+	// Освободившееся место можно занять
+	bot.finishQuiz(1, true, nil)
+	bot.handleUpdate(textUpdate(5, 3, "/quiz 2"))
+	if _, exists := bot.getSession(3); !exists {
+		t.Error("quiz was refused after a session had been freed")
+	}
+}
+
+func TestChatLocksAreReleased(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+
+	for chatID := range int64(100) {
+		bot.dispatch(chatID, func() {})
+		bot.dispatch(chatID, func() {})
+	}
+	bot.handlers.Wait()
+
+	bot.chatLocksMu.Lock()
+	defer bot.chatLocksMu.Unlock()
+	if len(bot.chatLocks) != 0 {
+		t.Errorf("%d chat locks left after all updates were handled", len(bot.chatLocks))
+	}
+}
+
+func TestStatsUseLeaderboardStore(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	bot, _, _ := newTestBot(t, cfg)
+	const chatID = 5
+
+	bot.handleUpdate(textUpdate(1, chatID, "/quiz 1"))
+	answerCurrent(bot, chatID, 2) // первый вариант - неправильный ответ на "2 + 2?"
+	bot.preferences.Set(-chatID, service.ChatPreferences{HideLeaderboard: true})
+	bot.flushStats() // статистика ответов копится в памяти до записи по таймеру
+
+	store := bot.leaderboardService.Store()
+	for _, namespace := range []string{"mistakes", service.AnswerStatsNamespace, "preferences"} {
+		values, err := store.List(namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(values) == 0 {
+			t.Errorf("namespace %q of the leaderboard store is empty", namespace)
+		}
+	}
+}
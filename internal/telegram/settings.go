@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleSettings показывает личные настройки игрока. Уведомления приходят в личку,
+// поэтому в группах настройки не открываются. При messageID != 0 меню редактируется на месте
+func (b *Bot) handleSettings(chat *tgbotapi.Chat, messageID int) {
+	if !chat.IsPrivate() {
+		b.sendMessage(chat.ID, b.text(chat.ID, i18n.SettingsPrivateOnly))
+		return
+	}
+
+	text := b.text(chat.ID, i18n.SettingsTitle)
+	keyboard := b.settingsKeyboard(chat.ID)
+
+	if messageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chat.ID, messageID, text, keyboard)
+		if _, err := b.send(edit); err != nil {
+			b.logger.Error("editing settings failed", "chat_id", chat.ID, "err", err)
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chat.ID, text)
+	msg.ReplyMarkup = keyboard
+	if _, err := b.send(msg); err != nil {
+		b.logger.Error("sending settings failed", "chat_id", chat.ID, "err", err)
+	}
+}
+
+// settingsKeyboard - переключатели настроек с их текущими значениями
+func (b *Bot) settingsKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	overtake := i18n.ButtonOvertakeOff
+	if b.preferences.Get(chatID).NotifyOvertake {
+		overtake = i18n.ButtonOvertakeOn
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, overtake), "settings_overtake"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.text(chatID, i18n.ButtonBackToMenu), "back_to_menu"),
+		),
+	)
+}
+
+// handleToggleOvertake включает или выключает уведомления об обгоне в лидерборде
+func (b *Bot) handleToggleOvertake(chat *tgbotapi.Chat, messageID int) {
+	if !chat.IsPrivate() {
+		return
+	}
+
+	prefs := b.preferences.Get(chat.ID)
+	prefs.NotifyOvertake = !prefs.NotifyOvertake
+	b.preferences.Set(chat.ID, prefs)
+
+	b.handleSettings(chat, messageID)
+}
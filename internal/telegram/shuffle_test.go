@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// answerButtons возвращает кнопки вариантов ответа из клавиатуры последнего сообщения в чат chatID
+func answerButtons(t *testing.T, ft *fakeTelegram, chatID int64) []tgbotapi.InlineKeyboardButton {
+	t.Helper()
+	var markup string
+	for _, request := range ft.sent("sendMessage") {
+		if request.Params.Get("chat_id") == strconv.FormatInt(chatID, 10) {
+			markup = request.Params.Get("reply_markup")
+		}
+	}
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(markup), &keyboard); err != nil {
+		t.Fatalf("question keyboard %q: %v", markup, err)
+	}
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, row := range keyboard.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData != nil && strings.HasPrefix(*button.CallbackData, "quiz_") {
+				buttons = append(buttons, button)
+			}
+		}
+	}
+	return buttons
+}
+
+func TestShuffledOptionsSurviveResend(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleOptions = true
+	bot, ft, _ := newTestBot(t, cfg)
+	const chatID = 7
+	user := &tgbotapi.User{ID: chatID, FirstName: "Player"}
+
+	options := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	bot.beginQuiz(chatID, []service.QuizQuestion{
+		{ID: 1, Question: "Буква f?", Options: options, Correct: 5},
+		{ID: 2, Question: "2 + 2?", Options: []string{"3", "4"}, Correct: 1},
+	})
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+	if got := session.Questions[0].CorrectText(); got != "f" {
+		t.Fatalf("shuffled question points to %q, want f", got)
+	}
+	before := answerButtons(t, ft, chatID)
+
+	// Вопрос отправляется заново после паузы - порядок вариантов не должен меняться
+	bot.handlePause(chatID)
+	bot.handleResume(chatID, user)
+	after := answerButtons(t, ft, chatID)
+	if !slices.EqualFunc(before, after, func(a, b tgbotapi.InlineKeyboardButton) bool {
+		return a.Text == b.Text && *a.CallbackData == *b.CallbackData
+	}) {
+		t.Fatalf("options changed after resend:\nbefore %v\nafter  %v", before, after)
+	}
+
+	// Индекс из кнопки, отправленной до паузы, по-прежнему означает тот же вариант
+	index := slices.IndexFunc(before, func(b tgbotapi.InlineKeyboardButton) bool { return b.Text == "f" })
+	if index < 0 {
+		t.Fatalf("no button for the correct option in %v", before)
+	}
+	update := callbackUpdate(100, chatID, *before[index].CallbackData)
+	update.CallbackQuery.Message.MessageID = session.MessageID
+	bot.handleUpdate(update)
+
+	if len(session.Answers) != 1 || !session.Answers[0].Correct {
+		t.Errorf("answer by the resent button: %+v", session.Answers)
+	}
+}
+
+func TestSameQuestionsKeepOptionOrder(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleOptions = true
+	bot, _, _ := newTestBot(t, cfg)
+	const chatID = 7
+
+	options := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	bot.beginQuiz(chatID, []service.QuizQuestion{
+		{ID: 1, Question: "Буква f?", Options: options, Correct: 5},
+		{ID: 2, Question: "Буква b?", Options: options, Correct: 1},
+	})
+	first, _ := bot.getSession(chatID)
+	played := slices.Clone(first.Questions)
+	for i, question := range played {
+		bot.handleUpdate(lifelineUpdate(bot, i+1, chatID, "quiz_"+strconv.Itoa(i)+"_"+strconv.Itoa(question.Correct)))
+	}
+	if _, exists := bot.getSession(chatID); exists {
+		t.Fatal("quiz did not finish")
+	}
+
+	// "Те же вопросы" повторяет и порядок вопросов, и порядок вариантов в них
+	bot.handleUpdate(callbackUpdate(10, chatID, "restart_same"))
+	replay, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("replay did not start")
+	}
+	for i, question := range replay.Questions {
+		if !slices.Equal(question.Options, played[i].Options) || question.Correct != played[i].Correct {
+			t.Errorf("question %d: options %v (correct %d), played %v (correct %d)",
+				i+1, question.Options, question.Correct, played[i].Options, played[i].Correct)
+		}
+	}
+}
+
+func TestQuestionSentAtUsesBotClock(t *testing.T) {
+	bot, _, _ := newTestBot(t, testConfig(t))
+	const chatID = 7
+	sentAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	bot.now = func() time.Time { return sentAt }
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+	if !session.QuestionSentAt.Equal(sentAt) {
+		t.Errorf("QuestionSentAt = %v, want the bot clock %v", session.QuestionSentAt, sentAt)
+	}
+}
@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// splitMessage разбивает текст на части не длиннее limit символов по границам строк,
+// чтобы не разрывать HTML/Markdown разметку внутри строки. Строка длиннее limit режется по символам,
+// но не внутри HTML-тега или сущности, см. safeCut
+func splitMessage(text string, limit int) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	currentLen := 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lineLen := utf8.RuneCountInString(line)
+
+		if currentLen+lineLen > limit {
+			flush()
+		}
+
+		// Слишком длинную строку приходится резать по символам
+		for lineLen > limit {
+			runes := []rune(line)
+			cut := safeCut(runes, limit)
+			parts = append(parts, string(runes[:cut]))
+			line = string(runes[cut:])
+			lineLen -= cut
+		}
+
+		current.WriteString(line)
+		currentLen += lineLen
+	}
+	flush()
+
+	return parts
+}
+
+// safeCut возвращает, сколько первых символов runes (не больше limit) можно отрезать, не разорвав
+// тег <...> или сущность &...;: разорванную разметку Telegram отклоняет вместе с сообщением.
+// Если тег или сущность начинается с первого символа и длиннее limit, строка режется по limit
+func safeCut(runes []rune, limit int) int {
+	head := string(runes[:limit])
+	cut := limit
+	// Тег не закрыт, если после последней < нет >, сущность - если после последнего & нет ;
+	if open := strings.LastIndex(head, "<"); open > strings.LastIndex(head, ">") {
+		cut = min(cut, utf8.RuneCountInString(head[:open]))
+	}
+	if open := strings.LastIndex(head, "&"); open > strings.LastIndex(head, ";") {
+		cut = min(cut, utf8.RuneCountInString(head[:open]))
+	}
+	if cut == 0 {
+		return limit
+	}
+	return cut
+}
+
+// sendLongMessage отправляет сообщение, при необходимости разбивая его на несколько.
+// Клавиатура прикрепляется только к последней части
+func (b *Bot) sendLongMessage(msg tgbotapi.MessageConfig) error {
+	parts := splitMessage(msg.Text, b.cfg().MaxMessageLength)
+	markup := msg.ReplyMarkup
+
+	for i, part := range parts {
+		msg.Text = part
+		msg.ReplyMarkup = nil
+		if i == len(parts)-1 {
+			msg.ReplyMarkup = markup
+		}
+
+		if _, err := b.send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
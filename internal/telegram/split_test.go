@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"short", "one\ntwo", 10, []string{"one\ntwo"}},
+		{"by lines", "aaa\nbbb\nccc\n", 8, []string{"aaa\nbbb\n", "ccc\n"}},
+		{"long line", "abcdefgh\nxy", 3, []string{"abc", "def", "gh\n", "xy"}},
+		{"runes", "ёжик\nёлка", 5, []string{"ёжик\n", "ёлка"}},
+		// Длинная строка режется перед тегом или сущностью, которые не поместились целиком
+		{"tag at the boundary", "abcd<b>e</b>", 6, []string{"abcd", "<b>e", "</b>"}},
+		{"entity at the boundary", "ab &amp; cd", 5, []string{"ab ", "&amp;", " cd"}},
+		{"closed markup", "<b>a</b>bcdef", 9, []string{"<b>a</b>b", "cdef"}},
+		{"tag longer than limit", "<abcdef>", 4, []string{"<abc", "def>"}},
+	}
+	for _, tt := range tests {
+		got := splitMessage(tt.text, tt.limit)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: splitMessage = %q, want %q", tt.name, got, tt.want)
+		}
+		if strings.Join(got, "") != tt.text {
+			t.Errorf("%s: parts do not add up to the text", tt.name)
+		}
+	}
+}
+
+func TestLongLeaderboardIsSplit(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxMessageLength = 300
+	bot, ft, _ := newTestBot(t, cfg)
+
+	name := strings.Repeat("Игрок", 20)
+	for userID := int64(1); userID <= leaderboardPageSize; userID++ {
+		if _, err := bot.leaderboardService.AddEntry(userID, "", name, int(userID), leaderboardPageSize, 0, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bot.handleLeaderboard(100, 0, 100, 0, 1)
+
+	requests := ft.sent("sendMessage")
+	if len(requests) < 2 {
+		t.Fatalf("sent %d messages, want the board split into several", len(requests))
+	}
+	for i, request := range requests {
+		text := request.Params.Get("text")
+		if n := utf8.RuneCountInString(text); n > cfg.MaxMessageLength {
+			t.Errorf("part %d has %d characters, limit %d", i, n, cfg.MaxMessageLength)
+		}
+		// Разметка не должна разрываться между частями
+		if strings.Count(text, "<b>") != strings.Count(text, "</b>") {
+			t.Errorf("part %d has unbalanced tags:\n%s", i, text)
+		}
+		if hasKeyboard := request.Params.Get("reply_markup") != ""; hasKeyboard != (i == len(requests)-1) {
+			t.Errorf("part %d: keyboard attached = %t", i, hasKeyboard)
+		}
+	}
+	if got := strings.Count(strings.Join(ft.texts(100), ""), name); got != leaderboardPageSize {
+		t.Errorf("board lists %d players, want %d", got, leaderboardPageSize)
+	}
+}
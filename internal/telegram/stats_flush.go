@@ -0,0 +1,31 @@
+package telegram
+
+import (
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+)
+
+// flushStatsEvery записывает накопленную статистику ответов раз в interval, пока не закрыт done
+func (b *Bot) flushStatsEvery(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flushStats()
+		case <-done:
+			return
+		}
+	}
+}
+
+// flushStats записывает в хранилище статистику ответов, накопленную в памяти
+func (b *Bot) flushStats() {
+	for _, stats := range []*service.AnswerStats{b.answerStats, b.practiceStats} {
+		if err := stats.Flush(); err != nil {
+			b.logger.Error("flushing answer stats failed", "err", err)
+		}
+	}
+}
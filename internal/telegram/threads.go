@@ -0,0 +1,215 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Темы форумов в супергруппах: сообщения викторины должны уходить в ту тему, из которой ее начали.
+// tgbotapi v5.5.1 не знает поля message_thread_id, поэтому тема входящего обновления читается
+// из ответа getUpdates клиентом topicClient, а исходящие сообщения в тему отправляются через sendToThread
+
+// topicClient - HTTP-клиент Bot API, который запоминает темы входящих обновлений по update_id
+type topicClient struct {
+	next tgbotapi.HTTPClient
+
+	mu      sync.Mutex
+	threads map[int]int // update_id -> message_thread_id, только для сообщений в темах
+}
+
+func newTopicClient(next tgbotapi.HTTPClient) *topicClient {
+	return &topicClient{next: next, threads: make(map[int]int)}
+}
+
+func (c *topicClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.Do(req)
+	if err != nil || path.Base(req.URL.Path) != "getUpdates" {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.remember(body)
+	return resp, nil
+}
+
+// threadMessage - часть сообщения с темой форума
+type threadMessage struct {
+	MessageThreadID int `json:"message_thread_id"`
+}
+
+// remember запоминает темы обновлений из ответа getUpdates. Ответ с ошибкой разберет сама библиотека
+func (c *topicClient) remember(body []byte) {
+	var response struct {
+		Result []struct {
+			UpdateID      int            `json:"update_id"`
+			Message       *threadMessage `json:"message"`
+			EditedMessage *threadMessage `json:"edited_message"`
+			CallbackQuery *struct {
+				Message *threadMessage `json:"message"`
+			} `json:"callback_query"`
+		} `json:"result"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, update := range response.Result {
+		message := update.Message
+		if update.EditedMessage != nil {
+			message = update.EditedMessage
+		}
+		if update.CallbackQuery != nil {
+			message = update.CallbackQuery.Message
+		}
+		if message != nil && message.MessageThreadID != 0 {
+			c.threads[update.UpdateID] = message.MessageThreadID
+		}
+	}
+}
+
+// take возвращает тему обновления updateID (0 - не в теме) и забывает ее
+func (c *topicClient) take(updateID int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	threadID := c.threads[updateID]
+	delete(c.threads, updateID)
+	return threadID
+}
+
+// rememberThread запоминает тему, из которой пришло обновление: ответы бота в этот чат уходят в нее.
+// Вызывается из handleUpdate под блокировкой чата, поэтому тема соответствует обрабатываемому обновлению
+func (b *Bot) rememberThread(update tgbotapi.Update) {
+	chatID := updateChatID(update)
+	if chatID == 0 {
+		return
+	}
+
+	threadID := b.topics.take(update.UpdateID)
+	if threadID == 0 {
+		if _, exists := b.threads.get(chatID); exists {
+			b.threads.set(chatID, 0)
+		}
+		return
+	}
+	b.threads.set(chatID, threadID)
+}
+
+// chatThread возвращает тему, из которой пришло последнее обновление чата, 0 - без темы
+func (b *Bot) chatThread(chatID int64) int {
+	threadID, _ := b.threads.get(chatID)
+	return threadID
+}
+
+// send отправляет c в тему последнего обновления чата, если оно пришло из темы форума
+func (b *Bot) send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	switch msg := c.(type) {
+	case tgbotapi.MessageConfig:
+		return b.sendToThread(msg, b.chatThread(msg.ChatID))
+	case tgbotapi.PhotoConfig:
+		return b.sendPhotoToThread(msg, b.chatThread(msg.ChatID))
+	}
+	return b.api.Send(c)
+}
+
+// sendToThread отправляет сообщение в тему threadID (0 - без темы). Параметры собираются
+// так же, как в tgbotapi, с добавлением message_thread_id
+func (b *Bot) sendToThread(msg tgbotapi.MessageConfig, threadID int) (tgbotapi.Message, error) {
+	if threadID == 0 {
+		return b.api.Send(msg)
+	}
+
+	params, err := threadParams(msg.BaseChat, threadID)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	params.AddNonEmpty("text", msg.Text)
+	params.AddBool("disable_web_page_preview", msg.DisableWebPagePreview)
+	params.AddNonEmpty("parse_mode", msg.ParseMode)
+	if err := params.AddInterface("entities", msg.Entities); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return b.request("sendMessage", params)
+}
+
+// sendPhotoToThread отправляет фото в тему threadID (0 - без темы). В тему отправляются только
+// фото по ссылке или file_id: загрузка файла требует multipart-запроса, и такое фото уходит без темы
+func (b *Bot) sendPhotoToThread(photo tgbotapi.PhotoConfig, threadID int) (tgbotapi.Message, error) {
+	if threadID == 0 || photo.File.NeedsUpload() {
+		return b.api.Send(photo)
+	}
+
+	params, err := threadParams(photo.BaseChat, threadID)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	params["photo"] = photo.File.SendData()
+	params.AddNonEmpty("caption", photo.Caption)
+	params.AddNonEmpty("parse_mode", photo.ParseMode)
+	if err := params.AddInterface("caption_entities", photo.CaptionEntities); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return b.request("sendPhoto", params)
+}
+
+// sendPollToThread отправляет опрос в тему threadID (0 - без темы)
+func (b *Bot) sendPollToThread(poll tgbotapi.SendPollConfig, threadID int) (tgbotapi.Message, error) {
+	if threadID == 0 {
+		return b.api.Send(poll)
+	}
+
+	params, err := threadParams(poll.BaseChat, threadID)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	params["question"] = poll.Question
+	if err := params.AddInterface("options", poll.Options); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	params["is_anonymous"] = strconv.FormatBool(poll.IsAnonymous)
+	params.AddNonEmpty("type", poll.Type)
+	params["correct_option_id"] = strconv.FormatInt(poll.CorrectOptionID, 10)
+	params.AddNonEmpty("explanation", poll.Explanation)
+	params.AddNonEmpty("explanation_parse_mode", poll.ExplanationParseMode)
+	params.AddNonZero("open_period", poll.OpenPeriod)
+	return b.request("sendPoll", params)
+}
+
+// threadParams - общие параметры отправки в чат, как у tgbotapi, вместе с message_thread_id
+func threadParams(chat tgbotapi.BaseChat, threadID int) (tgbotapi.Params, error) {
+	params := make(tgbotapi.Params)
+	if err := params.AddFirstValid("chat_id", chat.ChatID, chat.ChannelUsername); err != nil {
+		return nil, err
+	}
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonZero("reply_to_message_id", chat.ReplyToMessageID)
+	params.AddBool("disable_notification", chat.DisableNotification)
+	params.AddBool("allow_sending_without_reply", chat.AllowSendingWithoutReply)
+	err := params.AddInterface("reply_markup", chat.ReplyMarkup)
+	return params, err
+}
+
+// request выполняет метод Bot API и разбирает отправленное сообщение
+func (b *Bot) request(method string, params tgbotapi.Params) (tgbotapi.Message, error) {
+	resp, err := b.api.MakeRequest(method, params)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	var message tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &message)
+	return message, err
+}
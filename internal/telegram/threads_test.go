@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// stubClient отвечает на любой запрос телом body
+type stubClient struct {
+	body string
+}
+
+func (c stubClient) Do(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(c.body))}, nil
+}
+
+func TestTopicClientRemembersThreads(t *testing.T) {
+	client := newTopicClient(stubClient{body: `{"ok":true,"result":[
+		{"update_id":1,"message":{"message_id":1,"message_thread_id":5,"chat":{"id":-100,"type":"supergroup"}}},
+		{"update_id":2,"message":{"message_id":2,"chat":{"id":-100,"type":"supergroup"}}},
+		{"update_id":3,"callback_query":{"id":"q","message":{"message_id":3,"message_thread_id":9,"chat":{"id":-100,"type":"supergroup"}}}}
+	]}`})
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.telegram.org/botTOKEN/getUpdates", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Тело ответа остается доступным библиотеке
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), `"update_id":3`) {
+		t.Fatalf("response body was consumed: %q", body)
+	}
+
+	for updateID, want := range map[int]int{1: 5, 2: 0, 3: 9} {
+		if got := client.take(updateID); got != want {
+			t.Errorf("take(%d) = %d, want %d", updateID, got, want)
+		}
+	}
+	if got := client.take(1); got != 0 {
+		t.Errorf("thread of update 1 returned twice: %d", got)
+	}
+}
+
+// threadIDs возвращает message_thread_id отправленных в чат chatID сообщений
+func threadIDs(ft *fakeTelegram, chatID int64) []string {
+	var ids []string
+	for _, request := range ft.sent("sendMessage") {
+		if request.Params.Get("chat_id") == strconv.FormatInt(chatID, 10) {
+			ids = append(ids, request.Params.Get("message_thread_id"))
+		}
+	}
+	return ids
+}
+
+func TestQuizStaysInThread(t *testing.T) {
+	const group, player = -100, 7
+	bot, ft, _ := newTestBot(t, testConfig(t))
+	bot.quizQuestions = []service.QuizQuestion{
+		{ID: 1, Question: "2 + 2?", Options: []string{"4", "3"}},
+		{ID: 2, Question: "3 + 3?", Options: []string{"6", "5"}},
+	}
+	inThread := func(update tgbotapi.Update, threadID int) tgbotapi.Update {
+		bot.topics.threads[update.UpdateID] = threadID
+		return update
+	}
+
+	start := groupTextUpdate(1, group, player, "/quiz")
+	start.Message.Chat.Type = "supergroup"
+	bot.handleUpdate(inThread(start, 42))
+
+	session, exists := bot.getSession(group)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+	if session.ThreadID != 42 {
+		t.Fatalf("session thread = %d, want 42", session.ThreadID)
+	}
+
+	// Команда из другой темы отвечает в свою тему, а викторина остается в своей
+	other := groupTextUpdate(2, group, player, "/pause")
+	bot.handleUpdate(inThread(other, 77))
+	resume := groupTextUpdate(3, group, player, "/resume")
+	bot.handleUpdate(inThread(resume, 77))
+
+	want := []string{"42", "77", "42"}
+	if got := threadIDs(ft, group); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("message threads = %v, want %v", got, want)
+	}
+
+	// В личных чатах и обычных группах поле не передается
+	bot.handleUpdate(textUpdate(4, player, "/start"))
+	for _, id := range threadIDs(ft, player) {
+		if id != "" {
+			t.Errorf("private message sent to thread %s", id)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// questionTimeout - истекшее время на ответ на вопрос questionIndex сессии session
+type questionTimeout struct {
+	chatID        int64
+	session       *service.QuizSession
+	questionIndex int
+}
+
+// timeLimit возвращает время на ответ: собственное ограничение вопроса или QuestionTimeLimit из конфигурации
+func (b *Bot) timeLimit(question service.QuizQuestion) time.Duration {
+	if question.TimeLimit > 0 {
+		return question.TimeLimit
+	}
+	return time.Duration(b.cfg().QuestionTimeLimit) * time.Second
+}
+
+// scheduleTimeout запускает таймер вопроса. Таймаут обрабатывается под блокировкой чата,
+// как и обновления, чтобы не пересекаться с ответом пользователя
+func (b *Bot) scheduleTimeout(chatID int64, session *service.QuizSession, questionIndex int) {
+	session.StopTimer()
+
+	limit := b.timeLimit(session.Questions[questionIndex])
+	if limit <= 0 {
+		return
+	}
+
+	timeout := questionTimeout{chatID: chatID, session: session, questionIndex: questionIndex}
+	session.Timer = time.AfterFunc(limit, func() {
+		b.dispatch(chatID, func() { b.handleQuestionTimeout(timeout) })
+	})
+}
+
+// handleQuestionTimeout засчитывает вопрос как неправильный и переходит к следующему.
+// Таймауты завершенных викторин и уже отвеченных вопросов игнорируются
+func (b *Bot) handleQuestionTimeout(timeout questionTimeout) {
+	chatID, session := timeout.chatID, timeout.session
+	if current, _ := b.getSession(chatID); current != session || session.CurrentQuestion != timeout.questionIndex ||
+		session.Paused || session.AwaitingContinue || session.CurrentAnswered {
+		return
+	}
+	session.Timer = nil
+	question := session.Questions[timeout.questionIndex]
+	_, done := b.engine.Answer(session, -1)
+
+	text := b.text(chatID, i18n.TimeUp)
+	if !b.cfg().HideCorrectAnswer {
+		text += b.text(chatID, i18n.CorrectAnswer, escapeMarkdown(question.CorrectText()))
+	}
+	text += explanationText(question)
+	resultMsg := tgbotapi.NewMessage(chatID, text)
+	resultMsg.ParseMode = "Markdown"
+
+	b.advanceQuiz(chatID, session, resultMsg, done, b.sessionPlayer(chatID, session))
+}
+
+// sessionPlayer возвращает пользователя, отвечавшего на вопросы сессии. Если ответов еще не было,
+// в личном чате игрок - собеседник (ID чата), а в группе игрок неизвестен
+func (b *Bot) sessionPlayer(chatID int64, session *service.QuizSession) *tgbotapi.User {
+	if player := session.Player; player != nil {
+		return &tgbotapi.User{ID: player.ID, UserName: player.Username, FirstName: player.FirstName}
+	}
+	if chatID > 0 {
+		return &tgbotapi.User{ID: chatID}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PoluyanbIch/GoTgBot/internal/i18n"
+	"github.com/PoluyanbIch/GoTgBot/internal/service"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// timedQuestions - вопросы, на первый из которых дается limit
+func timedQuestions(limit time.Duration) []service.QuizQuestion {
+	questions := testQuestions()
+	questions[0].TimeLimit = limit
+	return questions
+}
+
+func TestQuestionTimeout(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	bot, ft, _ := newTestBot(t, cfg)
+	bot.quizQuestions = timedQuestions(20 * time.Millisecond)
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, exists := bot.getSession(chatID)
+	if !exists {
+		t.Fatal("quiz did not start")
+	}
+
+	// Таймаут обрабатывается под блокировкой чата, поэтому сессию читаем так же
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var current int
+		dispatchAndWait(bot, chatID, func() { current = session.CurrentQuestion })
+		if current == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("question did not time out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	dispatchAndWait(bot, chatID, func() {
+		if session.Score != 0 {
+			t.Errorf("score = %d after a timeout, want 0", session.Score)
+		}
+		if len(session.Answers) != 1 || session.Answers[0].Correct {
+			t.Errorf("answers = %+v, want one wrong answer", session.Answers)
+		}
+	})
+
+	timeUp := bot.text(chatID, i18n.TimeUp)
+	// Результат заменяет сообщение с вопросом
+	edits := ft.sent("editMessageText")
+	if !slices.ContainsFunc(edits, func(r apiRequest) bool { return strings.HasPrefix(r.Params.Get("text"), timeUp) }) {
+		t.Errorf("edits %v, want %q", edits, timeUp)
+	}
+}
+
+func TestAnswerAndExitStopTheTimer(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.ShuffleQuestions = false
+	bot, _, _ := newTestBot(t, cfg)
+	bot.quizQuestions = timedQuestions(time.Hour)
+	const chatID = 7
+
+	bot.startQuiz(chatID, 0)
+	session, _ := bot.getSession(chatID)
+	timer := session.Timer
+	if timer == nil {
+		t.Fatal("timer was not scheduled")
+	}
+
+	answerCurrent(bot, chatID, 1)
+	if timer.Stop() {
+		t.Error("answering in time did not stop the timer")
+	}
+	// Опоздавший таймаут уже отвеченного вопроса ничего не меняет
+	bot.handleQuestionTimeout(questionTimeout{chatID: chatID, session: session, questionIndex: 0})
+	if session.CurrentQuestion != 1 || len(session.Answers) != 1 {
+		t.Errorf("stale timeout changed the session: question %d, %d answers", session.CurrentQuestion, len(session.Answers))
+	}
+
+	bot.quizQuestions = timedQuestions(time.Hour)
+	bot.startQuiz(chatID+1, 0)
+	session, _ = bot.getSession(chatID + 1)
+	timer = session.Timer
+	bot.finishQuiz(chatID+1, true, &tgbotapi.User{ID: chatID + 1})
+	if timer.Stop() {
+		t.Error("exiting the quiz did not stop the timer")
+	}
+}